@@ -0,0 +1,74 @@
+// Command importbookings mengimpor booking dari file .ics atau CSV langsung ke
+// database, dengan aturan validasi yang sama seperti endpoint import admin.
+//
+//	go run ./cmd/importbookings -file bookings.csv -dry-run
+package main
+
+import (
+	"backendgo/config"
+	"backendgo/services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	path := flag.String("file", "", "file .ics atau .csv yang akan diimpor")
+	format := flag.String("format", "", "ics atau csv (default: dari ekstensi file)")
+	mode := flag.String("mode", services.ImportAllOrNothing, "all_or_nothing atau best_effort")
+	dryRun := flag.Bool("dry-run", false, "hanya validasi, tidak menyimpan")
+	approve := flag.Bool("approve", false, "simpan booking dengan status approved")
+	flag.Parse()
+
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		log.Fatal("Gagal membuka file: ", err)
+	}
+	defer file.Close()
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*path)), ".")
+	}
+	var rows []services.ImportRow
+	switch *format {
+	case "csv":
+		rows, err = services.ParseBookingsCSV(file)
+	case "ics", "ical":
+		rows, err = services.ParseBookingsICS(file)
+	default:
+		log.Fatalf("Format file harus ics atau csv, bukan %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config.ConnectDatabase()
+	report, err := services.ImportBookings(rows, services.ImportOptions{DryRun: *dryRun, Mode: *mode, Approve: *approve})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+
+	fmt.Fprintf(os.Stderr, "total=%d valid=%d failed=%d imported=%d dry_run=%t\n",
+		report.Total, report.Valid, report.Failed, report.Imported, report.DryRun)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -10,7 +10,11 @@ import (
 var DB *gorm.DB
 
 func ConnectDatabase() {
-	dsn := "root:@tcp(127.0.0.1:3306)/bookingdb?charset=utf8mb4&parseTime=True&loc=Local"
+	// DSN berisi password database, jadi dibaca seperti secret lain
+	dsn := Secret("DATABASE_DSN")
+	if dsn == "" {
+		dsn = "root:@tcp(127.0.0.1:3306)/bookingdb?charset=utf8mb4&parseTime=True&loc=Local"
+	}
 	database, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database!", err)
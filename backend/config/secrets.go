@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultSecretsDir = "/run/secrets"
+
+// LookupSecret membaca secret name dari, berurutan:
+//  1. environment variable name, mis. SENDGRID_API_KEY;
+//  2. file yang path-nya ada di name_FILE, mis. SENDGRID_API_KEY_FILE;
+//  3. Docker secret SECRETS_DIR/<name huruf kecil> (default /run/secrets),
+//     mis. /run/secrets/sendgrid_api_key.
+//
+// Newline di akhir file dibuang. ok bernilai false bila secret tidak ada di
+// mana pun; error hanya dikembalikan bila file yang diminta tidak bisa dibaca.
+func LookupSecret(name string) (value string, ok bool, err error) {
+	if v := os.Getenv(name); v != "" {
+		return v, true, nil
+	}
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		v, err := readSecretFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return v, true, nil
+	}
+	dir := os.Getenv("SECRETS_DIR")
+	if dir == "" {
+		dir = defaultSecretsDir
+	}
+	v, err := readSecretFile(filepath.Join(dir, strings.ToLower(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("docker secret %s: %w", strings.ToLower(name), err)
+	}
+	return v, true, nil
+}
+
+// Secret sama dengan LookupSecret tetapi mengembalikan string kosong bila
+// secret tidak ada atau gagal dibaca; kegagalan membaca file dicatat di log.
+func Secret(name string) string {
+	v, _, err := LookupSecret(name)
+	if err != nil {
+		log.Printf("Warning: gagal membaca secret %s: %v", name, err)
+	}
+	return v
+}
+
+func readSecretFile(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/services"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordAudit mencatat perubahan yang sudah berhasil dengan aktor, IP dan
+// user-agent dari request. Actor entry yang kosong diisi dari AuthMiddleware.
+// Kegagalan mencatat tidak membatalkan respons, hanya di-log.
+func recordAudit(c *gin.Context, entry services.AuditEntry) {
+	if entry.Actor.ID == nil && entry.Actor.Role == "" {
+		entry.Actor = actorFromContext(c)
+	}
+	if entry.Actor.ID == nil && entry.Actor.Role == "" {
+		entry.Actor.Role = "anonymous"
+	}
+	entry.IP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	if err := services.RecordAudit(config.DB, entry); err != nil {
+		log.Errorf("Failed to record audit log %s %s: %v", entry.Action, entry.EntityID, err)
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary List audit logs
+// @Description List administrative and booking changes, newest first
+// @Tags audit
+// @Produce  json
+// @Param   actor_id     query  string  false  "Actor (admin) ID"
+// @Param   action       query  string  false  "Action, e.g. booking.approve, or a prefix such as booking"
+// @Param   entity_type  query  string  false  "booking, booking_series, room, user, session, email_template, calendar_feed, notification or retention"
+// @Param   entity_id    query  string  false  "Entity ID"
+// @Param   from         query  string  false  "From (RFC3339)"
+// @Param   to           query  string  false  "To (RFC3339)"
+// @Param   limit        query  int     false  "Page size (default 50, max 200)"
+// @Param   offset       query  int     false  "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/audit-logs [get]
+func ListAuditLogs(c *gin.Context) {
+	q, err := parseAuditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	logs, total, err := services.ListAuditLogs(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil audit log", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Audit log berhasil diambil", "data": gin.H{"logs": logs, "total": total}})
+}
+
+// ExportAuditLogs godoc
+// @Summary Export audit logs as CSV
+// @Description Download every audit log matching the filters as CSV (same filters as the list endpoint, without paging)
+// @Tags audit
+// @Produce  text/csv
+// @Param   actor_id     query  string  false  "Actor (admin) ID"
+// @Param   action       query  string  false  "Action or action prefix"
+// @Param   entity_type  query  string  false  "Entity type"
+// @Param   entity_id    query  string  false  "Entity ID"
+// @Param   from         query  string  false  "From (RFC3339)"
+// @Param   to           query  string  false  "To (RFC3339)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/admin/audit-logs/export [get]
+func ExportAuditLogs(c *gin.Context) {
+	q, err := parseAuditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	filename := fmt.Sprintf("audit-log-%s.csv", time.Now().UTC().Format("20060102T150405Z"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+	// Header sudah terkirim, jadi error di tengah jalan hanya bisa di-log
+	if err := services.ExportAuditLogsCSV(q, c.Writer); err != nil {
+		log.Errorf("Failed to export audit logs: %v", err)
+	}
+}
+
+func parseAuditQuery(c *gin.Context) (services.AuditQuery, error) {
+	q := services.AuditQuery{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+	if id := c.Query("actor_id"); id != "" {
+		actorID, err := uuid.Parse(id)
+		if err != nil {
+			return q, fmt.Errorf("format actor_id tidak valid")
+		}
+		q.ActorID = &actorID
+	}
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return q, fmt.Errorf("parameter from tidak valid (format RFC3339)")
+		}
+		q.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return q, fmt.Errorf("parameter to tidak valid (format RFC3339)")
+		}
+		q.To = &t
+	}
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+	q.Offset, _ = strconv.Atoi(c.Query("offset"))
+	return q, nil
+}
+
+// auditSnapshot membaca data entitas sebelum diubah untuk dibandingkan di
+// audit log. model harus pointer, mis. &models.Booking{}; nil bila tidak ada.
+func auditSnapshot(model interface{}, id uuid.UUID) interface{} {
+	if err := config.DB.Unscoped().First(model, id).Error; err != nil {
+		return nil
+	}
+	return model
+}
@@ -5,22 +5,27 @@ import (
 	"backendgo/models"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"backendgo/services"
+	"errors"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
-)
+	"gorm.io/gorm"
 
-var jwtKey = []byte("your_secret_key")
+	log "github.com/sirupsen/logrus"
+)
 
 type RegisterInput struct {
 	Email    string `json:"email" binding:"required"`
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// Role staf, default super_admin. room_ids wajib untuk room_manager dan approver.
+	Role    string      `json:"role"`
+	RoomIDs []uuid.UUID `json:"room_ids"`
 }
 
 type LoginInput struct {
@@ -28,10 +33,12 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
-type Claims struct {
-	ID   uuid.UUID `json:"id"`
-	Role string    `json:"role"`
-	jwt.RegisteredClaims
+type RegisterRequesterInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+	Locale   string `json:"locale" binding:"omitempty,oneof=id en"`
 }
 
 type ForgotPasswordInput struct {
@@ -46,7 +53,7 @@ type ResetPasswordInput struct {
 
 // RegisterAdmin godoc
 // @Summary Register admin
-// @Description Register a new admin user
+// @Description Register a new staff user. role defaults to super_admin; room_ids is required for room_manager and approver.
 // @Tags auth
 // @Accept  json
 // @Produce  json
@@ -62,24 +69,42 @@ func RegisterAdmin(c *gin.Context) {
 		return
 	}
 
+	if input.Role == "" {
+		input.Role = models.RoleSuperAdmin
+	}
+	if !models.IsStaffRole(input.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": services.ErrInvalidRole.Error(), "data": nil})
+		return
+	}
+	if models.IsRoomScopedRole(input.Role) && len(input.RoomIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "room_ids wajib diisi untuk role " + input.Role, "data": nil})
+		return
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to hash password", "data": nil})
 		return
 	}
 
-	user := models.User{Email: input.Email, Username: input.Username, Password: string(hashedPassword), Role: "admin"}
-	if err := config.DB.Create(&user).Error; err != nil {
+	user := models.User{Email: input.Email, Username: input.Username, Password: string(hashedPassword), Role: input.Role}
+	created, err := services.CreateStaffUser(&user, input.RoomIDs)
+	if errors.Is(err, services.ErrRoomNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Username already exists", "data": nil})
 		return
 	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditAdminRegister, EntityType: services.AuditEntityUser, EntityID: user.ID.String(), After: created})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Admin registered successfully", "data": nil})
 }
 
 // LoginAdmin godoc
 // @Summary Login admin
-// @Description Authenticate admin and return JWT token
+// @Description Authenticate a staff user and return a short-lived access token and a refresh token. Local accounts are checked with bcrypt; LDAP accounts, and unknown usernames when LDAP is configured, are checked with an LDAP bind.
 // @Tags auth
 // @Accept  json
 // @Produce  json
@@ -87,7 +112,9 @@ func RegisterAdmin(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /api/auth/login [post]
 func LoginAdmin(c *gin.Context) {
 	var input LoginInput
@@ -96,33 +123,184 @@ func LoginAdmin(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := config.DB.Where("username = ? AND role = ?", input.Username, "admin").First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid credentials", "data": nil})
+	user, err := authenticateStaff(input.Username, input.Password)
+	if err != nil {
+		recordAudit(c, services.AuditEntry{Action: services.AuditLoginFailed, EntityType: services.AuditEntityUser, After: gin.H{"username": input.Username}})
+		switch {
+		case errors.Is(err, services.ErrUserDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Akun dinonaktifkan", "data": nil})
+		case errors.Is(err, errNotStaff), errors.Is(err, services.ErrExternalStaffLink):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": err.Error(), "data": nil})
+		case errors.Is(err, errInvalidCredentials), errors.Is(err, services.ErrLDAPInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid credentials", "data": nil})
+		default:
+			log.WithError(err).Error("Login: gagal autentikasi ke LDAP")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "Server autentikasi tidak dapat dihubungi", "data": nil})
+		}
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid credentials", "data": nil})
+	tokens, err := issueSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to generate token", "data": nil})
 		return
 	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditLogin, EntityType: services.AuditEntityUser, EntityID: user.ID.String()})
 
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &Claims{
-		ID:   user.ID,
-		Role: user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-		},
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "data": tokens})
+}
+
+// RegisterRequester godoc
+// @Summary Register requester
+// @Description Create a requester account so bookings can be viewed, cancelled and rescheduled without an admin
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   input  body  RegisterRequesterInput  true  "Requester registration info"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/auth/register [post]
+func RegisterRequester(c *gin.Context) {
+	var input RegisterRequesterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to generate token", "data": nil})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memproses password", "data": nil})
 		return
 	}
+	locale := input.Locale
+	if locale == "" {
+		locale = services.NormalizeLocale(c.GetHeader("Accept-Language"))
+	}
+	user := models.User{
+		Email:    strings.ToLower(strings.TrimSpace(input.Email)),
+		Username: input.Username,
+		Name:     input.Name,
+		Password: string(hashedPassword),
+		Role:     models.RoleRequester,
+		Locale:   locale,
+	}
+	if err := config.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Username atau email sudah terdaftar", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditRequesterRegister, EntityType: services.AuditEntityUser, EntityID: user.ID.String(), After: user})
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login successful", "data": gin.H{"token": tokenString}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Akun berhasil dibuat", "data": user})
+}
+
+// LoginRequester godoc
+// @Summary Login requester
+// @Description Authenticate a requester by username or email and return a short-lived access token and a refresh token
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   input  body  LoginInput  true  "Requester login info (username may be an email)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/login [post]
+func LoginRequester(c *gin.Context) {
+	var input LoginInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	var user models.User
+	err := config.DB.Where("(username = ? OR email = ?) AND role = ?", input.Username, strings.ToLower(input.Username), models.RoleRequester).First(&user).Error
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
+	}
+	if err != nil {
+		recordAudit(c, services.AuditEntry{Action: services.AuditLoginFailed, EntityType: services.AuditEntityUser, After: gin.H{"username": input.Username, "role": models.RoleRequester}})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Username atau password salah", "data": nil})
+		return
+	}
+	if user.IsDisabled() {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Akun dinonaktifkan", "data": nil})
+		return
+	}
+
+	tokens, err := issueSession(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat token", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditLogin, EntityType: services.AuditEntityUser, EntityID: user.ID.String()})
+
+	tokens.User = &user
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Login berhasil", "data": tokens})
+}
+
+var (
+	errInvalidCredentials = errors.New("invalid credentials")
+	errNotStaff           = errors.New("Akun tidak punya akses admin")
+)
+
+// authenticateStaff memeriksa password user staf lokal dengan bcrypt. User
+// LDAP, dan username yang belum ada di database saat LDAP aktif, diperiksa
+// dengan bind ke directory.
+func authenticateStaff(username, password string) (*models.User, error) {
+	var user models.User
+	err := config.DB.Where("username = ? AND role IN ?", username, models.StaffRoles).First(&user).Error
+	if err == nil && user.AuthProvider != models.AuthProviderLDAP {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			return nil, errInvalidCredentials
+		}
+		if user.IsDisabled() {
+			return nil, services.ErrUserDisabled
+		}
+		return &user, nil
+	}
+	if !services.LDAP().Enabled() {
+		return nil, errInvalidCredentials
+	}
+	ldapUser, err := services.LDAP().Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	if !models.IsStaffRole(ldapUser.Role) {
+		return nil, errNotStaff
+	}
+	return ldapUser, nil
+}
+
+// TokenResponse adalah pasangan token yang dikirim ke client setelah login
+// atau refresh. Key "token" tetap dipakai agar client lama tidak berubah.
+type TokenResponse struct {
+	Token        string       `json:"token"`
+	TokenType    string       `json:"token_type"`
+	ExpiresIn    int          `json:"expires_in"`
+	RefreshToken string       `json:"refresh_token"`
+	User         *models.User `json:"user,omitempty"`
+}
+
+func newTokenResponse(accessToken string, ttl time.Duration, refreshToken string) *TokenResponse {
+	return &TokenResponse{Token: accessToken, TokenType: "Bearer", ExpiresIn: int(ttl.Seconds()), RefreshToken: refreshToken}
+}
+
+// issueSession membuat sesi baru untuk user yang berhasil login dan
+// mengembalikan access token serta refresh token-nya.
+func issueSession(c *gin.Context, user *models.User) (*TokenResponse, error) {
+	session, refreshToken, err := services.CreateSession(user.ID, sessionClient(c))
+	if err != nil {
+		return nil, err
+	}
+	accessToken, ttl, err := services.IssueAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenResponse(accessToken, ttl, refreshToken), nil
+}
+
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
 }
 
 // ForgotPassword godoc
@@ -143,7 +321,7 @@ func ForgotPassword(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.Where("email = ? AND role = ?", input.Email, "admin").First(&user).Error; err != nil {
+	if err := config.DB.Where("email = ? AND role IN ?", input.Email, models.StaffRoles).First(&user).Error; err != nil {
 		// Untuk keamanan, selalu response sukses walau email tidak ditemukan
 		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Jika email terdaftar, OTP telah dikirim."})
 		return
@@ -151,17 +329,22 @@ func ForgotPassword(c *gin.Context) {
 
 	// Generate OTP 6 digit
 	otp := fmt.Sprintf("%06d", time.Now().UnixNano()%1000000)
-	expiry := time.Now().Add(10 * time.Minute)
+	expiry := time.Now().Add(services.ResetOTPValidity)
 	user.ResetOTP = otp
 	user.ResetOTPExpiry = &expiry
-	if err := config.DB.Save(&user).Error; err != nil {
+	// OTP dan email-nya disimpan bersamaan agar OTP tidak pernah tersimpan tanpa terkirim
+	emailService := c.MustGet("emailService").(*services.EmailService)
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return emailService.QueueOTPEmail(tx, user.Email, otp)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan OTP", "data": nil})
 		return
 	}
-
-	// Kirim OTP ke email
-	emailService := c.MustGet("emailService").(*services.EmailService)
-	go emailService.SendOTPEmail(user.Email, otp)
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditPasswordForgot, EntityType: services.AuditEntityUser, EntityID: user.ID.String()})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Jika email terdaftar, OTP telah dikirim."})
 }
@@ -184,7 +367,7 @@ func ResetPassword(c *gin.Context) {
 	}
 
 	var user models.User
-	if err := config.DB.Where("email = ? AND role = ?", input.Email, "admin").First(&user).Error; err != nil {
+	if err := config.DB.Where("email = ? AND role IN ?", input.Email, models.StaffRoles).First(&user).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "OTP tidak valid atau sudah expired", "data": nil})
 		return
 	}
@@ -206,5 +389,10 @@ func ResetPassword(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal update password", "data": nil})
 		return
 	}
+	// Sesi yang mungkin dipegang orang lain ikut berakhir setelah password diganti
+	if _, err := services.RevokeUserSessions(user.ID, services.SessionRevokedPassword); err != nil {
+		log.WithError(err).Error("Reset password: gagal mencabut sesi")
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditPasswordReset, EntityType: services.AuditEntityUser, EntityID: user.ID.String()})
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password berhasil direset. Silakan login dengan password baru."})
 }
@@ -6,11 +6,12 @@ import (
 	"backendgo/services"
 	"fmt"
 	"net/http"
-	"os"
-	"strings"
+	"strconv"
 	"time"
 
 	"encoding/base64"
+	"errors"
+	"io"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -23,36 +24,119 @@ type BookingHandler struct {
 	EmailService *services.EmailService
 }
 
-type UpdateBookingInput struct {
-	RoomID    string    `json:"room_id"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
-	Status    string    `json:"status"`
-	Purpose   string    `json:"purpose"`
+type BookingConflictSlot struct {
+	StartTime time.Time            `json:"start_time"`
+	EndTime   time.Time            `json:"end_time"`
+	Status    models.BookingStatus `json:"status"`
+}
+
+type BookingStatusInput struct {
+	Reason string `json:"reason"`
 }
 
 type BookingResponse struct {
-	ID              uuid.UUID  `json:"id"`
-	RoomID          uuid.UUID  `json:"room_id"`
-	UserEmail       string     `json:"user_email"`
-	UserName        string     `json:"user_name"`
-	RoomName        string     `json:"room_name"`
-	Purpose         string     `json:"purpose"`
-	Attendees       int        `json:"attendees"`
-	StartTime       time.Time  `json:"start_time"`
-	EndTime         time.Time  `json:"end_time"`
-	Status          string     `json:"status"`
-	QRCodeToken     string     `json:"qr_code_token"`
-	CreatedAt       time.Time  `json:"created_at"`
-	QRCodeBase64    string     `json:"qr_code_base64,omitempty"`
-	IsOvertime      bool       `json:"is_overtime"`
-	OvertimeMinutes int        `json:"overtime_minutes,omitempty"`
-	ExtendedUntil   *time.Time `json:"extended_until,omitempty"`
+	ID              uuid.UUID            `json:"id"`
+	RoomID          uuid.UUID            `json:"room_id"`
+	UserEmail       string               `json:"user_email"`
+	UserName        string               `json:"user_name"`
+	RoomName        string               `json:"room_name"`
+	Purpose         string               `json:"purpose"`
+	Attendees       int                  `json:"attendees"`
+	StartTime       time.Time            `json:"start_time"`
+	EndTime         time.Time            `json:"end_time"`
+	Status          models.BookingStatus `json:"status"`
+	CreatedAt       time.Time            `json:"created_at"`
+	QRCodeBase64    string               `json:"qr_code_base64,omitempty"`
+	ExtendQRBase64  string               `json:"extend_qr_code_base64,omitempty"`
+	IsOvertime      bool                 `json:"is_overtime"`
+	OvertimeMinutes int                  `json:"overtime_minutes,omitempty"`
+	ExtendedMinutes int                  `json:"extended_minutes,omitempty"`
+	CheckedInAt     *time.Time           `json:"checked_in_at,omitempty"`
+	EndedAt         *time.Time           `json:"ended_at,omitempty"`
+}
+
+// bookingViewer adalah pemanggil endpoint booking publik. Token QR akhiri dan
+// perpanjang meeting hanya diberikan ke pemilik booking atau staf yang boleh
+// mengelola ruangannya.
+type bookingViewer struct {
+	access *services.Access
+}
+
+// viewerFromContext membaca user yang login lewat OptionalAuthMiddleware.
+// Pengunjung anonim atau akun nonaktif tidak mendapat token apa pun.
+func viewerFromContext(c *gin.Context) bookingViewer {
+	id, ok := c.Get("id")
+	if !ok {
+		return bookingViewer{}
+	}
+	access, err := services.LoadAccess(id.(uuid.UUID))
+	if err != nil {
+		return bookingViewer{}
+	}
+	return bookingViewer{access: access}
+}
+
+func (v bookingViewer) canControl(b *models.Booking) bool {
+	if v.access == nil {
+		return false
+	}
+	if b.UserID != nil && *b.UserID == v.access.UserID {
+		return true
+	}
+	return v.access.CanForRoom(models.PermBookingsManage, b.RoomID)
+}
+
+// newBookingResponse menyusun response booking. Meeting yang masih berjalan
+// melewati EndTime mendapat QR untuk mengakhiri dan memperpanjangnya bila
+// viewer boleh mengendalikannya; overtime meeting yang sudah diakhiri diambil
+// dari nilai yang tersimpan.
+func newBookingResponse(b *models.Booking, now time.Time, viewer bookingViewer) BookingResponse {
+	response := BookingResponse{
+		ID:              b.ID,
+		RoomID:          b.RoomID,
+		UserName:        b.UserName,
+		UserEmail:       b.UserEmail,
+		Purpose:         b.Purpose,
+		Attendees:       b.Attendees,
+		StartTime:       b.StartTime,
+		EndTime:         b.EndTime,
+		Status:          b.Status,
+		CreatedAt:       b.CreatedAt,
+		RoomName:        b.Room.Name,
+		OvertimeMinutes: b.OvertimeMinutes,
+		ExtendedMinutes: b.ExtendedMinutes,
+		CheckedInAt:     b.CheckedInAt,
+		EndedAt:         b.EndedAt,
+	}
+	running := b.EndedAt == nil && (b.Status == models.BookingApproved || b.Status == models.BookingCheckedIn)
+	if running && now.After(b.EndTime) {
+		response.IsOvertime = true
+		response.OvertimeMinutes = int(now.Sub(b.EndTime).Minutes())
+		if viewer.canControl(b) {
+			response.QRCodeBase64 = bookingActionQR(b, services.TokenActionEnd)
+			response.ExtendQRBase64 = bookingActionQR(b, services.TokenActionExtend)
+		}
+	}
+	return response
+}
+
+// bookingActionQR mengembalikan QR (PNG base64) berisi URL aksi token, atau
+// string kosong bila gagal dibuat.
+func bookingActionQR(b *models.Booking, action string) string {
+	actionURL, err := services.BookingActionURL(b, action)
+	if err != nil {
+		return ""
+	}
+	qr, err := qrcode.Encode(actionURL, qrcode.Medium, 256)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(qr)
 }
 
 // GetBookings godoc
 // @Summary Get all bookings
-// @Description Get list of bookings with optional pagination, room, and status filter
+// @Description Get list of bookings with optional pagination, room, and status filter. QR codes for ending or extending an overtime meeting are only included for the booking owner or staff who can manage the room.
 // @Tags booking
 // @Accept  json
 // @Produce  json
@@ -84,7 +168,7 @@ func (h *BookingHandler) GetBookings(c *gin.Context) {
 	status := c.Query("status")
 
 	var bookings []models.Booking
-	query := config.DB.Preload("Room")
+	query := config.DB.Preload("Room", services.IncludeDeleted)
 
 	if roomID != "" {
 		if roomUUID, err := uuid.Parse(roomID); err == nil {
@@ -101,46 +185,11 @@ func (h *BookingHandler) GetBookings(c *gin.Context) {
 	}
 
 	// Convert to response format with QR codes
+	now := time.Now()
+	viewer := viewerFromContext(c)
 	var bookingsWithQR []BookingResponse
-	for _, b := range bookings {
-		var qrBase64 string
-		isOvertime := false
-		overtimeMinutes := 0
-		var extendedUntil *time.Time
-		now := time.Now()
-		if b.Status == "approved" && now.After(b.EndTime) {
-			isOvertime = true
-			overtimeMinutes = int(now.Sub(b.EndTime).Minutes())
-			t := now
-			extendedUntil = &t
-			deleteURL := fmt.Sprintf("http://localhost:8080/api/bookings/delete/%s", b.QRCodeToken)
-			qr, err := qrcode.Encode(deleteURL, qrcode.Medium, 256)
-			if err == nil {
-				qrBase64 = base64.StdEncoding.EncodeToString(qr)
-			}
-		}
-		roomName := ""
-		if b.Room.Name != "" {
-			roomName = b.Room.Name
-		}
-		bookingsWithQR = append(bookingsWithQR, BookingResponse{
-			ID:              b.ID,
-			RoomID:          b.RoomID,
-			UserName:        b.UserName,
-			UserEmail:       b.UserEmail,
-			Purpose:         b.Purpose,
-			Attendees:       b.Attendees,
-			StartTime:       b.StartTime,
-			EndTime:         b.EndTime,
-			Status:          b.Status,
-			QRCodeToken:     b.QRCodeToken,
-			CreatedAt:       b.CreatedAt,
-			QRCodeBase64:    qrBase64,
-			RoomName:        roomName,
-			IsOvertime:      isOvertime,
-			OvertimeMinutes: overtimeMinutes,
-			ExtendedUntil:   extendedUntil,
-		})
+	for i := range bookings {
+		bookingsWithQR = append(bookingsWithQR, newBookingResponse(&bookings[i], now, viewer))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data booking berhasil diambil", "data": bookingsWithQR})
@@ -148,7 +197,7 @@ func (h *BookingHandler) GetBookings(c *gin.Context) {
 
 // GetBookingByID godoc
 // @Summary Get booking detail
-// @Description Get detail of a booking by ID
+// @Description Get detail of a booking by ID. QR codes for ending or extending an overtime meeting are only included for the booking owner or staff who can manage the room.
 // @Tags booking
 // @Accept  json
 // @Produce  json
@@ -166,46 +215,12 @@ func (h *BookingHandler) GetBookingByID(c *gin.Context) {
 	}
 
 	var booking models.Booking
-	if err := config.DB.Preload("Room").First(&booking, bookingUUID).Error; err != nil {
+	if err := config.DB.Preload("Room", services.IncludeDeleted).First(&booking, bookingUUID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
 		return
 	}
 
-	// Generate QR code if meeting has ended
-	var qrBase64 string
-	isOvertime := false
-	overtimeMinutes := 0
-	var extendedUntil *time.Time
-	now := time.Now()
-	if booking.Status == "approved" && now.After(booking.EndTime) {
-		isOvertime = true
-		overtimeMinutes = int(now.Sub(booking.EndTime).Minutes())
-		t := now
-		extendedUntil = &t
-		deleteURL := fmt.Sprintf("http://localhost:8080/api/bookings/delete/%s", booking.QRCodeToken)
-		qr, err := qrcode.Encode(deleteURL, qrcode.Medium, 256)
-		if err == nil {
-			qrBase64 = base64.StdEncoding.EncodeToString(qr)
-		}
-	}
-	response := BookingResponse{
-		ID:              booking.ID,
-		RoomID:          booking.RoomID,
-		UserName:        booking.UserName,
-		UserEmail:       booking.UserEmail,
-		Purpose:         booking.Purpose,
-		Attendees:       booking.Attendees,
-		StartTime:       booking.StartTime,
-		EndTime:         booking.EndTime,
-		Status:          booking.Status,
-		QRCodeToken:     booking.QRCodeToken,
-		CreatedAt:       booking.CreatedAt,
-		QRCodeBase64:    qrBase64,
-		RoomName:        booking.Room.Name,
-		IsOvertime:      isOvertime,
-		OvertimeMinutes: overtimeMinutes,
-		ExtendedUntil:   extendedUntil,
-	}
+	response := newBookingResponse(&booking, time.Now(), viewerFromContext(c))
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data booking berhasil diambil", "data": response})
 }
@@ -219,6 +234,7 @@ func (h *BookingHandler) GetBookingByID(c *gin.Context) {
 // @Param   input  body  models.CreateBookingInput  true  "Booking info"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/bookings [post]
 func (h *BookingHandler) CreateBooking(c *gin.Context) {
@@ -235,34 +251,89 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 		return
 	}
 
+	// Bahasa email mengikuti browser pemesan bila tidak dipilih
+	if input.Locale == "" {
+		input.Locale = services.NormalizeLocale(c.GetHeader("Accept-Language"))
+	}
+	input.UserID = requesterIDFromContext(c)
+
 	// Panggil service untuk logic utama
-	booking, err := services.CreateBookingService(input)
-	if err != nil {
-		log.Errorf("Failed to create booking: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
-		return
-	}
-	// Kirim email notifikasi ke user (dan admin)
-	go func() {
-		var room models.Room
-		if err := config.DB.First(&room, booking.RoomID).Error; err == nil {
-			h.EmailService.SendBookingNotification(booking, &room, "")
-			// Notifikasi ke admin
-			adminEmails := os.Getenv("ADMIN_EMAIL")
-			if adminEmails != "" {
-				emails := strings.Split(adminEmails, ",")
-				for _, adminEmail := range emails {
-					adminEmail = strings.TrimSpace(adminEmail)
-					if adminEmail != "" {
-						h.EmailService.SendBookingNotificationToAdmin(booking, &room, adminEmail)
-					}
-				}
-			}
+	booking, err := services.CreateBookingService(input, h.EmailService)
+	var conflictErr *services.ConflictError
+	if errors.As(err, &conflictErr) {
+		// Endpoint publik: hanya tampilkan slot yang terisi, bukan data pemesan lain
+		slots := make([]BookingConflictSlot, 0, len(conflictErr.Conflicts))
+		for _, b := range conflictErr.Conflicts {
+			slots = append(slots, BookingConflictSlot{StartTime: b.StartTime, EndTime: b.EndTime, Status: b.Status})
+		}
+		// Tawarkan alternatif agar pemesan tidak perlu menebak slot lain
+		roomUUID, _ := uuid.Parse(input.RoomID)
+		suggestions, sErr := services.SuggestAlternatives(services.SuggestionQuery{
+			RoomID:    roomUUID,
+			Start:     input.StartTime,
+			End:       input.EndTime,
+			Attendees: input.Attendees,
+		})
+		if sErr != nil {
+			log.Warnf("Failed to compute booking suggestions: %v", sErr)
 		}
-	}()
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": gin.H{"conflicts": slots, "suggestions": suggestions}})
+		return
+	}
+	if err != nil {
+		respondBookingError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingCreate, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), After: booking})
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil dibuat", "data": booking})
 }
 
+// GetBookingSuggestions godoc
+// @Summary Suggest alternative booking slots
+// @Description Rank alternatives for a requested slot: the same room at the nearest free times and other rooms with enough capacity at the requested time
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param   start      query  string  true   "Requested start (RFC3339)"
+// @Param   end        query  string  true   "Requested end (RFC3339)"
+// @Param   room_id    query  string  false  "Preferred room ID"
+// @Param   attendees  query  int     false  "Number of attendees"
+// @Param   limit      query  int     false  "Maximum number of suggestions (default 5)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/bookings/suggestions [get]
+func (h *BookingHandler) GetBookingSuggestions(c *gin.Context) {
+	var q services.SuggestionQuery
+	var err error
+	if q.Start, err = time.Parse(time.RFC3339, c.Query("start")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Parameter start tidak valid (format RFC3339)", "data": nil})
+		return
+	}
+	if q.End, err = time.Parse(time.RFC3339, c.Query("end")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Parameter end tidak valid (format RFC3339)", "data": nil})
+		return
+	}
+	if roomID := c.Query("room_id"); roomID != "" {
+		if q.RoomID, err = uuid.Parse(roomID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID ruangan tidak valid", "data": nil})
+			return
+		}
+	}
+	if a := c.Query("attendees"); a != "" {
+		fmt.Sscanf(a, "%d", &q.Attendees)
+	}
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &q.Limit)
+	}
+
+	suggestions, err := services.SuggestAlternatives(q)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Saran booking berhasil diambil", "data": suggestions})
+}
+
 // ApproveBooking godoc
 // @Summary Approve booking
 // @Description Approve a booking by ID
@@ -273,6 +344,7 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/bookings/approve/{id} [patch]
 func (h *BookingHandler) ApproveBooking(c *gin.Context) {
@@ -282,39 +354,23 @@ func (h *BookingHandler) ApproveBooking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
 		return
 	}
-
-	var booking models.Booking
-	if err := config.DB.First(&booking, bookingUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+	if !authorizeBooking(c, models.PermBookingsApprove, bookingUUID) {
 		return
 	}
-	if booking.Status == "approved" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Booking sudah disetujui", "data": nil})
+
+	var input BookingStatusInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
 		return
 	}
 
-	oldStatus := booking.Status
-	booking.Status = "approved"
-	if err := config.DB.Save(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyetujui booking", "data": nil})
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, _, err := services.ApproveBookingService(bookingUUID, actorFromContext(c), input.Reason, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusInternalServerError)
 		return
 	}
-
-	// Send email notification for status update
-	go func() {
-		var room models.Room
-		if err := config.DB.First(&room, booking.RoomID).Error; err == nil {
-			qrBase64 := ""
-			if booking.Status == "approved" {
-				deleteURL := fmt.Sprintf("http://localhost:8080/api/bookings/delete/%s", booking.QRCodeToken)
-				qr, err := qrcode.Encode(deleteURL, qrcode.Medium, 256)
-				if err == nil {
-					qrBase64 = base64.StdEncoding.EncodeToString(qr)
-				}
-			}
-			h.EmailService.SendBookingStatusUpdate(&booking, &room, oldStatus, qrBase64)
-		}
-	}()
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingApprove, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil disetujui", "data": booking})
 }
@@ -338,33 +394,23 @@ func (h *BookingHandler) RejectBooking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
 		return
 	}
-
-	var booking models.Booking
-	if err := config.DB.First(&booking, bookingUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+	if !authorizeBooking(c, models.PermBookingsApprove, bookingUUID) {
 		return
 	}
-	if booking.Status == "rejected" {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Booking sudah ditolak", "data": nil})
+
+	var input BookingStatusInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
 		return
 	}
 
-	oldStatus := booking.Status
-	booking.Status = "rejected"
-	if err := config.DB.Save(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menolak booking", "data": nil})
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, _, err := services.RejectBookingService(bookingUUID, actorFromContext(c), input.Reason, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusInternalServerError)
 		return
 	}
-
-	// Send email notification for status update
-	go func() {
-		var room models.Room
-		if err := config.DB.First(&room, booking.RoomID).Error; err == nil {
-			qrBase64 := ""
-			// QR code hanya untuk status approved, jadi kosong di reject
-			h.EmailService.SendBookingStatusUpdate(&booking, &room, oldStatus, qrBase64)
-		}
-	}()
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingReject, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil ditolak", "data": booking})
 }
@@ -376,10 +422,11 @@ func (h *BookingHandler) RejectBooking(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @Param   id     path  string  true  "Booking ID"
-// @Param   input  body  UpdateBookingInput  true  "Booking info"
+// @Param   input  body  models.UpdateBookingInput  true  "Booking info"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/bookings/{id} [put]
 func (h *BookingHandler) UpdateBooking(c *gin.Context) {
@@ -389,50 +436,63 @@ func (h *BookingHandler) UpdateBooking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
 		return
 	}
-
-	var booking models.Booking
-	if err := config.DB.First(&booking, bookingUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+	if !authorizeBooking(c, models.PermBookingsManage, bookingUUID) {
 		return
 	}
 
-	var input UpdateBookingInput
+	var input models.UpdateBookingInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
 		return
 	}
 
-	if input.RoomID != "" && input.RoomID != "null" {
-		roomUUID, err := uuid.Parse(input.RoomID)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID ruangan tidak valid", "data": nil})
-			return
-		}
-		booking.RoomID = roomUUID
-	}
-	if !input.StartTime.IsZero() {
-		booking.StartTime = input.StartTime
+	// Room manager hanya boleh memindahkan booking ke ruangan yang ia kelola
+	if newRoomID, err := uuid.Parse(input.RoomID); err == nil && !authorizeRooms(c, models.PermBookingsManage, newRoomID) {
+		return
 	}
-	if !input.EndTime.IsZero() {
-		booking.EndTime = input.EndTime
+
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, err := services.UpdateBookingService(bookingUUID, input, actorFromContext(c), h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
 	}
-	if input.Status != "" {
-		booking.Status = input.Status
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingUpdate, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil diperbarui", "data": booking})
+}
+
+// GetBookingHistory godoc
+// @Summary Get booking status history
+// @Description Get every status transition of a booking with actor, timestamp and reason
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param   id  path  string  true  "Booking ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/bookings/{id}/history [get]
+func (h *BookingHandler) GetBookingHistory(c *gin.Context) {
+	bookingUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
+		return
 	}
-	if input.Purpose != "" {
-		booking.Purpose = input.Purpose
+	if !authorizeBooking(c, models.PermBookingsView, bookingUUID) {
+		return
 	}
 
-	if err := config.DB.Save(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui booking", "data": nil})
+	history, err := services.GetBookingStatusHistory(config.DB, bookingUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil riwayat booking", "data": nil})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil diperbarui", "data": booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Riwayat booking berhasil diambil", "data": history})
 }
 
 // DeleteBooking godoc
 // @Summary Delete booking
-// @Description Delete a booking by ID
+// @Description Soft delete a booking by ID; it can be restored via /api/bookings/{id}/restore
 // @Tags booking
 // @Accept  json
 // @Produce  json
@@ -449,40 +509,128 @@ func (h *BookingHandler) DeleteBooking(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
 		return
 	}
-
-	var booking models.Booking
-	if err := config.DB.First(&booking, bookingUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+	if !authorizeBooking(c, models.PermBookingsManage, bookingUUID) {
 		return
 	}
-	if err := config.DB.Delete(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus booking", "data": nil})
+
+	booking, err := services.DeleteBookingService(bookingUUID, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusInternalServerError)
 		return
 	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingDelete, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: booking})
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil dihapus", "data": nil})
 }
 
-// DeleteBookingByToken godoc
-// @Summary Delete booking by QR token
-// @Description Delete a booking using QR code token
+// GetDeletedBookings godoc
+// @Summary List deleted bookings
+// @Description List soft deleted bookings that can still be restored. Room managers and approvers only see their rooms.
 // @Tags booking
-// @Accept  json
 // @Produce  json
-// @Param   token  path  string  true  "QR Code Token"
+// @Param   limit   query  int  false  "Page size (default 50, max 200)"
+// @Param   offset  query  int  false  "Offset"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
-// @Router /api/bookings/delete/{token} [delete]
-func (h *BookingHandler) DeleteBookingByToken(c *gin.Context) {
-	token := c.Param("token")
-	var booking models.Booking
-	if err := config.DB.Where("qr_code_token = ?", token).First(&booking).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+// @Router /api/bookings/deleted [get]
+func (h *BookingHandler) GetDeletedBookings(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	bookings, total, err := services.ListDeletedBookings(limit, offset, accessFromContext(c).ScopedRoomIDs())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data booking terhapus", "data": nil})
 		return
 	}
-	if err := config.DB.Delete(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus booking", "data": nil})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data booking terhapus berhasil diambil", "data": gin.H{"total": total, "bookings": bookings}})
+}
+
+// RestoreBooking godoc
+// @Summary Restore booking
+// @Description Restore a soft deleted booking. Upcoming bookings are re-checked for conflicts and their room must not be deleted.
+// @Tags booking
+// @Produce  json
+// @Param   id  path  string  true  "Booking ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/{id}/restore [post]
+func (h *BookingHandler) RestoreBooking(c *gin.Context) {
+	bookingUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil dihapus", "data": nil})
+	if !authorizeBooking(c, models.PermBookingsManage, bookingUUID) {
+		return
+	}
+	booking, err := services.RestoreBookingService(bookingUUID, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingRestore, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil dipulihkan", "data": booking})
+}
+
+// actorFromContext mengambil admin yang sedang login dari context AuthMiddleware.
+func actorFromContext(c *gin.Context) services.Actor {
+	actor := services.Actor{}
+	if id, ok := c.Get("id"); ok {
+		if userID, ok := id.(uuid.UUID); ok {
+			actor.ID = &userID
+		}
+	}
+	if role, ok := c.Get("role"); ok {
+		actor.Role, _ = role.(string)
+	}
+	return actor
+}
+
+// requesterIDFromContext mengembalikan ID requester yang login lewat
+// OptionalAuthMiddleware; nil untuk pemesan anonim dan admin.
+func requesterIDFromContext(c *gin.Context) *uuid.UUID {
+	if role, _ := c.Get("role"); role != models.RoleRequester {
+		return nil
+	}
+	return actorFromContext(c).ID
+}
+
+// bindOptionalJSON seperti ShouldBindJSON tetapi mengizinkan body kosong.
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// respondBookingError memetakan error dari service booking ke status HTTP.
+// Perubahan status yang ditolak dan jadwal bentrok dikembalikan sebagai 409;
+// error lain memakai defaultStatus.
+func respondBookingError(c *gin.Context, err error, defaultStatus int) {
+	var transitionErr *services.TransitionError
+	var conflictErr *services.ConflictError
+	switch {
+	case errors.As(err, &transitionErr):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": gin.H{"from": transitionErr.From, "to": transitionErr.To}})
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": gin.H{"conflicts": conflictErr.Conflicts}})
+	case errors.Is(err, services.ErrOverCapacity):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+	case errors.Is(err, services.ErrBookingStatusChanged), errors.Is(err, services.ErrSeriesChanged), errors.Is(err, services.ErrBookingMoved):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+	case errors.Is(err, services.ErrMeetingNotActive), errors.Is(err, services.ErrMeetingNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+	case errors.Is(err, services.ErrInvalidBookingToken):
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": err.Error(), "data": nil})
+	case errors.Is(err, services.ErrBookingNotDeleted), errors.Is(err, services.ErrRoomDeleted):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+	case errors.Is(err, services.ErrBookingNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+	default:
+		log.Errorf("Booking operation failed: %v", err)
+		c.JSON(defaultStatus, gin.H{"success": false, "message": err.Error(), "data": nil})
+	}
 }
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// CreateBookingSeries godoc
+// @Summary Create recurring booking
+// @Description Create a booking series from an RRULE and expand it into individual bookings
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   input  body  models.CreateBookingSeriesInput  true  "Booking series info"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/series [post]
+func (h *BookingHandler) CreateBookingSeries(c *gin.Context) {
+	var input models.CreateBookingSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Warnf("Invalid input: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	// Bahasa email mengikuti browser pemesan bila tidak dipilih
+	if input.Locale == "" {
+		input.Locale = services.NormalizeLocale(c.GetHeader("Accept-Language"))
+	}
+	input.UserID = requesterIDFromContext(c)
+
+	result, err := services.CreateBookingSeriesService(input, h.EmailService)
+	if errors.Is(err, services.ErrSeriesConflict) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": result})
+		return
+	}
+	if err != nil {
+		log.Errorf("Failed to create booking series: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": result})
+		return
+	}
+
+	recordAudit(c, services.AuditEntry{Action: services.AuditSeriesCreate, EntityType: services.AuditEntitySeries, EntityID: result.Series.ID.String(),
+		After: gin.H{"series": result.Series, "bookings": len(result.Bookings), "skipped": len(result.Conflicts)}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Seri booking berhasil dibuat", "data": result})
+}
+
+// BookingSeriesSlot adalah satu kemunculan seri tanpa data pemesan.
+type BookingSeriesSlot struct {
+	ID        uuid.UUID            `json:"id"`
+	StartTime time.Time            `json:"start_time"`
+	EndTime   time.Time            `json:"end_time"`
+	Status    models.BookingStatus `json:"status"`
+}
+
+// BookingSeriesPublicResponse adalah seri booking untuk pengunjung yang bukan
+// pemilik seri atau staf ruangannya: nama, email dan keperluan tidak ikut.
+type BookingSeriesPublicResponse struct {
+	ID        uuid.UUID           `json:"id"`
+	RoomID    uuid.UUID           `json:"room_id"`
+	Room      models.Room         `json:"room"`
+	StartTime time.Time           `json:"start_time"`
+	EndTime   time.Time           `json:"end_time"`
+	RRule     string              `json:"rrule"`
+	Bookings  []BookingSeriesSlot `json:"bookings"`
+}
+
+// canViewSeries mengizinkan data pemesan seri dilihat oleh pemilik salah satu
+// kemunculannya atau staf yang boleh melihat booking di ruangannya.
+func (v bookingViewer) canViewSeries(series *models.BookingSeries) bool {
+	if v.access == nil {
+		return false
+	}
+	for i := range series.Bookings {
+		if owner := series.Bookings[i].UserID; owner != nil && *owner == v.access.UserID {
+			return true
+		}
+	}
+	return v.access.CanForRoom(models.PermBookingsView, series.RoomID)
+}
+
+func newBookingSeriesPublicResponse(series *models.BookingSeries) BookingSeriesPublicResponse {
+	slots := make([]BookingSeriesSlot, 0, len(series.Bookings))
+	for _, b := range series.Bookings {
+		slots = append(slots, BookingSeriesSlot{ID: b.ID, StartTime: b.StartTime, EndTime: b.EndTime, Status: b.Status})
+	}
+	return BookingSeriesPublicResponse{
+		ID:        series.ID,
+		RoomID:    series.RoomID,
+		Room:      series.Room,
+		StartTime: series.StartTime,
+		EndTime:   series.EndTime,
+		RRule:     series.RRule,
+		Bookings:  slots,
+	}
+}
+
+// GetBookingSeries godoc
+// @Summary Get booking series
+// @Description Get a booking series with all of its occurrences. Requester name, email and purpose are only returned to the series owner and staff who can view bookings in its room.
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   id  path  string  true  "Series ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/bookings/series/{id} [get]
+func (h *BookingHandler) GetBookingSeries(c *gin.Context) {
+	seriesUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID seri tidak valid", "data": nil})
+		return
+	}
+
+	var series models.BookingSeries
+	err = config.DB.Preload("Room", services.IncludeDeleted).
+		Preload("Bookings", func(db *gorm.DB) *gorm.DB { return db.Order("start_time") }).
+		First(&series, seriesUUID).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Seri booking tidak ditemukan", "data": nil})
+		return
+	}
+	if !viewerFromContext(c).canViewSeries(&series) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data seri booking berhasil diambil", "data": newBookingSeriesPublicResponse(&series)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data seri booking berhasil diambil", "data": series})
+}
+
+// UpdateBookingSeries godoc
+// @Summary Update booking series
+// @Description Apply changes to all upcoming active occurrences that were not edited individually. Approved occurrences whose room or purpose changes get an updated calendar invite.
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string  true  "Series ID"
+// @Param   input  body  services.UpdateBookingSeriesInput  true  "Series changes"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/series/{id} [put]
+func (h *BookingHandler) UpdateBookingSeries(c *gin.Context) {
+	seriesUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID seri tidak valid", "data": nil})
+		return
+	}
+	if !authorizeSeries(c, models.PermBookingsManage, seriesUUID) {
+		return
+	}
+
+	var input services.UpdateBookingSeriesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	if newRoomID, err := uuid.Parse(input.RoomID); err == nil && !authorizeRooms(c, models.PermBookingsManage, newRoomID) {
+		return
+	}
+
+	result, err := services.UpdateBookingSeriesService(seriesUUID, input, h.EmailService)
+	if errors.Is(err, services.ErrSeriesConflict) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": result})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditSeriesUpdate, EntityType: services.AuditEntitySeries, EntityID: seriesUUID.String(), After: input})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Seri booking berhasil diperbarui", "data": result})
+}
+
+// ApproveBookingSeries godoc
+// @Summary Approve booking series
+// @Description Approve all upcoming pending occurrences of a series. Each occurrence is checked again for conflicts and capacity in its own room; if one fails nothing is approved.
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   id  path  string  true  "Series ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/series/{id}/approve [patch]
+func (h *BookingHandler) ApproveBookingSeries(c *gin.Context) {
+	h.setBookingSeriesStatus(c, models.BookingApproved, "Seri booking berhasil disetujui")
+}
+
+// RejectBookingSeries godoc
+// @Summary Reject booking series
+// @Description Reject all upcoming pending occurrences of a series
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   id  path  string  true  "Series ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/bookings/series/{id}/reject [patch]
+func (h *BookingHandler) RejectBookingSeries(c *gin.Context) {
+	h.setBookingSeriesStatus(c, models.BookingRejected, "Seri booking berhasil ditolak")
+}
+
+func (h *BookingHandler) setBookingSeriesStatus(c *gin.Context, status models.BookingStatus, message string) {
+	seriesUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID seri tidak valid", "data": nil})
+		return
+	}
+	if !authorizeSeries(c, models.PermBookingsApprove, seriesUUID) {
+		return
+	}
+
+	var input BookingStatusInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	bookings, err := services.SetBookingSeriesStatusService(seriesUUID, status, actorFromContext(c), input.Reason, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditSeriesStatus, EntityType: services.AuditEntitySeries, EntityID: seriesUUID.String(),
+		After: gin.H{"status": status, "reason": input.Reason, "bookings": len(bookings)}})
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message, "data": bookings})
+}
+
+// DeleteBookingSeries godoc
+// @Summary Delete booking series
+// @Description Delete all upcoming occurrences of a series. Approved occurrences are removed from the requester's calendar with a CANCEL invite.
+// @Tags booking-series
+// @Accept  json
+// @Produce  json
+// @Param   id  path  string  true  "Series ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/bookings/series/{id} [delete]
+func (h *BookingHandler) DeleteBookingSeries(c *gin.Context) {
+	seriesUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID seri tidak valid", "data": nil})
+		return
+	}
+	if !authorizeSeries(c, models.PermBookingsManage, seriesUUID) {
+		return
+	}
+
+	deleted, err := services.DeleteBookingSeriesService(seriesUUID, h.EmailService)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditSeriesDelete, EntityType: services.AuditEntitySeries, EntityID: seriesUUID.String(), Before: gin.H{"deleted": deleted}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Seri booking berhasil dihapus", "data": gin.H{"deleted": deleted}})
+}
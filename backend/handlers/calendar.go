@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CalendarFeedResponse struct {
+	models.CalendarFeed
+	URL string `json:"url"`
+}
+
+// GetCalendarFeed godoc
+// @Summary Calendar feed
+// @Description Subscribable iCalendar feed for a room or requester, secured by its token. Requester emails are not included
+// @Tags calendar
+// @Produce  text/calendar
+// @Param   token  path  string  true  "Feed token (optionally suffixed with .ics)"
+// @Success 200 {string} string
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/calendar/feed/{token} [get]
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	ics, err := services.RenderCalendarFeed(token)
+	if errors.Is(err, services.ErrCalendarFeedNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Feed kalender tidak ditemukan", "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
+
+// ListCalendarFeeds godoc
+// @Summary List calendar feeds
+// @Description List issued calendar feed subscriptions
+// @Tags calendar
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/calendar/feeds [get]
+func ListCalendarFeeds(c *gin.Context) {
+	var feeds []models.CalendarFeed
+	if err := config.DB.Order("created_at DESC").Find(&feeds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil feed kalender", "data": nil})
+		return
+	}
+	response := make([]CalendarFeedResponse, 0, len(feeds))
+	for i := range feeds {
+		response = append(response, CalendarFeedResponse{CalendarFeed: feeds[i], URL: services.CalendarFeedURL(&feeds[i])})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil diambil", "data": response})
+}
+
+// CreateCalendarFeed godoc
+// @Summary Create calendar feed
+// @Description Issue a subscribable .ics feed URL for a room or a requester email
+// @Tags calendar
+// @Accept  json
+// @Produce  json
+// @Param   input  body  models.CreateCalendarFeedInput  true  "Feed info"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/calendar/feeds [post]
+func CreateCalendarFeed(c *gin.Context) {
+	var input models.CreateCalendarFeedInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	feed, err := services.CreateCalendarFeed(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditFeedCreate, EntityType: services.AuditEntityFeed, EntityID: feed.ID.String(), After: feed})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil dibuat", "data": CalendarFeedResponse{CalendarFeed: *feed, URL: services.CalendarFeedURL(feed)}})
+}
+
+// RevokeCalendarFeed godoc
+// @Summary Revoke calendar feed
+// @Description Revoke a calendar feed so its URL stops working
+// @Tags calendar
+// @Produce  json
+// @Param   id  path  string  true  "Feed ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/calendar/feeds/{id} [delete]
+func RevokeCalendarFeed(c *gin.Context) {
+	feedUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID feed tidak valid", "data": nil})
+		return
+	}
+
+	err = services.RevokeCalendarFeed(feedUUID)
+	if errors.Is(err, services.ErrCalendarFeedNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditFeedRevoke, EntityType: services.AuditEntityFeed, EntityID: feedUUID.String()})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil dicabut", "data": nil})
+}
+
+// GetMyCalendarFeeds godoc
+// @Summary List my calendar feeds
+// @Description List active calendar feeds owned by the logged in requester
+// @Tags me
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/me/calendar/feeds [get]
+func GetMyCalendarFeeds(c *gin.Context) {
+	feeds, err := services.ListUserCalendarFeeds(*actorFromContext(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil feed kalender", "data": nil})
+		return
+	}
+	response := make([]CalendarFeedResponse, 0, len(feeds))
+	for i := range feeds {
+		response = append(response, CalendarFeedResponse{CalendarFeed: feeds[i], URL: services.CalendarFeedURL(&feeds[i])})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil diambil", "data": response})
+}
+
+// CreateMyCalendarFeed godoc
+// @Summary Create my calendar feed
+// @Description Issue a subscribable .ics feed URL containing the logged in requester's own bookings
+// @Tags me
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/me/calendar/feeds [post]
+func CreateMyCalendarFeed(c *gin.Context) {
+	feed, err := services.CreateUserCalendarFeed(*actorFromContext(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditFeedCreate, EntityType: services.AuditEntityFeed, EntityID: feed.ID.String(), After: feed})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil dibuat", "data": CalendarFeedResponse{CalendarFeed: *feed, URL: services.CalendarFeedURL(feed)}})
+}
+
+// RevokeMyCalendarFeed godoc
+// @Summary Revoke my calendar feed
+// @Description Revoke a calendar feed owned by the logged in requester
+// @Tags me
+// @Produce  json
+// @Param   id  path  string  true  "Feed ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/me/calendar/feeds/{id} [delete]
+func RevokeMyCalendarFeed(c *gin.Context) {
+	feedUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID feed tidak valid", "data": nil})
+		return
+	}
+
+	err = services.RevokeUserCalendarFeed(feedUUID, *actorFromContext(c).ID)
+	if errors.Is(err, services.ErrCalendarFeedNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditFeedRevoke, EntityType: services.AuditEntityFeed, EntityID: feedUUID.String()})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil dicabut", "data": nil})
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckInBooking godoc
+// @Summary Check in to a booking
+// @Description Check in to an approved booking with the signed token from its QR code. Check-in opens CHECK_IN_OPENS_MINUTES before the start and closes NO_SHOW_MINUTES after it.
+// @Tags booking
+// @Produce  json
+// @Param   token  path  string  true  "Signed check-in token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/check-in/{token} [post]
+func (h *BookingHandler) CheckInBooking(c *gin.Context) {
+	booking, err := services.CheckInBookingService(c.Param("token"), time.Now())
+	if errors.Is(err, services.ErrCheckInTooEarly) || errors.Is(err, services.ErrCheckInClosed) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		respondBookingError(c, err, http.StatusInternalServerError)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{Role: "check_in"}, Action: services.AuditBookingCheckIn, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(),
+		After: gin.H{"status": booking.Status, "checked_in_at": booking.CheckedInAt}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Check-in berhasil", "data": booking})
+}
+
+// ListRequesterNoShows godoc
+// @Summary List requester no-shows
+// @Description List check-in and no-show counts per requester, most no-shows first
+// @Tags booking
+// @Produce  json
+// @Param   email        query  string  false  "Requester email"
+// @Param   min_no_shows query  int     false  "Only requesters with at least this many no-shows"
+// @Param   limit        query  int     false  "Page size (default 50, max 200)"
+// @Param   offset       query  int     false  "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/no-shows [get]
+func ListRequesterNoShows(c *gin.Context) {
+	q := services.RequesterStatQuery{Email: c.Query("email")}
+	q.MinNoShows, _ = strconv.Atoi(c.Query("min_no_shows"))
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+	q.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	stats, total, err := services.ListRequesterStats(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data no-show", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data no-show berhasil diambil", "data": gin.H{"requesters": stats, "total": total}})
+}
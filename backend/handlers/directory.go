@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"backendgo/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDirectoryStatus godoc
+// @Summary LDAP directory sync status
+// @Description Show whether LDAP authentication is enabled and the result of the last directory sync since the server started
+// @Tags directory
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/admin/directory [get]
+func GetDirectoryStatus(c *gin.Context) {
+	ldap := services.LDAP()
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Status directory LDAP", "data": gin.H{
+		"enabled":   ldap.Enabled(),
+		"last_sync": ldap.LastSync(),
+	}})
+}
+
+// RunDirectorySync godoc
+// @Summary Run LDAP directory sync
+// @Description Create and update users from the LDAP directory now and disable LDAP users that are no longer in it
+// @Tags directory
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /api/admin/directory/sync [post]
+func RunDirectorySync(c *gin.Context) {
+	report, err := services.LDAP().Sync()
+	if errors.Is(err, services.ErrLDAPDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "message": err.Error(), "data": report})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditDirectorySync, EntityType: services.AuditEntityUser,
+		After: gin.H{"created": report.Created, "updated": report.Updated, "disabled": report.Disabled}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Sinkronisasi directory selesai", "data": report})
+}
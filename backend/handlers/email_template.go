@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailTemplateInfo struct {
+	Name       string `json:"name"`
+	Locale     string `json:"locale"`
+	Overridden bool   `json:"overridden"`
+}
+
+// ListEmailTemplates godoc
+// @Summary List email templates
+// @Description List email template names per locale and whether they are overridden in the database
+// @Tags email-templates
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/email-templates [get]
+func ListEmailTemplates(c *gin.Context) {
+	var overrides []models.EmailTemplate
+	if err := config.DB.Find(&overrides).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil template email", "data": nil})
+		return
+	}
+	overridden := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		overridden[o.Name+"/"+o.Locale] = true
+	}
+
+	names := append([]string{"layout"}, services.EmailTemplateNames...)
+	templates := make([]EmailTemplateInfo, 0, len(names)*len(services.SupportedLocales))
+	for _, name := range names {
+		for _, locale := range services.SupportedLocales {
+			templates = append(templates, EmailTemplateInfo{Name: name, Locale: locale, Overridden: overridden[name+"/"+locale]})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Template email berhasil diambil", "data": templates})
+}
+
+// PreviewEmailTemplate godoc
+// @Summary Preview email template
+// @Description Render an email template against a sample booking
+// @Tags email-templates
+// @Produce  html
+// @Param   name    path   string  true   "Template name"
+// @Param   locale  query  string  false  "id or en"
+// @Param   format  query  string  false  "html (default), text or json"
+// @Success 200 {string} string
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/email-templates/{name}/preview [get]
+func PreviewEmailTemplate(c *gin.Context) {
+	name := c.Param("name")
+	locale := services.NormalizeLocale(c.Query("locale"))
+
+	rendered, err := services.RenderEmail(name, locale, services.SampleEmailTemplateData(name, locale))
+	if errors.Is(err, services.ErrEmailTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	switch c.Query("format") {
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte("Subject: "+rendered.Subject+"\n\n"+rendered.Text))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Preview template email", "data": rendered})
+	default:
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTML))
+	}
+}
+
+// SaveEmailTemplate godoc
+// @Summary Override email template
+// @Description Store a database override for an email template. Empty parts fall back to the directory or built-in template.
+// @Tags email-templates
+// @Accept  json
+// @Produce  json
+// @Param   name    path  string                     true  "Template name (or layout)"
+// @Param   locale  path  string                     true  "id or en"
+// @Param   input   body  models.EmailTemplateInput  true  "Template sources"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/email-templates/{name}/{locale} [put]
+func SaveEmailTemplate(c *gin.Context) {
+	var input models.EmailTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	before := emailTemplateSnapshot(c.Param("name"), c.Param("locale"))
+	tmpl, err := services.SaveEmailTemplateOverride(c.Param("name"), c.Param("locale"), input)
+	if errors.Is(err, services.ErrEmailTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditTemplateSave, EntityType: services.AuditEntityTemplate, EntityID: tmpl.Name + "/" + tmpl.Locale, Before: before, After: tmpl})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Template email berhasil disimpan", "data": tmpl})
+}
+
+// DeleteEmailTemplate godoc
+// @Summary Remove email template override
+// @Description Remove a database override so the directory or built-in template is used again
+// @Tags email-templates
+// @Produce  json
+// @Param   name    path  string  true  "Template name (or layout)"
+// @Param   locale  path  string  true  "id or en"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/email-templates/{name}/{locale} [delete]
+func DeleteEmailTemplate(c *gin.Context) {
+	before := emailTemplateSnapshot(c.Param("name"), c.Param("locale"))
+	err := services.DeleteEmailTemplateOverride(c.Param("name"), c.Param("locale"))
+	if errors.Is(err, services.ErrEmailTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Override template tidak ditemukan", "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditTemplateDelete, EntityType: services.AuditEntityTemplate, EntityID: c.Param("name") + "/" + c.Param("locale"), Before: before})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Override template email dihapus", "data": nil})
+}
+
+// emailTemplateSnapshot mengambil override template untuk audit, atau nil bila
+// belum ada override.
+func emailTemplateSnapshot(name, locale string) interface{} {
+	var tmpl models.EmailTemplate
+	if err := config.DB.Where("name = ? AND locale = ?", name, locale).First(&tmpl).Error; err != nil {
+		return nil
+	}
+	return &tmpl
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"backendgo/services"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportFileSize = 5 << 20
+
+// ImportBookings godoc
+// @Summary Import bookings
+// @Description Import bookings from an .ics or CSV file. Rooms are matched by name and every row is validated like a regular booking.
+// @Tags bookings
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param   file     formData  file    true   "Booking file (.ics or .csv)"
+// @Param   format   query     string  false  "ics or csv (default: from file extension)"
+// @Param   mode     query     string  false  "all_or_nothing (default) or best_effort"
+// @Param   dry_run  query     bool    false  "Validate only, do not save"
+// @Param   approve  query     bool    false  "Approve imported bookings and send calendar invitations"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Router /api/admin/import/bookings [post]
+func ImportBookings(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File import wajib diunggah", "data": nil})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Ukuran file maksimal 5MB", "data": nil})
+		return
+	}
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File import tidak dapat dibaca", "data": nil})
+		return
+	}
+	defer file.Close()
+
+	var rows []services.ImportRow
+	switch format {
+	case "csv":
+		rows, err = services.ParseBookingsCSV(file)
+	case "ics", "ical":
+		rows, err = services.ParseBookingsICS(file)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format file harus ics atau csv", "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File tidak berisi booking", "data": nil})
+		return
+	}
+
+	report, err := services.ImportBookings(rows, services.ImportOptions{
+		DryRun:   c.Query("dry_run") == "true",
+		Mode:     c.Query("mode"),
+		Approve:  c.Query("approve") == "true",
+		Actor:    actorFromContext(c),
+		Notifier: c.MustGet("emailService").(*services.EmailService),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	if !report.DryRun && report.Imported > 0 {
+		recordAudit(c, services.AuditEntry{Action: services.AuditBookingImport, EntityType: services.AuditEntityBooking,
+			After: gin.H{"file": fileHeader.Filename, "format": format, "mode": report.Mode, "imported": report.Imported, "failed": report.Failed}})
+	}
+	switch {
+	case report.DryRun:
+		c.JSON(http.StatusOK, gin.H{"success": report.Failed == 0, "message": "Validasi import selesai", "data": report})
+	case report.Imported == 0 && report.Failed > 0:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "message": "Import dibatalkan karena ada baris yang gagal", "data": report})
+	default:
+		c.JSON(http.StatusOK, gin.H{"success": true, "message": "Import booking selesai", "data": report})
+	}
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"backendgo/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys (RS256/EdDSA) for verifying access tokens issued by this service, selected by the kid header. HS256 secrets are never published, so the set is empty when only JWT_SECRET is configured.
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func GetJWKS(c *gin.Context) {
+	// Boleh di-cache singkat; key baru sudah dipublikasikan sebelum dipakai
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": services.JWTKeys().JWKS()})
+}
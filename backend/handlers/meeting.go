@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"backendgo/models"
+	"backendgo/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ExtendMeetingInput struct {
+	Minutes int `json:"minutes" binding:"required,min=1"`
+}
+
+// EndMeeting godoc
+// @Summary End meeting now
+// @Description End a running meeting. Ending early frees the rest of the slot; ending late records the overtime minutes.
+// @Tags booking
+// @Produce  json
+// @Param   id  path  string  true  "Booking ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/{id}/end [post]
+func (h *BookingHandler) EndMeeting(c *gin.Context) {
+	bookingUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
+		return
+	}
+	if !authorizeBooking(c, models.PermBookingsManage, bookingUUID) {
+		return
+	}
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, err := services.EndMeetingService(bookingUUID, actorFromContext(c), time.Now(), h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingEnd, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Meeting berhasil diakhiri", "data": booking})
+}
+
+// ExtendMeeting godoc
+// @Summary Extend meeting
+// @Description Extend a meeting's end time by N minutes if the room is still free
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string              true  "Booking ID"
+// @Param   input  body  ExtendMeetingInput  true  "Minutes to extend"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/{id}/extend [post]
+func (h *BookingHandler) ExtendMeeting(c *gin.Context) {
+	bookingUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
+		return
+	}
+	if !authorizeBooking(c, models.PermBookingsManage, bookingUUID) {
+		return
+	}
+	var input ExtendMeetingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, err := services.ExtendMeetingService(bookingUUID, input.Minutes, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingExtend, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Meeting berhasil diperpanjang", "data": booking})
+}
+
+// EndMeetingByToken godoc
+// @Summary End meeting by QR token
+// @Description End a running meeting with the signed end-meeting token from its QR code
+// @Tags booking
+// @Produce  json
+// @Param   token  path  string  true  "Signed end-meeting token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/end/{token} [post]
+func (h *BookingHandler) EndMeetingByToken(c *gin.Context) {
+	booking, err := services.EndMeetingByTokenService(c.Param("token"), time.Now(), h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{Role: "qr"}, Action: services.AuditBookingEnd, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Meeting berhasil diakhiri", "data": booking})
+}
+
+// ExtendMeetingByToken godoc
+// @Summary Extend meeting by QR token
+// @Description Extend a meeting with the signed extend-meeting token from its QR code. End-meeting tokens are not accepted.
+// @Tags booking
+// @Accept  json
+// @Produce  json
+// @Param   token  path  string              true  "Signed extend-meeting token"
+// @Param   input  body  ExtendMeetingInput  true  "Minutes to extend"
+// @Success 200 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/bookings/extend/{token} [post]
+func (h *BookingHandler) ExtendMeetingByToken(c *gin.Context) {
+	var input ExtendMeetingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	booking, err := services.ExtendMeetingByTokenService(c.Param("token"), input.Minutes, h.EmailService)
+	if err != nil {
+		respondBookingError(c, err, http.StatusBadRequest)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{Role: "qr"}, Action: services.AuditBookingExtend, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), After: gin.H{"end_time": booking.EndTime, "extended_minutes": booking.ExtendedMinutes}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Meeting berhasil diperpanjang", "data": booking})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CancelMyBookingInput struct {
+	Reason string `json:"reason"`
+}
+
+// GetMyBookings godoc
+// @Summary List my bookings
+// @Description List bookings owned by the logged in requester
+// @Tags me
+// @Produce  json
+// @Param   status    query  string  false  "Filter by status"
+// @Param   upcoming  query  bool    false  "Only bookings that have not ended, soonest first"
+// @Param   limit     query  int     false  "Page size (default 50, max 200)"
+// @Param   offset    query  int     false  "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/me/bookings [get]
+func GetMyBookings(c *gin.Context) {
+	q := services.MyBookingQuery{Status: models.BookingStatus(c.Query("status"))}
+	if q.Status != "" && !q.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Status booking tidak valid", "data": nil})
+		return
+	}
+	q.Upcoming, _ = strconv.ParseBool(c.Query("upcoming"))
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+	q.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	bookings, total, err := services.ListUserBookings(*actorFromContext(c).ID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data booking", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data booking berhasil diambil", "data": gin.H{"bookings": bookings, "total": total}})
+}
+
+// CancelMyBooking godoc
+// @Summary Cancel my booking
+// @Description Cancel a pending or approved booking owned by the logged in requester before it starts
+// @Tags me
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string                true   "Booking ID"
+// @Param   input  body  CancelMyBookingInput  false  "Cancellation reason"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/me/bookings/{id}/cancel [post]
+func (h *BookingHandler) CancelMyBooking(c *gin.Context) {
+	bookingUUID := uuid.MustParse(c.Param("id")) // sudah divalidasi BookingOwnerOnly
+	var input CancelMyBookingInput
+	if err := bindOptionalJSON(c, &input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, err := services.CancelOwnBookingService(bookingUUID, actorFromContext(c), input.Reason, h.EmailService)
+	if err != nil {
+		respondMyBookingError(c, err)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingCancel, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Booking berhasil dibatalkan", "data": booking})
+}
+
+// RescheduleMyBooking godoc
+// @Summary Reschedule my booking
+// @Description Move a pending or approved booking owned by the logged in requester to another time and optionally another room. An approved booking goes back to pending and must be approved again.
+// @Tags me
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string                         true  "Booking ID"
+// @Param   input  body  models.RescheduleBookingInput  true  "New schedule"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/me/bookings/{id}/reschedule [put]
+func (h *BookingHandler) RescheduleMyBooking(c *gin.Context) {
+	bookingUUID := uuid.MustParse(c.Param("id")) // sudah divalidasi BookingOwnerOnly
+	var input models.RescheduleBookingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	before := auditSnapshot(&models.Booking{}, bookingUUID)
+	booking, err := services.RescheduleOwnBookingService(bookingUUID, input, actorFromContext(c), h.EmailService)
+	if err != nil {
+		respondMyBookingError(c, err)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditBookingReschedule, EntityType: services.AuditEntityBooking, EntityID: booking.ID.String(), Before: before, After: booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Jadwal booking berhasil diubah", "data": booking})
+}
+
+// respondMyBookingError seperti respondBookingError, tetapi jadwal bentrok hanya
+// ditampilkan sebagai slot tanpa data pemesan lain.
+func respondMyBookingError(c *gin.Context, err error) {
+	var conflictErr *services.ConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		slots := make([]BookingConflictSlot, 0, len(conflictErr.Conflicts))
+		for _, b := range conflictErr.Conflicts {
+			slots = append(slots, BookingConflictSlot{StartTime: b.StartTime, EndTime: b.EndTime, Status: b.Status})
+		}
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": gin.H{"conflicts": slots}})
+	case errors.Is(err, services.ErrBookingLocked):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+	default:
+		respondBookingError(c, err, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description List outbox notifications, optionally filtered by status (pending, sent, dead), kind or booking
+// @Tags notifications
+// @Produce  json
+// @Param   status      query  string  false  "pending, sent or dead"
+// @Param   kind        query  string  false  "Notification kind"
+// @Param   booking_id  query  string  false  "Booking ID"
+// @Param   limit       query  int     false  "Page size (default 50, max 200)"
+// @Param   offset      query  int     false  "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/admin/notifications [get]
+func ListNotifications(c *gin.Context) {
+	q := services.NotificationQuery{
+		Status: models.NotificationStatus(c.Query("status")),
+		Kind:   c.Query("kind"),
+	}
+	if v := c.Query("booking_id"); v != "" {
+		bookingUUID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
+			return
+		}
+		q.BookingID = &bookingUUID
+	}
+	q.Limit, _ = strconv.Atoi(c.Query("limit"))
+	q.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	notifications, total, err := services.ListNotifications(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil notifikasi", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Notifikasi berhasil diambil", "data": gin.H{"notifications": notifications, "total": total}})
+}
+
+// RetryNotification godoc
+// @Summary Retry notification
+// @Description Put a dead or pending notification back in the queue with its attempts reset. Password reset OTP emails cannot be retried.
+// @Tags notifications
+// @Produce  json
+// @Param   id  path  string  true  "Notification ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/notifications/{id}/retry [post]
+func RetryNotification(c *gin.Context) {
+	notificationUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID notifikasi tidak valid", "data": nil})
+		return
+	}
+
+	notification, err := services.RetryNotification(notificationUUID)
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditNotificationRetry, EntityType: services.AuditEntityNotification, EntityID: notification.ID.String(),
+		After: gin.H{"kind": notification.Kind, "recipient": notification.Recipient}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Notifikasi dijadwalkan ulang", "data": notification})
+}
+
+// RetryDeadNotifications godoc
+// @Summary Retry dead notifications
+// @Description Put every dead notification back in the queue, except password reset OTP emails
+// @Tags notifications
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/notifications/retry-dead [post]
+func RetryDeadNotifications(c *gin.Context) {
+	count, err := services.RetryDeadNotifications()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menjadwalkan ulang notifikasi", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditNotificationDead, EntityType: services.AuditEntityNotification, After: gin.H{"count": count}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Notifikasi dijadwalkan ulang", "data": gin.H{"count": count}})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCLogin godoc
+// @Summary Start SSO login
+// @Description Redirect to the configured OpenID Connect provider (authorization code flow with PKCE). The login state is bound to the browser with an HttpOnly cookie.
+// @Tags auth
+// @Param   redirect  query  string  false  "Frontend path to open after login, e.g. /admin"
+// @Success 302
+// @Failure 404 {object} map[string]interface{}
+// @Failure 502 {object} map[string]interface{}
+// @Router /api/auth/oidc/login [get]
+func OIDCLogin(c *gin.Context) {
+	authURL, state, err := services.OIDC().BeginLogin(c.Request.Context(), c.Query("redirect"))
+	if errors.Is(err, services.ErrOIDCDisabled) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("SSO: gagal memulai login")
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "message": "Gagal memulai login SSO", "data": nil})
+		return
+	}
+	setOIDCStateCookie(c, state, int(services.OIDCStateTTL().Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+const oidcStateCookie = "oidc_state"
+
+// setOIDCStateCookie mengikat state login ke browser. SameSite=Lax agar cookie
+// tetap terkirim saat identity provider mengarahkan browser kembali ke
+// callback; maxAge negatif menghapus cookie.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(services.Links().APIBaseURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	// Path "/" karena API bisa berada di belakang prefix reverse proxy
+	c.SetCookie(oidcStateCookie, state, maxAge, "/", "", secure, true)
+}
+
+// OIDCCallback godoc
+// @Summary SSO callback
+// @Description Redirect target of the OpenID Connect provider. The state must match the cookie set by /api/auth/oidc/login. Provisions the user and redirects to the frontend with the tokens in the URL fragment (#token=...&refresh_token=...&redirect=...) or #error=... on failure.
+// @Tags auth
+// @Param   code   query  string  true  "Authorization code"
+// @Param   state  query  string  true  "State"
+// @Success 302
+// @Router /api/auth/oidc/callback [get]
+func OIDCCallback(c *gin.Context) {
+	fragment := url.Values{}
+	browserState, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if idpErr := c.Query("error"); idpErr != "" {
+		fragment.Set("error", "Login SSO dibatalkan: "+idpErr)
+		c.Redirect(http.StatusFound, services.Links().SSOCallbackURL()+"#"+fragment.Encode())
+		return
+	}
+
+	user, redirectPath, err := services.OIDC().CompleteLogin(c.Request.Context(), c.Query("state"), browserState, c.Query("code"))
+	if err != nil {
+		log.WithError(err).Warn("SSO: login gagal")
+		recordAudit(c, services.AuditEntry{Action: services.AuditLoginFailed, EntityType: services.AuditEntityUser, After: gin.H{"provider": models.AuthProviderOIDC}})
+		fragment.Set("error", ssoErrorMessage(err))
+		c.Redirect(http.StatusFound, services.Links().SSOCallbackURL()+"#"+fragment.Encode())
+		return
+	}
+
+	if user.IsDisabled() {
+		fragment.Set("error", "Akun dinonaktifkan")
+		c.Redirect(http.StatusFound, services.Links().SSOCallbackURL()+"#"+fragment.Encode())
+		return
+	}
+
+	tokens, err := issueSession(c, user)
+	if err != nil {
+		fragment.Set("error", "Failed to generate token")
+		c.Redirect(http.StatusFound, services.Links().SSOCallbackURL()+"#"+fragment.Encode())
+		return
+	}
+	recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &user.ID, Role: user.Role}, Action: services.AuditLogin, EntityType: services.AuditEntityUser, EntityID: user.ID.String(),
+		After: gin.H{"provider": models.AuthProviderOIDC}})
+
+	fragment.Set("token", tokens.Token)
+	fragment.Set("refresh_token", tokens.RefreshToken)
+	fragment.Set("redirect", redirectPath)
+	c.Redirect(http.StatusFound, services.Links().SSOCallbackURL()+"#"+fragment.Encode())
+}
+
+// ssoErrorMessage hanya meneruskan pesan yang aman ditampilkan ke user; detail
+// error dari identity provider cukup masuk log.
+func ssoErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrOIDCDisabled),
+		errors.Is(err, services.ErrOIDCState),
+		errors.Is(err, services.ErrExternalEmailMissing),
+		errors.Is(err, services.ErrExternalEmailUnverified),
+		errors.Is(err, services.ErrExternalAccountConflict),
+		errors.Is(err, services.ErrExternalStaffLink):
+		return err.Error()
+	}
+	return "Login SSO gagal"
+}
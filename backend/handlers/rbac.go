@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AssignRoleInput struct {
+	Role    string      `json:"role" binding:"required"`
+	RoomIDs []uuid.UUID `json:"room_ids"`
+}
+
+// accessFromContext mengambil Access yang disimpan RequirePermission.
+func accessFromContext(c *gin.Context) *services.Access {
+	if v, ok := c.Get("access"); ok {
+		if access, ok := v.(*services.Access); ok {
+			return access
+		}
+	}
+	return nil
+}
+
+// authorizeRooms mengecek permission untuk ruangan tertentu dan membalas 403
+// bila ditolak. Pemanggil cukup return bila hasilnya false.
+func authorizeRooms(c *gin.Context, perm models.Permission, roomIDs ...uuid.UUID) bool {
+	if accessFromContext(c).CanForRoom(perm, roomIDs...) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda tidak punya akses ke ruangan ini", "data": nil})
+	return false
+}
+
+// authorizeBooking seperti authorizeRooms untuk ruangan sebuah booking. Booking
+// yang tidak ada dibiarkan lolos agar handler membalas 404 seperti biasa.
+func authorizeBooking(c *gin.Context, perm models.Permission, bookingID uuid.UUID) bool {
+	var booking models.Booking
+	if err := config.DB.Unscoped().Select("id", "room_id").First(&booking, bookingID).Error; err != nil {
+		return true
+	}
+	return authorizeRooms(c, perm, booking.RoomID)
+}
+
+// authorizeSeries seperti authorizeRooms untuk ruangan sebuah seri booking.
+func authorizeSeries(c *gin.Context, perm models.Permission, seriesID uuid.UUID) bool {
+	var series models.BookingSeries
+	if err := config.DB.Select("id", "room_id").First(&series, seriesID).Error; err != nil {
+		return true
+	}
+	return authorizeRooms(c, perm, series.RoomID)
+}
+
+// GetMyAccess godoc
+// @Summary Current admin access
+// @Description Role, permissions and assigned rooms of the logged in staff user
+// @Tags rbac
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/admin/me [get]
+func GetMyAccess(c *gin.Context) {
+	access := accessFromContext(c)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Akses berhasil diambil", "data": gin.H{
+		"user_id":     access.UserID,
+		"role":        access.Role,
+		"permissions": models.RolePermissions(access.Role),
+		"room_ids":    access.ScopedRoomIDs(),
+	}})
+}
+
+// ListUsers godoc
+// @Summary List users and roles
+// @Description List users with their role, permissions and assigned rooms (super admin only)
+// @Tags rbac
+// @Produce  json
+// @Param   role  query  string  false  "Filter by role"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/users [get]
+func ListUsers(c *gin.Context) {
+	users, err := services.ListUsers(c.Query("role"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data user", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data user berhasil diambil", "data": users})
+}
+
+// AssignUserRole godoc
+// @Summary Assign role
+// @Description Set a user's role. room_ids is required for room_manager and approver and ignored for other roles.
+// @Tags rbac
+// @Accept  json
+// @Produce  json
+// @Param   id     path  string           true  "User ID"
+// @Param   input  body  AssignRoleInput  true  "Role and rooms"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/admin/users/{id}/role [put]
+func AssignUserRole(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID user tidak valid", "data": nil})
+		return
+	}
+	var input AssignRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	if models.IsRoomScopedRole(models.NormalizeRole(input.Role)) && len(input.RoomIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "room_ids wajib diisi untuk role " + input.Role, "data": nil})
+		return
+	}
+
+	before, err := userAccessSnapshot(userUUID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User tidak ditemukan", "data": nil})
+		return
+	}
+	user, err := services.AssignRole(userUUID, input.Role, input.RoomIDs)
+	switch {
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrRoomNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	case errors.Is(err, services.ErrLastSuperAdmin):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditUserRole, EntityType: services.AuditEntityUser, EntityID: userUUID.String(),
+		Before: before, After: gin.H{"role": user.Role, "room_ids": user.RoomIDs}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Role user berhasil diperbarui", "data": user})
+}
+
+func userAccessSnapshot(userID uuid.UUID) (gin.H, error) {
+	access, err := services.LoadAccess(userID)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs := access.ScopedRoomIDs()
+	if roomIDs == nil {
+		roomIDs = []uuid.UUID{}
+	}
+	return gin.H{"role": access.Role, "room_ids": roomIDs}, nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"backendgo/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRetentionStatus godoc
+// @Summary Retention policy status
+// @Description Show the active booking retention policy and job metrics since the server started
+// @Tags retention
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/admin/retention [get]
+func GetRetentionStatus(c *gin.Context) {
+	policy := services.RetentionPolicyFromEnv()
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Status retensi booking", "data": gin.H{
+		"policy": gin.H{
+			"complete_after_minutes": int(policy.CompleteAfter / time.Minute),
+			"archive_after_days":     int(policy.ArchiveAfter / (24 * time.Hour)),
+			"export_dir":             policy.ExportDir,
+			"dry_run":                policy.DryRun,
+		},
+		"metrics": services.GetRetentionMetrics(),
+	}})
+}
+
+// RunRetention godoc
+// @Summary Run retention job
+// @Description Run one retention pass now. With dry_run=true nothing is changed and the report shows what would happen.
+// @Tags retention
+// @Produce  json
+// @Param   dry_run  query  bool  false  "Only report, do not change anything (default from RETENTION_DRY_RUN)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/admin/retention/run [post]
+func RunRetention(c *gin.Context) {
+	policy := services.RetentionPolicyFromEnv()
+	if v := c.Query("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Parameter dry_run tidak valid", "data": nil})
+			return
+		}
+		policy.DryRun = dryRun
+	}
+
+	report, err := services.RunRetention(policy, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error(), "data": report})
+		return
+	}
+	if !report.DryRun {
+		recordAudit(c, services.AuditEntry{Action: services.AuditRetentionRun, EntityType: services.AuditEntityRetention, After: report})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Retensi booking selesai dijalankan", "data": report})
+}
@@ -3,8 +3,13 @@ package handlers
 import (
 	"backendgo/config"
 	"backendgo/models"
+	"backendgo/services"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -52,6 +57,91 @@ func GetRooms(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data ruangan berhasil diambil", "data": rooms})
 }
 
+// GetRoomAvailability godoc
+// @Summary Get room availability
+// @Description Get free windows and a free/busy bitmap per room for a date range. Bitmap characters: 0 free, 1 tentative (pending), 2 busy.
+// @Tags room
+// @Accept  json
+// @Produce  json
+// @Param   start            query  string  true   "Range start (RFC3339)"
+// @Param   end              query  string  true   "Range end (RFC3339)"
+// @Param   duration         query  int     true   "Required duration in minutes"
+// @Param   attendees        query  int     false  "Number of attendees"
+// @Param   room_id          query  string  false  "Comma separated room IDs"
+// @Param   include_pending  query  bool    false  "Treat pending bookings as holds (default true)"
+// @Param   slot_minutes     query  int     false  "Bitmap slot size in minutes (default 30)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/rooms/availability [get]
+func GetRoomAvailability(c *gin.Context) {
+	query, err := parseAvailabilityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	rooms, err := services.GetRoomAvailability(query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ketersediaan ruangan berhasil diambil", "data": gin.H{
+		"start":        query.Start,
+		"end":          query.End,
+		"slot_minutes": int(query.SlotSize / time.Minute),
+		"rooms":        rooms,
+	}})
+}
+
+func parseAvailabilityQuery(c *gin.Context) (services.AvailabilityQuery, error) {
+	q := services.AvailabilityQuery{IncludePending: true}
+
+	start, err := time.Parse(time.RFC3339, c.Query("start"))
+	if err != nil {
+		return q, fmt.Errorf("parameter start tidak valid (format RFC3339)")
+	}
+	end, err := time.Parse(time.RFC3339, c.Query("end"))
+	if err != nil {
+		return q, fmt.Errorf("parameter end tidak valid (format RFC3339)")
+	}
+	q.Start, q.End = start, end
+
+	minutes, err := strconv.Atoi(c.Query("duration"))
+	if err != nil || minutes <= 0 {
+		return q, fmt.Errorf("parameter duration tidak valid")
+	}
+	q.Duration = time.Duration(minutes) * time.Minute
+
+	if a := c.Query("attendees"); a != "" {
+		if q.Attendees, err = strconv.Atoi(a); err != nil {
+			return q, fmt.Errorf("parameter attendees tidak valid")
+		}
+	}
+	if p := c.Query("include_pending"); p != "" {
+		if q.IncludePending, err = strconv.ParseBool(p); err != nil {
+			return q, fmt.Errorf("parameter include_pending tidak valid")
+		}
+	}
+	if s := c.Query("slot_minutes"); s != "" {
+		slot, err := strconv.Atoi(s)
+		if err != nil {
+			return q, fmt.Errorf("parameter slot_minutes tidak valid")
+		}
+		q.SlotSize = time.Duration(slot) * time.Minute
+	}
+	if ids := c.Query("room_id"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			roomUUID, err := uuid.Parse(strings.TrimSpace(id))
+			if err != nil {
+				return q, fmt.Errorf("format ID ruangan tidak valid")
+			}
+			q.RoomIDs = append(q.RoomIDs, roomUUID)
+		}
+	}
+	return q, nil
+}
+
 // GetRoomDetail godoc
 // @Summary Get room detail
 // @Description Get detail of a room by ID
@@ -101,6 +191,7 @@ type CreateRoomInput struct {
 // @Param   input  body  CreateRoomInput  true  "Room info"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/rooms [post]
 func CreateRoom(c *gin.Context) {
@@ -110,6 +201,13 @@ func CreateRoom(c *gin.Context) {
 		return
 	}
 
+	// Nama ruangan unik termasuk untuk ruangan yang sudah dihapus
+	var deleted models.Room
+	if err := config.DB.Unscoped().Where("name = ? AND deleted_at IS NOT NULL", input.Name).First(&deleted).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Ruangan dengan nama ini pernah dihapus; pulihkan ruangan tersebut atau gunakan nama lain", "data": gin.H{"deleted_room": deleted}})
+		return
+	}
+
 	room := models.Room{
 		Name:        input.Name,
 		Description: input.Description,
@@ -119,6 +217,7 @@ func CreateRoom(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat ruangan", "data": nil})
 		return
 	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditRoomCreate, EntityType: services.AuditEntityRoom, EntityID: room.ID.String(), After: room})
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ruangan berhasil dibuat", "data": room})
 }
 
@@ -148,6 +247,9 @@ func UpdateRoom(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID ruangan tidak valid", "data": nil})
 		return
 	}
+	if !authorizeRooms(c, models.PermRoomsUpdate, roomUUID) {
+		return
+	}
 
 	var room models.Room
 	if err := config.DB.First(&room, roomUUID).Error; err != nil {
@@ -161,6 +263,7 @@ func UpdateRoom(c *gin.Context) {
 		return
 	}
 
+	before := room
 	if input.Name != "" {
 		room.Name = input.Name
 	}
@@ -175,22 +278,26 @@ func UpdateRoom(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui ruangan", "data": nil})
 		return
 	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditRoomUpdate, EntityType: services.AuditEntityRoom, EntityID: room.ID.String(), Before: before, After: room})
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ruangan berhasil diperbarui", "data": room})
 }
 
 // DeleteRoom godoc
 // @Summary Delete room
-// @Description Delete a room by ID
+// @Description Soft delete a room by ID. Upcoming pending/approved bookings are handled by policy: block (default, from ROOM_DELETE_POLICY) refuses while they exist, cancel cancels and notifies requesters, migrate moves them to target_room_id if all of them fit.
 // @Tags room
 // @Accept  json
 // @Produce  json
-// @Param   id  path  string  true  "Room ID"
+// @Param   id              path   string  true   "Room ID"
+// @Param   policy          query  string  false  "block, cancel or migrate"
+// @Param   target_room_id  query  string  false  "Target room ID for migrate"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
 // @Router /api/rooms/{id} [delete]
-func DeleteRoom(c *gin.Context) {
+func (h *BookingHandler) DeleteRoom(c *gin.Context) {
 	id := c.Param("id")
 	roomUUID, err := uuid.Parse(id)
 	if err != nil {
@@ -198,15 +305,89 @@ func DeleteRoom(c *gin.Context) {
 		return
 	}
 
-	var room models.Room
-	if err := config.DB.First(&room, roomUUID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Ruangan tidak ditemukan", "data": nil})
+	policy := services.DefaultRoomDeletePolicy()
+	if p := c.Query("policy"); p != "" {
+		policy = services.RoomDeletePolicy(p)
+	}
+	var targetRoomID *uuid.UUID
+	if t := c.Query("target_room_id"); t != "" {
+		target, err := uuid.Parse(t)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID ruangan tujuan tidak valid", "data": nil})
+			return
+		}
+		targetRoomID = &target
+	}
+
+	before := auditSnapshot(&models.Room{}, roomUUID)
+	result, err := services.DeleteRoomService(roomUUID, policy, targetRoomID, actorFromContext(c), h.EmailService)
+	if err != nil {
+		respondRoomError(c, err)
 		return
 	}
+	affected := make([]string, 0, len(result.Bookings))
+	for _, b := range result.Bookings {
+		affected = append(affected, b.ID.String())
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditRoomDelete, EntityType: services.AuditEntityRoom, EntityID: roomUUID.String(), Before: before,
+		After: gin.H{"policy": result.Policy, "target_room_id": targetRoomID, "bookings": affected}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ruangan berhasil dihapus", "data": result})
+}
 
-	if err := config.DB.Delete(&room).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus ruangan", "data": nil})
+// GetDeletedRooms godoc
+// @Summary List deleted rooms
+// @Description List soft deleted rooms that can still be restored (admin only)
+// @Tags room
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/rooms/deleted [get]
+func GetDeletedRooms(c *gin.Context) {
+	rooms, err := services.ListDeletedRooms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data ruangan terhapus", "data": nil})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ruangan berhasil dihapus", "data": nil})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data ruangan terhapus berhasil diambil", "data": rooms})
+}
+
+// RestoreRoom godoc
+// @Summary Restore room
+// @Description Restore a soft deleted room. Bookings cancelled or migrated when it was deleted stay as they are.
+// @Tags room
+// @Produce  json
+// @Param   id  path  string  true  "Room ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /api/rooms/{id}/restore [post]
+func RestoreRoom(c *gin.Context) {
+	roomUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID ruangan tidak valid", "data": nil})
+		return
+	}
+	room, err := services.RestoreRoomService(roomUUID)
+	if err != nil {
+		respondRoomError(c, err)
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditRoomRestore, EntityType: services.AuditEntityRoom, EntityID: room.ID.String(), After: room})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ruangan berhasil dipulihkan", "data": room})
+}
+
+// respondRoomError memetakan error service ruangan ke status HTTP.
+func respondRoomError(c *gin.Context, err error) {
+	var inUseErr *services.RoomInUseError
+	switch {
+	case errors.As(err, &inUseErr):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error() + "; gunakan policy=cancel atau policy=migrate", "data": gin.H{"bookings": inUseErr.Bookings}})
+	case errors.Is(err, services.ErrRoomNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Ruangan tidak ditemukan", "data": nil})
+	case errors.Is(err, services.ErrRoomNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error(), "data": nil})
+	default:
+		respondBookingError(c, err, http.StatusBadRequest)
+	}
 }
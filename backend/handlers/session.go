@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing an old one revokes the whole session.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   input  body  RefreshTokenInput  true  "Refresh token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Router /api/auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var input RefreshTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	}
+
+	session, user, refreshToken, err := services.RefreshSession(input.RefreshToken, sessionClient(c))
+	switch {
+	case errors.Is(err, services.ErrSessionReused):
+		recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: &session.UserID}, Action: services.AuditRefreshReuse, EntityType: services.AuditEntitySession, EntityID: session.ID.String()})
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	case errors.Is(err, services.ErrSessionInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error(), "data": nil})
+		return
+	case errors.Is(err, services.ErrUserDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Akun dinonaktifkan", "data": nil})
+		return
+	case err != nil:
+		log.WithError(err).Error("Refresh token: gagal memperbarui sesi")
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui sesi", "data": nil})
+		return
+	}
+
+	accessToken, ttl, err := services.IssueAccessToken(user, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to generate token", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Token diperbarui", "data": newTokenResponse(accessToken, ttl, refreshToken)})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description End the current session. The access token (if sent) is added to the revocation list and the session of the access token or of refresh_token is revoked. Always succeeds so clients can clear their tokens.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param   input  body  LogoutInput  false  "Refresh token of the session"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/auth/logout [post]
+func Logout(c *gin.Context) {
+	var input LogoutInput
+	// Body boleh kosong bila access token masih berlaku
+	_ = c.ShouldBindJSON(&input)
+
+	var userID *uuid.UUID
+	if id, ok := c.Get("id"); ok {
+		uid := id.(uuid.UUID)
+		userID = &uid
+		revokeCurrentAccessToken(c)
+		if err := services.RevokeSession(c.MustGet("sid").(uuid.UUID), services.SessionRevokedLogout); err != nil {
+			log.WithError(err).Error("Logout: gagal mencabut sesi")
+		}
+	}
+	if input.RefreshToken != "" {
+		session, err := services.RevokeSessionByRefreshToken(input.RefreshToken, services.SessionRevokedLogout)
+		if err != nil {
+			log.WithError(err).Error("Logout: gagal mencabut sesi")
+		}
+		if session != nil && userID == nil {
+			userID = &session.UserID
+		}
+	}
+
+	if userID != nil {
+		recordAudit(c, services.AuditEntry{Actor: services.Actor{ID: userID}, Action: services.AuditLogout, EntityType: services.AuditEntityUser, EntityID: userID.String()})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logout berhasil", "data": nil})
+}
+
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Revoke every session of the logged-in user, including the current one
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/logout-all [post]
+func LogoutAll(c *gin.Context) {
+	userID := c.MustGet("id").(uuid.UUID)
+	revokeCurrentAccessToken(c)
+	revoked, err := services.RevokeUserSessions(userID, services.SessionRevokedLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencabut sesi", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditLogoutAll, EntityType: services.AuditEntityUser, EntityID: userID.String(), After: gin.H{"sessions": revoked}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Semua sesi berhasil diakhiri", "data": gin.H{"revoked": revoked}})
+}
+
+// GetMySessions godoc
+// @Summary List my sessions
+// @Description List the active sessions (devices) of the logged-in user. current marks the session of this request.
+// @Tags auth
+// @Produce  json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Router /api/auth/sessions [get]
+func GetMySessions(c *gin.Context) {
+	userID := c.MustGet("id").(uuid.UUID)
+	sessions, _, err := services.ListActiveSessions(services.SessionFilter{UserID: &userID, Limit: 200})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data sesi", "data": nil})
+		return
+	}
+	current := c.MustGet("sid").(uuid.UUID)
+	items := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, gin.H{
+			"id":           s.ID,
+			"ip":           s.IP,
+			"user_agent":   s.UserAgent,
+			"created_at":   s.CreatedAt,
+			"last_used_at": s.LastUsedAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.ID == current,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data sesi berhasil diambil", "data": items})
+}
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List active sessions of all users, most recently used first
+// @Tags sessions
+// @Produce  json
+// @Param   user_id  query  string  false  "Only sessions of this user"
+// @Param   limit    query  int     false  "Page size (default 50, max 200)"
+// @Param   offset   query  int     false  "Offset"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Router /api/admin/sessions [get]
+func ListSessions(c *gin.Context) {
+	var filter services.SessionFilter
+	if v := c.Query("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format user_id tidak valid", "data": nil})
+			return
+		}
+		filter.UserID = &userID
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	sessions, total, err := services.ListActiveSessions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data sesi", "data": nil})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Data sesi berhasil diambil", "data": gin.H{"sessions": sessions, "total": total}})
+}
+
+// RevokeSession godoc
+// @Summary Revoke session
+// @Description End one session of any user. Its refresh token stops working and its access tokens are rejected immediately.
+// @Tags sessions
+// @Produce  json
+// @Param   id  path  string  true  "Session ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID sesi tidak valid", "data": nil})
+		return
+	}
+	session, err := services.GetSession(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !session.IsActive(time.Now())) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Sesi tidak ditemukan", "data": nil})
+		return
+	}
+	if err == nil {
+		err = services.RevokeSession(session.ID, services.SessionRevokedAdmin)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencabut sesi", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditSessionRevoke, EntityType: services.AuditEntitySession, EntityID: session.ID.String(),
+		After: gin.H{"user_id": session.UserID, "ip": session.IP, "user_agent": session.UserAgent}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Sesi berhasil dicabut", "data": nil})
+}
+
+// LogoutUser godoc
+// @Summary Log out a user everywhere
+// @Description Revoke every active session of a user
+// @Tags sessions
+// @Produce  json
+// @Param   id  path  string  true  "User ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Router /api/admin/users/{id}/logout-all [post]
+func LogoutUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID user tidak valid", "data": nil})
+		return
+	}
+	if err := config.DB.Select("id").First(&models.User{}, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User tidak ditemukan", "data": nil})
+		return
+	}
+	revoked, err := services.RevokeUserSessions(userID, services.SessionRevokedAdmin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencabut sesi", "data": nil})
+		return
+	}
+	recordAudit(c, services.AuditEntry{Action: services.AuditLogoutAll, EntityType: services.AuditEntityUser, EntityID: userID.String(), After: gin.H{"sessions": revoked}})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Semua sesi user berhasil diakhiri", "data": gin.H{"revoked": revoked}})
+}
+
+// revokeCurrentAccessToken memasukkan jti access token request ini ke daftar
+// revokasi.
+func revokeCurrentAccessToken(c *gin.Context) {
+	jti := c.GetString("jti")
+	exp, _ := c.Get("token_exp")
+	expiresAt, _ := exp.(time.Time)
+	if err := services.RevokeAccessToken(jti, expiresAt); err != nil {
+		log.WithError(err).Error("Logout: gagal mencabut access token")
+	}
+}
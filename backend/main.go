@@ -3,14 +3,12 @@ package main
 import (
 	"backendgo/config"
 	"backendgo/handlers"
+	"backendgo/middleware"
 	"backendgo/models"
 	"backendgo/routes"
 	"backendgo/services"
+	"context"
 	"log"
-	"time"
-
-	"fmt"
-	"os"
 
 	_ "backendgo/docs"
 
@@ -31,14 +29,35 @@ func main() {
 		log.Println("Warning: .env file not found, using system environment variables")
 	}
 
-	fmt.Println("SENDGRID_API_KEY:", os.Getenv("SENDGRID_API_KEY"))
+	// Key JWT dibaca saat start agar konfigurasi yang salah langsung ketahuan
+	log.Printf("JWT signing key: %s", services.JWTKeys().ActiveKeyID())
+	services.InitBookingTokens()
 
 	config.ConnectDatabase()
-	config.DB.AutoMigrate(&models.User{}, &models.Room{}, &models.Booking{})
+	config.DB.AutoMigrate(&models.User{}, &models.Room{}, &models.Booking{}, &models.BookingSeries{}, &models.BookingStatusTransition{}, &models.CalendarFeed{}, &models.Notification{}, &models.EmailTemplate{}, &models.BookingReminder{}, &models.RequesterStat{}, &models.BookingArchive{}, &models.AuditLog{}, &models.RoomAssignment{}, &models.OIDCLoginState{}, &models.Session{}, &models.RevokedToken{})
+	if err := services.MigrateLegacyAdminRoles(); err != nil {
+		log.Println("Warning: gagal migrasi role admin lama:", err)
+	}
+	if err := services.RedactSecretNotifications(); err != nil {
+		log.Println("Warning: gagal menghapus OTP dari outbox:", err)
+	}
 
 	emailService := services.NewEmailService()
 	bookingHandler := &handlers.BookingHandler{EmailService: emailService}
 
+	// Kirim email dari outbox di background
+	go services.NewNotificationWorker(emailService).Run(context.Background())
+	// Pengingat sebelum meeting dimulai (REMINDER_OFFSETS, default 24h,15m)
+	go services.NewReminderScheduler(emailService).Run(context.Background())
+	// Booking approved yang tidak check-in dilepas sebagai no_show (NO_SHOW_MINUTES)
+	go services.NewNoShowWorker().Run(context.Background())
+	// Booking yang sudah lewat diselesaikan lalu diarsipkan sesuai kebijakan retensi
+	go services.NewRetentionWorker().Run(context.Background())
+	// User LDAP dibuat/dinonaktifkan mengikuti directory (LDAP_SYNC_INTERVAL)
+	go services.NewDirectorySyncWorker().Run(context.Background())
+	// Sesi dan jti yang sudah kedaluwarsa dihapus setiap jam
+	go services.NewSessionCleanupWorker().Run(context.Background())
+
 	r := gin.Default()
 
 	r.Use(cors.New(cors.Config{
@@ -58,23 +77,11 @@ func main() {
 	store := memory.NewStore()
 	rateLimiter := ginmiddleware.NewMiddleware(limiter.New(store, rate))
 
-	r.POST("/api/bookings", rateLimiter, bookingHandler.CreateBooking)
+	r.POST("/api/bookings", rateLimiter, middleware.OptionalAuthMiddleware(), bookingHandler.CreateBooking)
+	r.POST("/api/bookings/series", rateLimiter, middleware.OptionalAuthMiddleware(), bookingHandler.CreateBookingSeries)
 
 	routes.RegisterRoutes(r, bookingHandler)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	go func() {
-		for {
-			time.Sleep(5 * time.Minute)
-			threshold := time.Now().Add(-2 * time.Hour)
-			result := config.DB.Where("end_time < ?", threshold).Delete(&models.Booking{})
-			if result.Error != nil {
-				log.Println("Auto-delete booking error:", result.Error)
-			} else if result.RowsAffected > 0 {
-				log.Printf("Auto-deleted %d expired bookings\n", result.RowsAffected)
-			}
-		}
-	}()
-
 	r.Run(":8080")
 }
@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"backendgo/config"
+	"backendgo/models"
+	"backendgo/services"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -10,8 +14,6 @@ import (
 	"github.com/google/uuid"
 )
 
-var JwtKey = []byte("your_secret_key")
-
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -20,67 +22,143 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return JwtKey, nil
-		})
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, msg := parseToken(strings.TrimPrefix(authHeader, "Bearer "))
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
 			c.Abort()
 			return
 		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
+		claims.set(c)
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware mengisi id dan role bila request membawa token yang
+// valid, tanpa menolak request anonim. Dipakai endpoint publik yang perlu
+// tahu requester yang sedang login, mis. pembuatan booking.
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			if claims, msg := parseToken(strings.TrimPrefix(authHeader, "Bearer ")); msg == "" {
+				claims.set(c)
+			}
 		}
-		// Cek expiry (exp)
-		expVal, ok := claims["exp"]
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token missing expiry"})
-			c.Abort()
-			return
+		c.Next()
+	}
+}
+
+// tokenClaims adalah isi access token yang sudah divalidasi.
+type tokenClaims struct {
+	ID        uuid.UUID
+	Role      string
+	JTI       string
+	SessionID uuid.UUID
+	ExpiresAt time.Time
+}
+
+// set menyimpan claims di context: id dan role untuk otorisasi, jti, sid dan
+// token_exp untuk logout.
+func (t tokenClaims) set(c *gin.Context) {
+	c.Set("id", t.ID)
+	c.Set("role", t.Role)
+	c.Set("jti", t.JTI)
+	c.Set("sid", t.SessionID)
+	c.Set("token_exp", t.ExpiresAt)
+}
+
+// parseToken memvalidasi JWT, memastikan jti belum dicabut dan sesinya masih
+// aktif, lalu mengembalikan claims di dalamnya. msg berisi alasan penolakan
+// bila token tidak valid.
+func parseToken(tokenString string) (claims tokenClaims, msg string) {
+	parsed, err := services.ParseAccessToken(tokenString)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return claims, "Token expired"
+	case errors.Is(err, services.ErrAccessTokenNoSession):
+		// Token tanpa sesi (diterbitkan sebelum ada refresh token) tidak bisa
+		// dicabut, jadi ditolak dan user diminta login ulang
+		return claims, "Token has no session"
+	case err != nil:
+		return claims, "Invalid token"
+	}
+	claims = tokenClaims{
+		ID:        parsed.UserID,
+		Role:      parsed.Role,
+		JTI:       parsed.ID,
+		SessionID: parsed.SessionID,
+		ExpiresAt: parsed.ExpiresAt.Time,
+	}
+	if err := services.ValidateAccessToken(claims.JTI, claims.SessionID); err != nil {
+		if errors.Is(err, services.ErrSessionRevoked) {
+			return claims, "Token revoked"
 		}
-		var exp int64
-		switch v := expVal.(type) {
-		case float64:
-			exp = int64(v)
-		case int64:
-			exp = v
-		default:
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid exp in token"})
+		return claims, "Unable to validate token"
+	}
+	return claims, ""
+}
+
+// RequirePermission memastikan user yang login punya permission perm. Role
+// dibaca ulang dari database dan disimpan di context sebagai "access" agar
+// handler bisa mengecek cakupan ruangan. Harus dipasang setelah AuthMiddleware.
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := c.Get("id")
+		id, ok := userID.(uuid.UUID)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-		if exp < time.Now().Unix() {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
+		access, err := services.LoadAccess(id)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-		idStr, ok := claims["id"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid id in token"})
+		if !access.Can(perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied", "permission": perm})
 			c.Abort()
 			return
 		}
-		idUUID, err := uuid.Parse(idStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid id format"})
+		c.Set("access", access)
+		c.Set("role", access.Role)
+		c.Next()
+	}
+}
+
+func RequesterOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists || role != models.RoleRequester {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Requester only"})
 			c.Abort()
 			return
 		}
-		c.Set("id", idUUID)
-		c.Set("role", claims["role"].(string))
 		c.Next()
 	}
 }
 
-func AdminOnly() gin.HandlerFunc {
+// BookingOwnerOnly memastikan booking :id milik requester yang sedang login.
+// Harus dipasang setelah AuthMiddleware.
+func BookingOwnerOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists || role != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Admin only"})
+		bookingID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format ID booking tidak valid", "data": nil})
+			c.Abort()
+			return
+		}
+		userID, _ := c.Get("id")
+		var booking models.Booking
+		if err := config.DB.Select("id", "user_id").First(&booking, bookingID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
+			c.Abort()
+			return
+		}
+		// Booking orang lain dibalas 404 agar keberadaannya tidak bocor
+		if booking.UserID == nil || *booking.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Booking tidak ditemukan", "data": nil})
 			c.Abort()
 			return
 		}
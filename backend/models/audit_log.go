@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable dikembalikan bila ada yang mencoba mengubah atau
+// menghapus baris audit log. Satu-satunya pengecualian adalah anonimisasi data
+// pemesan oleh retensi, yang memakai UpdateColumns sehingga hook dilewati.
+var ErrAuditLogImmutable = errors.New("audit log hanya boleh ditambah")
+
+// AuditLog mencatat satu perubahan yang dilakukan lewat API. Before dan After
+// berisi JSON kolom yang berubah saja; pada create/delete berisi seluruh data.
+type AuditLog struct {
+	ID         uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ActorID    *uuid.UUID `json:"actor_id,omitempty" gorm:"type:char(36);column:actor_id;index"`
+	ActorRole  string     `json:"actor_role" gorm:"column:actor_role;size:32"`
+	Action     string     `json:"action" gorm:"column:action;size:64;index"`
+	EntityType string     `json:"entity_type" gorm:"column:entity_type;size:32;index:idx_audit_entity"`
+	EntityID   string     `json:"entity_id" gorm:"column:entity_id;size:64;index:idx_audit_entity"`
+	Before     string     `json:"before,omitempty" gorm:"column:before_data;type:text"`
+	After      string     `json:"after,omitempty" gorm:"column:after_data;type:text"`
+	IP         string     `json:"ip" gorm:"column:ip;size:64"`
+	UserAgent  string     `json:"user_agent" gorm:"column:user_agent;size:255"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;index"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return
+}
+
+func (a *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (a *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
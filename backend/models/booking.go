@@ -8,17 +8,41 @@ import (
 )
 
 type Booking struct {
-	ID          uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
-	RoomID      uuid.UUID `json:"room_id" gorm:"type:char(36);column:room_id"`
-	UserName    string    `json:"user_name" gorm:"column:user_name"`
-	UserEmail   string    `json:"user_email" gorm:"column:user_email"`
-	Purpose     string    `json:"purpose" gorm:"column:purpose"`
-	Attendees   int       `json:"attendees" gorm:"column:attendees"`
-	StartTime   time.Time `json:"start_time" gorm:"column:start_time"`
-	EndTime     time.Time `json:"end_time" gorm:"column:end_time"`
-	Status      string    `json:"status" gorm:"column:status"`
-	QRCodeToken string    `json:"qr_code_token" gorm:"column:qr_code_token"`
-	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at"`
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	RoomID    uuid.UUID `json:"room_id" gorm:"type:char(36);column:room_id"`
+	UserName  string    `json:"user_name" gorm:"column:user_name"`
+	UserEmail string    `json:"user_email" gorm:"column:user_email"`
+	// UserID menautkan booking ke akun requester; kosong untuk booking tanpa login
+	UserID      *uuid.UUID    `json:"user_id,omitempty" gorm:"type:char(36);column:user_id;index"`
+	Purpose     string        `json:"purpose" gorm:"column:purpose"`
+	Attendees   int           `json:"attendees" gorm:"column:attendees"`
+	StartTime   time.Time     `json:"start_time" gorm:"column:start_time"`
+	EndTime     time.Time     `json:"end_time" gorm:"column:end_time"`
+	Status      BookingStatus `json:"status" gorm:"column:status"`
+	QRCodeToken string        `json:"-" gorm:"column:qr_code_token"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"column:created_at"`
+	SeriesID    *uuid.UUID    `json:"series_id,omitempty" gorm:"type:char(36);column:series_id;index"`
+	// IsException menandai kemunculan seri yang sudah diubah secara individual
+	IsException bool `json:"is_exception" gorm:"column:is_exception"`
+	// Sequence dinaikkan setiap perubahan penting untuk SEQUENCE iCalendar
+	Sequence int `json:"sequence" gorm:"column:sequence;default:0"`
+	// Locale adalah bahasa email untuk pemesan (id atau en)
+	Locale string `json:"locale" gorm:"column:locale;size:5"`
+	// CheckInRequired menandai booking yang dibuat setelah fitur check-in ada.
+	// Booking lama bernilai false sehingga tidak pernah ditandai no_show.
+	CheckInRequired bool `json:"check_in_required" gorm:"column:check_in_required;default:false"`
+	// CheckedInAt diisi saat QR booking dipindai di ruangan
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty" gorm:"column:checked_in_at"`
+	// EndedAt diisi saat meeting diakhiri, lebih awal maupun setelah overtime
+	EndedAt *time.Time `json:"ended_at,omitempty" gorm:"column:ended_at"`
+	// OvertimeMinutes adalah lama meeting melewati EndTime, dicatat saat diakhiri
+	OvertimeMinutes int `json:"overtime_minutes" gorm:"column:overtime_minutes;default:0"`
+	// ExtendedMinutes adalah total perpanjangan dari jadwal awal
+	ExtendedMinutes int `json:"extended_minutes" gorm:"column:extended_minutes;default:0"`
+	// TokenVersion dinaikkan untuk mencabut semua token QR yang sudah dibagikan
+	TokenVersion int `json:"-" gorm:"column:token_version;default:0"`
+	// DeletedAt diisi saat booking dihapus; booking bisa dipulihkan oleh admin
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"column:deleted_at;index"`
 
 	// Add relationship to Room
 	Room Room `json:"room,omitempty" gorm:"foreignKey:RoomID;references:ID"`
@@ -32,6 +56,27 @@ type CreateBookingInput struct {
 	RoomID    string    `json:"room_id" binding:"required"`
 	StartTime time.Time `json:"start_time" binding:"required"`
 	EndTime   time.Time `json:"end_time" binding:"required"`
+	// Locale opsional; bila kosong diambil dari header Accept-Language
+	Locale string `json:"locale" binding:"omitempty,oneof=id en"`
+	// UserID diisi dari token bila booking dibuat oleh requester yang login
+	UserID *uuid.UUID `json:"-"`
+}
+
+type UpdateBookingInput struct {
+	RoomID    string        `json:"room_id"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Status    BookingStatus `json:"status"`
+	Purpose   string        `json:"purpose"`
+	Attendees int           `json:"attendees"`
+	Reason    string        `json:"reason"`
+}
+
+// RescheduleBookingInput dipakai requester untuk memindah jadwal bookingnya sendiri.
+type RescheduleBookingInput struct {
+	RoomID    string    `json:"room_id"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
 }
 
 func (Booking) TableName() string {
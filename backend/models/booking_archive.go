@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingArchive adalah salinan booking lama setelah dikeluarkan dari tabel
+// bookings. Nama dan email pemesan tidak disimpan; RequesterHash tetap
+// memungkinkan rekap per pemesan tanpa mengungkap identitasnya.
+type BookingArchive struct {
+	ID              uuid.UUID     `json:"id" gorm:"type:char(36);primaryKey"`
+	RoomID          uuid.UUID     `json:"room_id" gorm:"type:char(36);column:room_id;index"`
+	RoomName        string        `json:"room_name" gorm:"column:room_name"`
+	RequesterHash   string        `json:"requester_hash" gorm:"column:requester_hash;size:64;index"`
+	Attendees       int           `json:"attendees" gorm:"column:attendees"`
+	StartTime       time.Time     `json:"start_time" gorm:"column:start_time;index"`
+	EndTime         time.Time     `json:"end_time" gorm:"column:end_time"`
+	Status          BookingStatus `json:"status" gorm:"column:status"`
+	SeriesID        *uuid.UUID    `json:"series_id,omitempty" gorm:"type:char(36);column:series_id"`
+	CheckedInAt     *time.Time    `json:"checked_in_at,omitempty" gorm:"column:checked_in_at"`
+	EndedAt         *time.Time    `json:"ended_at,omitempty" gorm:"column:ended_at"`
+	OvertimeMinutes int           `json:"overtime_minutes" gorm:"column:overtime_minutes"`
+	ExtendedMinutes int           `json:"extended_minutes" gorm:"column:extended_minutes"`
+	CreatedAt       time.Time     `json:"created_at" gorm:"column:created_at"`
+	ArchivedAt      time.Time     `json:"archived_at" gorm:"column:archived_at"`
+}
+
+func (BookingArchive) TableName() string {
+	return "booking_archives"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookingReminder mencatat pengingat yang sudah diantrekan. Kunci unik
+// booking + offset + jadwal mulai membuat pengingat tidak pernah terkirim dua
+// kali, sementara booking yang dijadwal ulang tetap mendapat pengingat baru.
+type BookingReminder struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	BookingID      uuid.UUID  `json:"booking_id" gorm:"type:char(36);column:booking_id;uniqueIndex:idx_booking_reminders_key,priority:1"`
+	OffsetMinutes  int        `json:"offset_minutes" gorm:"column:offset_minutes;uniqueIndex:idx_booking_reminders_key,priority:2"`
+	StartTime      time.Time  `json:"start_time" gorm:"column:start_time;uniqueIndex:idx_booking_reminders_key,priority:3"`
+	NotificationID *uuid.UUID `json:"notification_id,omitempty" gorm:"type:char(36);column:notification_id;index"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+}
+
+func (BookingReminder) TableName() string {
+	return "booking_reminders"
+}
+
+func (r *BookingReminder) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// BookingSeries menyimpan aturan booking berulang (RRULE) beserta data
+// template-nya. Setiap kemunculan disimpan sebagai Booking biasa dengan SeriesID.
+type BookingSeries struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	RoomID    uuid.UUID `json:"room_id" gorm:"type:char(36);column:room_id"`
+	UserName  string    `json:"user_name" gorm:"column:user_name"`
+	UserEmail string    `json:"user_email" gorm:"column:user_email"`
+	Purpose   string    `json:"purpose" gorm:"column:purpose"`
+	Attendees int       `json:"attendees" gorm:"column:attendees"`
+	StartTime time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime   time.Time `json:"end_time" gorm:"column:end_time"`
+	RRule     string    `json:"rrule" gorm:"column:rrule"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+
+	Room     Room      `json:"room,omitempty" gorm:"foreignKey:RoomID;references:ID"`
+	Bookings []Booking `json:"bookings,omitempty" gorm:"foreignKey:SeriesID;references:ID"`
+}
+
+type CreateBookingSeriesInput struct {
+	UserEmail string    `json:"user_email" binding:"required"`
+	UserName  string    `json:"user_name" binding:"required"`
+	Purpose   string    `json:"purpose" binding:"required"`
+	Attendees int       `json:"attendees" binding:"required"`
+	RoomID    string    `json:"room_id" binding:"required"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+	// RRule mengikuti format RFC 5545, contoh: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"
+	RRule string `json:"rrule" binding:"required"`
+	// SkipConflicts membuat kemunculan yang bentrok dilewati, bukan menggagalkan seluruh seri
+	SkipConflicts bool   `json:"skip_conflicts"`
+	Locale        string `json:"locale" binding:"omitempty,oneof=id en"`
+	// UserID diisi dari token bila seri dibuat oleh requester yang login
+	UserID *uuid.UUID `json:"-"`
+}
+
+func (BookingSeries) TableName() string {
+	return "booking_series"
+}
+
+func (s *BookingSeries) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookingStatus string
+
+const (
+	BookingPending   BookingStatus = "pending"
+	BookingApproved  BookingStatus = "approved"
+	BookingRejected  BookingStatus = "rejected"
+	BookingCancelled BookingStatus = "cancelled"
+	BookingCheckedIn BookingStatus = "checked_in"
+	BookingCompleted BookingStatus = "completed"
+	BookingNoShow    BookingStatus = "no_show"
+	BookingExpired   BookingStatus = "expired"
+)
+
+// bookingTransitions mendefinisikan perpindahan status yang diizinkan.
+// Status yang tidak punya tujuan adalah status akhir. approved -> pending
+// dipakai saat pemesan mengubah jadwal sehingga perlu disetujui ulang.
+var bookingTransitions = map[BookingStatus][]BookingStatus{
+	BookingPending:   {BookingApproved, BookingRejected, BookingCancelled, BookingExpired},
+	BookingApproved:  {BookingPending, BookingCheckedIn, BookingCancelled, BookingCompleted, BookingNoShow},
+	BookingCheckedIn: {BookingCompleted},
+	BookingRejected:  {},
+	BookingCancelled: {},
+	BookingCompleted: {},
+	BookingNoShow:    {},
+	BookingExpired:   {},
+}
+
+// ActiveBookingStatuses adalah status yang masih menempati slot ruangan.
+var ActiveBookingStatuses = []BookingStatus{BookingPending, BookingApproved, BookingCheckedIn, BookingCompleted}
+
+func (s BookingStatus) IsValid() bool {
+	_, ok := bookingTransitions[s]
+	return ok
+}
+
+// OccupiesSlot menandakan booking dengan status ini masih menempati slot ruangan.
+func (s BookingStatus) OccupiesSlot() bool {
+	for _, active := range ActiveBookingStatuses {
+		if s == active {
+			return true
+		}
+	}
+	return false
+}
+
+func (s BookingStatus) CanTransitionTo(to BookingStatus) bool {
+	for _, allowed := range bookingTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// BookingStatusTransition mencatat setiap perubahan status booking.
+type BookingStatusTransition struct {
+	ID         uuid.UUID     `json:"id" gorm:"type:char(36);primaryKey"`
+	BookingID  uuid.UUID     `json:"booking_id" gorm:"type:char(36);column:booking_id;index"`
+	FromStatus BookingStatus `json:"from_status" gorm:"column:from_status"`
+	ToStatus   BookingStatus `json:"to_status" gorm:"column:to_status"`
+	ActorID    *uuid.UUID    `json:"actor_id,omitempty" gorm:"type:char(36);column:actor_id"`
+	ActorRole  string        `json:"actor_role" gorm:"column:actor_role"`
+	Reason     string        `json:"reason" gorm:"column:reason"`
+	CreatedAt  time.Time     `json:"created_at" gorm:"column:created_at"`
+}
+
+func (BookingStatusTransition) TableName() string {
+	return "booking_status_transitions"
+}
+
+func (t *BookingStatusTransition) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	return
+}
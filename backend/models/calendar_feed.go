@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CalendarFeedRoom      = "room"
+	CalendarFeedRequester = "requester"
+)
+
+// CalendarFeed adalah langganan .ics untuk satu ruangan atau satu pemesan.
+// Token tidak dapat ditebak dan menjadi satu-satunya kredensial feed. Feed
+// pemesan yang dibuat requester sendiri memakai UserID; feed pemesan buatan
+// admin memakai UserEmail.
+type CalendarFeed struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	Token     string     `json:"token" gorm:"column:token;size:64;uniqueIndex"`
+	Kind      string     `json:"kind" gorm:"column:kind"`
+	RoomID    *uuid.UUID `json:"room_id,omitempty" gorm:"type:char(36);column:room_id"`
+	UserEmail string     `json:"user_email,omitempty" gorm:"column:user_email"`
+	UserID    *uuid.UUID `json:"user_id,omitempty" gorm:"type:char(36);column:user_id;index"`
+	CreatedAt time.Time  `json:"created_at" gorm:"column:created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+}
+
+type CreateCalendarFeedInput struct {
+	Kind      string `json:"kind" binding:"required,oneof=room requester"`
+	RoomID    string `json:"room_id"`
+	UserEmail string `json:"user_email"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "calendar_feeds"
+}
+
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) (err error) {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	if f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailTemplate menimpa template email bawaan untuk satu nama dan bahasa.
+// Bagian yang kosong tetap memakai template dari direktori atau bawaan.
+type EmailTemplate struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primaryKey"`
+	Name      string    `json:"name" gorm:"column:name;size:50;uniqueIndex:idx_email_templates_name_locale"`
+	Locale    string    `json:"locale" gorm:"column:locale;size:5;uniqueIndex:idx_email_templates_name_locale"`
+	Subject   string    `json:"subject" gorm:"column:subject;type:text"`
+	Text      string    `json:"text" gorm:"column:text;type:longtext"`
+	HTML      string    `json:"html" gorm:"column:html;type:longtext"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+type EmailTemplateInput struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Part mengembalikan sumber untuk bagian subject, txt atau html.
+func (t *EmailTemplate) Part(part string) string {
+	switch part {
+	case "subject":
+		return t.Subject
+	case "txt":
+		return t.Text
+	case "html":
+		return t.HTML
+	}
+	return ""
+}
+
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+func (t *EmailTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
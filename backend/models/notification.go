@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "pending"
+	NotificationSent    NotificationStatus = "sent"
+	NotificationDead    NotificationStatus = "dead"
+	// NotificationSkipped dipakai bila isi notifikasi sudah tidak berlaku saat akan dikirim
+	NotificationSkipped NotificationStatus = "skipped"
+)
+
+// Notification adalah email di outbox. Baris ditulis di transaksi yang sama
+// dengan perubahan yang memicunya lalu dikirim oleh worker. Payload berisi
+// pesan lengkap (JSON); untuk notifikasi berisi OTP payload dikosongkan begitu
+// notifikasi tidak akan dikirim lagi.
+type Notification struct {
+	ID            uuid.UUID          `json:"id" gorm:"type:char(36);primaryKey"`
+	Kind          string             `json:"kind" gorm:"column:kind;size:50;index"`
+	BookingID     *uuid.UUID         `json:"booking_id,omitempty" gorm:"type:char(36);column:booking_id;index"`
+	Recipient     string             `json:"recipient" gorm:"column:recipient"`
+	Subject       string             `json:"subject" gorm:"column:subject"`
+	Payload       string             `json:"-" gorm:"column:payload;type:longtext"`
+	Status        NotificationStatus `json:"status" gorm:"column:status;size:20;index:idx_notifications_due,priority:1"`
+	Attempts      int                `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" gorm:"column:next_attempt_at;index:idx_notifications_due,priority:2"`
+	LastError     string             `json:"last_error,omitempty" gorm:"column:last_error;type:text"`
+	SentAt        *time.Time         `json:"sent_at,omitempty" gorm:"column:sent_at"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) (err error) {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.Status == "" {
+		n.Status = NotificationPending
+	}
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// OIDCLoginState menyimpan state, nonce dan PKCE code verifier antara redirect
+// ke identity provider dan callback. Setiap baris hanya bisa dipakai sekali.
+type OIDCLoginState struct {
+	State        string    `json:"-" gorm:"column:state;size:64;primaryKey"`
+	Nonce        string    `json:"-" gorm:"column:nonce;size:64"`
+	CodeVerifier string    `json:"-" gorm:"column:code_verifier;size:128"`
+	RedirectPath string    `json:"-" gorm:"column:redirect_path;size:255"`
+	ExpiresAt    time.Time `json:"-" gorm:"column:expires_at;index"`
+	CreatedAt    time.Time `json:"-" gorm:"column:created_at"`
+}
+
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
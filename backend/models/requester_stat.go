@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// RequesterStat menyimpan rekap kehadiran per pemesan (berdasarkan email).
+type RequesterStat struct {
+	UserEmail    string     `json:"user_email" gorm:"column:user_email;size:191;primaryKey"`
+	UserName     string     `json:"user_name" gorm:"column:user_name"`
+	CheckInCount int        `json:"check_in_count" gorm:"column:check_in_count;default:0"`
+	NoShowCount  int        `json:"no_show_count" gorm:"column:no_show_count;default:0;index"`
+	LastNoShowAt *time.Time `json:"last_no_show_at,omitempty" gorm:"column:last_no_show_at"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (RequesterStat) TableName() string {
+	return "requester_stats"
+}
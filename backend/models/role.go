@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role user. RoleAdmin ("admin") adalah nama lama super admin dan diperlakukan
+// sama dengan RoleSuperAdmin.
+const (
+	RoleAdmin         = "admin"
+	RoleRequester     = "requester"
+	RoleSuperAdmin    = "super_admin"
+	RoleBuildingAdmin = "building_admin"
+	RoleRoomManager   = "room_manager"
+	RoleApprover      = "approver"
+	RoleViewer        = "viewer"
+)
+
+type Permission string
+
+const (
+	// PermBookingsView: melihat riwayat, booking terhapus dan data booking lain
+	PermBookingsView Permission = "bookings:view"
+	// PermBookingsApprove: menyetujui dan menolak booking
+	PermBookingsApprove Permission = "bookings:approve"
+	// PermBookingsManage: mengubah, menghapus, memulihkan, mengakhiri dan memperpanjang booking
+	PermBookingsManage Permission = "bookings:manage"
+	// PermRoomsUpdate: mengubah detail ruangan
+	PermRoomsUpdate Permission = "rooms:update"
+	// PermRoomsManage: membuat, menghapus dan memulihkan ruangan
+	PermRoomsManage Permission = "rooms:manage"
+	// PermReportsView: laporan no-show, status retensi dan audit log
+	PermReportsView Permission = "reports:view"
+	// PermSettingsManage: template email, outbox, retensi, import dan feed kalender
+	PermSettingsManage Permission = "settings:manage"
+	// PermUsersManage: mendaftarkan admin dan mengatur role
+	PermUsersManage Permission = "users:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleSuperAdmin: {
+		PermBookingsView, PermBookingsApprove, PermBookingsManage, PermRoomsUpdate, PermRoomsManage,
+		PermReportsView, PermSettingsManage, PermUsersManage,
+	},
+	RoleBuildingAdmin: {
+		PermBookingsView, PermBookingsApprove, PermBookingsManage, PermRoomsUpdate, PermRoomsManage, PermReportsView,
+	},
+	RoleRoomManager: {PermBookingsView, PermBookingsApprove, PermBookingsManage, PermRoomsUpdate},
+	RoleApprover:    {PermBookingsView, PermBookingsApprove},
+	RoleViewer:      {PermBookingsView, PermReportsView},
+	RoleRequester:   {},
+}
+
+// NormalizeRole mengubah nama role lama ke nama yang berlaku.
+func NormalizeRole(role string) string {
+	if role == RoleAdmin {
+		return RoleSuperAdmin
+	}
+	return role
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[NormalizeRole(role)]
+	return ok
+}
+
+// IsStaffRole menandakan role yang boleh login ke panel admin.
+func IsStaffRole(role string) bool {
+	role = NormalizeRole(role)
+	return IsValidRole(role) && role != RoleRequester
+}
+
+// StaffRoles adalah semua role staf termasuk nama lama "admin".
+var StaffRoles = []string{RoleAdmin, RoleSuperAdmin, RoleBuildingAdmin, RoleRoomManager, RoleApprover, RoleViewer}
+
+// IsRoomScopedRole menandakan role yang hanya berlaku untuk ruangan yang
+// di-assign lewat RoomAssignment.
+func IsRoomScopedRole(role string) bool {
+	return role == RoleRoomManager || role == RoleApprover
+}
+
+func RoleHasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[NormalizeRole(role)] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions mengembalikan daftar permission sebuah role.
+func RolePermissions(role string) []Permission {
+	return rolePermissions[NormalizeRole(role)]
+}
+
+// RoomAssignment membatasi room manager dan approver ke ruangan tertentu.
+type RoomAssignment struct {
+	UserID    uuid.UUID `json:"user_id" gorm:"type:char(36);primaryKey"`
+	RoomID    uuid.UUID `json:"room_id" gorm:"type:char(36);primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (RoomAssignment) TableName() string {
+	return "room_assignments"
+}
@@ -12,6 +12,8 @@ type Room struct {
 	Name        string    `gorm:"unique" json:"name"`
 	Description string    `json:"description"`
 	Capacity    int       `json:"capacity"`
+	// DeletedAt diisi saat ruangan dihapus; ruangan bisa dipulihkan oleh admin
+	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 func (r *Room) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session adalah satu sesi login. Refresh token hanya disimpan sebagai hash
+// SHA-256 dan diganti setiap kali dipakai; hash sebelumnya disimpan untuk
+// mendeteksi refresh token lama yang dipakai ulang (kemungkinan bocor).
+type Session struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:char(36);primaryKey"`
+	UserID            uuid.UUID  `json:"user_id" gorm:"type:char(36);index"`
+	RefreshTokenHash  string     `json:"-" gorm:"column:refresh_token_hash;size:64;uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"column:previous_token_hash;size:64;index"`
+	IP                string     `json:"ip" gorm:"column:ip;size:64"`
+	UserAgent         string     `json:"user_agent" gorm:"column:user_agent;size:255"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastUsedAt        time.Time  `json:"last_used_at" gorm:"column:last_used_at"`
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"column:expires_at;index"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty" gorm:"column:revoked_at"`
+	RevokedReason     string     `json:"revoked_reason,omitempty" gorm:"column:revoked_reason;size:50"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID;references:ID"`
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RevokedToken adalah access token (berdasarkan jti) yang dicabut sebelum
+// kedaluwarsa, mis. saat logout. Baris dihapus setelah token kedaluwarsa.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"column:jti;size:64;primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -12,9 +12,28 @@ type User struct {
 	Email          string     `gorm:"unique" json:"email"`
 	Username       string     `gorm:"unique" json:"username"`
 	Password       string     `json:"-"`
-	Role           string     `json:"role"` // e.g. "admin"
+	Role           string     `json:"role"` // lihat role.go
+	Name           string     `json:"name"`
 	ResetOTP       string     `gorm:"column:reset_otp" json:"-"`
 	ResetOTPExpiry *time.Time `gorm:"column:reset_otp_expiry" json:"-"`
+	Locale         string     `gorm:"column:locale;size:5" json:"locale"` // bahasa email: "id" atau "en"
+	// AuthProvider dan ExternalID mengaitkan user dengan akun di identity
+	// provider (SSO). User lokal memakai AuthProvider "local" tanpa ExternalID.
+	AuthProvider string  `gorm:"column:auth_provider;size:20;default:local;uniqueIndex:idx_users_external" json:"auth_provider"`
+	ExternalID   *string `gorm:"column:external_id;size:255;uniqueIndex:idx_users_external" json:"-"`
+	// DisabledAt diisi bila akun dinonaktifkan, mis. karena sudah tidak ada di
+	// directory LDAP. User nonaktif tidak bisa login.
+	DisabledAt *time.Time `gorm:"column:disabled_at" json:"disabled_at,omitempty"`
+}
+
+const (
+	AuthProviderLocal = "local"
+	AuthProviderOIDC  = "oidc"
+	AuthProviderLDAP  = "ldap"
+)
+
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
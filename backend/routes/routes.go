@@ -3,35 +3,104 @@ package routes
 import (
 	"backendgo/handlers"
 	"backendgo/middleware"
+	"backendgo/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterRoutes(r *gin.Engine, bookingHandler *handlers.BookingHandler) {
+	r.GET("/.well-known/jwks.json", handlers.GetJWKS)
+
 	api := r.Group("/api")
 	{
 		admin := api.Group("/admin")
 		{
-			admin.POST("/register", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.RegisterAdmin)
+			admin.POST("/register", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.RegisterAdmin)
 			admin.POST("/login", handlers.LoginAdmin)
 			admin.POST("/forgot-password", handlers.ForgotPassword)
 			admin.POST("/reset-password", handlers.ResetPassword)
+			admin.POST("/import/bookings", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.ImportBookings)
+			admin.GET("/notifications", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.ListNotifications)
+			admin.POST("/notifications/retry-dead", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.RetryDeadNotifications)
+			admin.POST("/notifications/:id/retry", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.RetryNotification)
+			admin.GET("/email-templates", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.ListEmailTemplates)
+			admin.GET("/email-templates/:name/preview", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.PreviewEmailTemplate)
+			admin.PUT("/email-templates/:name/:locale", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.SaveEmailTemplate)
+			admin.DELETE("/email-templates/:name/:locale", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.DeleteEmailTemplate)
+			admin.GET("/no-shows", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermReportsView), handlers.ListRequesterNoShows)
+			admin.GET("/retention", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermReportsView), handlers.GetRetentionStatus)
+			admin.POST("/retention/run", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.RunRetention)
+			admin.GET("/audit-logs", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermReportsView), handlers.ListAuditLogs)
+			admin.GET("/audit-logs/export", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermReportsView), handlers.ExportAuditLogs)
+			// Semua role staf punya bookings:view
+			admin.GET("/me", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsView), handlers.GetMyAccess)
+			admin.GET("/users", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.ListUsers)
+			admin.PUT("/users/:id/role", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.AssignUserRole)
+			admin.GET("/directory", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.GetDirectoryStatus)
+			admin.POST("/directory/sync", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.RunDirectorySync)
+			admin.GET("/sessions", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.ListSessions)
+			admin.DELETE("/sessions/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.RevokeSession)
+			admin.POST("/users/:id/logout-all", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermUsersManage), handlers.LogoutUser)
+		}
+
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", handlers.RegisterRequester)
+			auth.POST("/login", handlers.LoginRequester)
+			auth.GET("/oidc/login", handlers.OIDCLogin)
+			auth.GET("/oidc/callback", handlers.OIDCCallback)
+			auth.POST("/refresh", handlers.RefreshToken)
+			auth.POST("/logout", middleware.OptionalAuthMiddleware(), handlers.Logout)
+			auth.POST("/logout-all", middleware.AuthMiddleware(), handlers.LogoutAll)
+			auth.GET("/sessions", middleware.AuthMiddleware(), handlers.GetMySessions)
+		}
+
+		me := api.Group("/me", middleware.AuthMiddleware(), middleware.RequesterOnly())
+		{
+			me.GET("/bookings", handlers.GetMyBookings)
+			me.POST("/bookings/:id/cancel", middleware.BookingOwnerOnly(), bookingHandler.CancelMyBooking)
+			me.PUT("/bookings/:id/reschedule", middleware.BookingOwnerOnly(), bookingHandler.RescheduleMyBooking)
+			me.GET("/calendar/feeds", handlers.GetMyCalendarFeeds)
+			me.POST("/calendar/feeds", handlers.CreateMyCalendarFeed)
+			me.DELETE("/calendar/feeds/:id", handlers.RevokeMyCalendarFeed)
 		}
 
 		api.GET("/rooms", handlers.GetRooms)
+		api.GET("/rooms/availability", handlers.GetRoomAvailability)
 		api.GET("/rooms/:id", handlers.GetRoomDetail)
 
-		api.GET("/bookings", bookingHandler.GetBookings)
-		api.GET("/bookings/:id", bookingHandler.GetBookingByID)
+		api.GET("/bookings", middleware.OptionalAuthMiddleware(), bookingHandler.GetBookings)
+		api.GET("/bookings/series/:id", middleware.OptionalAuthMiddleware(), bookingHandler.GetBookingSeries)
+		api.GET("/bookings/suggestions", bookingHandler.GetBookingSuggestions)
+		api.GET("/bookings/:id", middleware.OptionalAuthMiddleware(), bookingHandler.GetBookingByID)
+
+		api.POST("/rooms", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermRoomsManage), handlers.CreateRoom)
+		api.PUT("/rooms/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermRoomsUpdate), handlers.UpdateRoom)
+		api.DELETE("/rooms/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermRoomsManage), bookingHandler.DeleteRoom)
+		api.GET("/rooms/deleted", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermRoomsManage), handlers.GetDeletedRooms)
+		api.POST("/rooms/:id/restore", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermRoomsManage), handlers.RestoreRoom)
+
+		api.PATCH("/bookings/:id/approve", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsApprove), bookingHandler.ApproveBooking)
+		api.PATCH("/bookings/:id/reject", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsApprove), bookingHandler.RejectBooking)
+		api.GET("/bookings/:id/history", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsView), bookingHandler.GetBookingHistory)
+		api.PUT("/bookings/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.UpdateBooking)
+		api.DELETE("/bookings/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.DeleteBooking)
+		api.GET("/bookings/deleted", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsView), bookingHandler.GetDeletedBookings)
+		api.POST("/bookings/:id/restore", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.RestoreBooking)
+		api.POST("/bookings/check-in/:token", bookingHandler.CheckInBooking)
+		api.POST("/bookings/end/:token", bookingHandler.EndMeetingByToken)
+		api.POST("/bookings/extend/:token", bookingHandler.ExtendMeetingByToken)
+		api.POST("/bookings/:id/end", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.EndMeeting)
+		api.POST("/bookings/:id/extend", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.ExtendMeeting)
 
-		api.POST("/rooms", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.CreateRoom)
-		api.PUT("/rooms/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.UpdateRoom)
-		api.DELETE("/rooms/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), handlers.DeleteRoom)
+		api.GET("/calendar/feed/:token", handlers.GetCalendarFeed)
+		api.GET("/calendar/feeds", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.ListCalendarFeeds)
+		api.POST("/calendar/feeds", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.CreateCalendarFeed)
+		api.DELETE("/calendar/feeds/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermSettingsManage), handlers.RevokeCalendarFeed)
 
-		api.PATCH("/bookings/:id/approve", middleware.AuthMiddleware(), middleware.AdminOnly(), bookingHandler.ApproveBooking)
-		api.PATCH("/bookings/:id/reject", middleware.AuthMiddleware(), middleware.AdminOnly(), bookingHandler.RejectBooking)
-		api.PUT("/bookings/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), bookingHandler.UpdateBooking)
-		api.DELETE("/bookings/:id", middleware.AuthMiddleware(), middleware.AdminOnly(), bookingHandler.DeleteBooking)
-		api.DELETE("/bookings/delete/:token", bookingHandler.DeleteBookingByToken)
+		api.PUT("/bookings/series/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.UpdateBookingSeries)
+		api.PATCH("/bookings/series/:id/approve", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsApprove), bookingHandler.ApproveBookingSeries)
+		api.PATCH("/bookings/series/:id/reject", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsApprove), bookingHandler.RejectBookingSeries)
+		api.DELETE("/bookings/series/:id", middleware.AuthMiddleware(), middleware.RequirePermission(models.PermBookingsManage), bookingHandler.DeleteBookingSeries)
 	}
 }
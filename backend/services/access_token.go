@@ -0,0 +1,56 @@
+package services
+
+import (
+	"backendgo/models"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var ErrAccessTokenNoSession = errors.New("access token tidak punya sesi")
+
+// AccessClaims adalah isi access token. jti (RegisteredClaims.ID) dipakai
+// untuk mencabut satu token, sid untuk mencabut sesinya.
+type AccessClaims struct {
+	UserID    uuid.UUID `json:"id"`
+	Role      string    `json:"role"`
+	SessionID uuid.UUID `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// IssueAccessToken menandatangani access token berumur pendek untuk sesi
+// sessionID dengan key aktif.
+func IssueAccessToken(user *models.User, sessionID uuid.UUID) (string, time.Duration, error) {
+	keys := JWTKeys()
+	ttl := SessionTTLFromEnv().Access
+	now := time.Now()
+	claims := &AccessClaims{
+		UserID:    user.ID,
+		Role:      user.Role,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    keys.Issuer,
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	signed, err := keys.Sign(claims)
+	return signed, ttl, err
+}
+
+// ParseAccessToken memverifikasi tanda tangan, issuer dan expiry access
+// token. Pengecekan revokasi dilakukan terpisah lewat ValidateAccessToken.
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	if _, err := JWTKeys().Parse(tokenString, claims); err != nil {
+		return nil, err
+	}
+	if claims.ID == "" || claims.SessionID == uuid.Nil {
+		return nil, ErrAccessTokenNoSession
+	}
+	return claims, nil
+}
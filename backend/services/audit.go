@@ -0,0 +1,284 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Aksi yang dicatat di audit log, dengan format <entitas>.<aksi>.
+const (
+	AuditBookingCreate     = "booking.create"
+	AuditBookingApprove    = "booking.approve"
+	AuditBookingReject     = "booking.reject"
+	AuditBookingUpdate     = "booking.update"
+	AuditBookingDelete     = "booking.delete"
+	AuditBookingRestore    = "booking.restore"
+	AuditBookingCancel     = "booking.cancel"
+	AuditBookingReschedule = "booking.reschedule"
+	AuditBookingEnd        = "booking.end"
+	AuditBookingExtend     = "booking.extend"
+	AuditBookingCheckIn    = "booking.check_in"
+	AuditBookingImport     = "booking.import"
+	AuditSeriesCreate      = "booking_series.create"
+	AuditSeriesUpdate      = "booking_series.update"
+	AuditSeriesStatus      = "booking_series.status"
+	AuditSeriesDelete      = "booking_series.delete"
+	AuditRoomCreate        = "room.create"
+	AuditRoomUpdate        = "room.update"
+	AuditRoomDelete        = "room.delete"
+	AuditRoomRestore       = "room.restore"
+	AuditAdminRegister     = "auth.register"
+	AuditRequesterRegister = "auth.register_requester"
+	AuditLogin             = "auth.login"
+	AuditLoginFailed       = "auth.login_failed"
+	AuditPasswordForgot    = "auth.password_forgot"
+	AuditPasswordReset     = "auth.password_reset"
+	AuditLogout            = "auth.logout"
+	AuditLogoutAll         = "auth.logout_all"
+	AuditRefreshReuse      = "auth.refresh_reuse"
+	AuditSessionRevoke     = "session.revoke"
+	AuditUserRole          = "user.role"
+	AuditDirectorySync     = "directory.sync"
+	AuditTemplateSave      = "email_template.save"
+	AuditTemplateDelete    = "email_template.delete"
+	AuditFeedCreate        = "calendar_feed.create"
+	AuditFeedRevoke        = "calendar_feed.revoke"
+	AuditRetentionRun      = "retention.run"
+	AuditNotificationRetry = "notification.retry"
+	AuditNotificationDead  = "notification.retry_dead"
+)
+
+const (
+	AuditEntityBooking      = "booking"
+	AuditEntitySeries       = "booking_series"
+	AuditEntityRoom         = "room"
+	AuditEntityUser         = "user"
+	AuditEntitySession      = "session"
+	AuditEntityTemplate     = "email_template"
+	AuditEntityFeed         = "calendar_feed"
+	AuditEntityNotification = "notification"
+	AuditEntityRetention    = "retention"
+)
+
+// auditRedactedFields tidak pernah disimpan di audit log meski ikut ter-serialize.
+var auditRedactedFields = map[string]bool{
+	"password": true, "reset_otp": true, "token": true, "qr_code_token": true,
+}
+
+// AuditEntry adalah satu perubahan yang akan dicatat. Before dan After boleh
+// berupa struct, map atau nil (untuk create/delete).
+type AuditEntry struct {
+	Actor      Actor
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+	IP         string
+	UserAgent  string
+}
+
+// RecordAudit menambah satu baris audit log. Bila Before dan After sama-sama
+// diisi, yang disimpan hanya kolom yang berubah. Relasi (objek bersarang)
+// tidak ikut dicatat; cukup kolom ID-nya.
+func RecordAudit(db *gorm.DB, entry AuditEntry) error {
+	before, err := auditState(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditState(entry.After)
+	if err != nil {
+		return err
+	}
+	if before != nil && after != nil {
+		for key, value := range before {
+			if other, ok := after[key]; ok && reflect.DeepEqual(value, other) {
+				delete(before, key)
+				delete(after, key)
+			}
+		}
+	}
+
+	row := models.AuditLog{
+		ActorID:    entry.Actor.ID,
+		ActorRole:  entry.Actor.Role,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		IP:         entry.IP,
+		UserAgent:  truncate(entry.UserAgent, 255),
+	}
+	if row.Before, err = encodeAuditState(before); err != nil {
+		return err
+	}
+	if row.After, err = encodeAuditState(after); err != nil {
+		return err
+	}
+	if err := db.Create(&row).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan audit log: %w", err)
+	}
+	return nil
+}
+
+// auditState mengubah v menjadi map kolom JSON tanpa relasi dan field rahasia.
+func auditState(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca data audit: %w", err)
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("data audit harus berupa objek: %w", err)
+	}
+	for key, value := range state {
+		if _, nested := value.(map[string]interface{}); nested || auditRedactedFields[key] {
+			delete(state, key)
+		}
+	}
+	return state, nil
+}
+
+func encodeAuditState(state map[string]interface{}) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan data audit: %w", err)
+	}
+	return string(raw), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+type AuditQuery struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+func (q AuditQuery) apply(db *gorm.DB) *gorm.DB {
+	query := db.Model(&models.AuditLog{})
+	if q.ActorID != nil {
+		query = query.Where("actor_id = ?", *q.ActorID)
+	}
+	if q.Action != "" {
+		// "booking" cocok dengan semua aksi booking.*
+		if strings.Contains(q.Action, ".") {
+			query = query.Where("action = ?", q.Action)
+		} else {
+			query = query.Where("action LIKE ?", q.Action+".%")
+		}
+	}
+	if q.EntityType != "" {
+		query = query.Where("entity_type = ?", q.EntityType)
+	}
+	if q.EntityID != "" {
+		query = query.Where("entity_id = ?", q.EntityID)
+	}
+	if q.From != nil {
+		query = query.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		query = query.Where("created_at < ?", *q.To)
+	}
+	return query
+}
+
+// ListAuditLogs mengembalikan audit log sesuai filter, terbaru lebih dulu.
+func ListAuditLogs(q AuditQuery) ([]models.AuditLog, int64, error) {
+	query := q.apply(config.DB)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	var logs []models.AuditLog
+	err := query.Order("id DESC").Limit(q.Limit).Offset(q.Offset).Find(&logs).Error
+	return logs, total, err
+}
+
+// ExportAuditLogsCSV menulis semua audit log yang cocok dengan filter ke w
+// sebagai CSV, dibaca per batch agar tidak dimuat sekaligus ke memori.
+// Limit dan Offset diabaikan.
+func ExportAuditLogsCSV(q AuditQuery, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "created_at", "actor_id", "actor_role", "action", "entity_type", "entity_id", "before", "after", "ip", "user_agent"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	var batch []models.AuditLog
+	result := q.apply(config.DB).Order("id").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, l := range batch {
+			actorID := ""
+			if l.ActorID != nil {
+				actorID = l.ActorID.String()
+			}
+			row := []string{
+				strconv.FormatUint(l.ID, 10),
+				l.CreatedAt.UTC().Format(time.RFC3339),
+				actorID,
+				l.ActorRole,
+				l.Action,
+				l.EntityType,
+				l.EntityID,
+				l.Before,
+				l.After,
+				l.IP,
+				l.UserAgent,
+			}
+			for i := range row {
+				row[i] = csvSafeCell(row[i])
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvSafeCell mencegah formula injection saat CSV dibuka di spreadsheet: sel
+// yang diawali =, +, -, @, tab, atau carriage return diberi awalan '.
+func csvSafeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
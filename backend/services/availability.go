@@ -0,0 +1,216 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// Karakter bitmap free/busy, mengikuti konvensi MergedFreeBusy:
+	// 0 = kosong, 1 = tentative (booking pending), 2 = terisi.
+	FreeBusyFree      = '0'
+	FreeBusyTentative = '1'
+	FreeBusyBusy      = '2'
+
+	maxAvailabilityRange = 31 * 24 * time.Hour
+	defaultSlotSize      = 30 * time.Minute
+)
+
+type AvailabilityQuery struct {
+	Start     time.Time
+	End       time.Time
+	Duration  time.Duration
+	Attendees int
+	RoomIDs   []uuid.UUID
+	// IncludePending membuat booking pending ikut dianggap menempati slot
+	IncludePending bool
+	SlotSize       time.Duration
+}
+
+type TimeWindow struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type BusyWindow struct {
+	StartTime time.Time            `json:"start_time"`
+	EndTime   time.Time            `json:"end_time"`
+	Status    models.BookingStatus `json:"status"`
+}
+
+type RoomAvailability struct {
+	RoomID   uuid.UUID    `json:"room_id"`
+	RoomName string       `json:"room_name"`
+	Capacity int          `json:"capacity"`
+	Free     []TimeWindow `json:"free"`
+	Busy     []BusyWindow `json:"busy"`
+	// FreeBusy berisi satu karakter per slot sepanjang rentang query
+	FreeBusy string `json:"freebusy"`
+}
+
+// BookingBuffer adalah jeda wajib antar booking di ruangan yang sama,
+// diatur lewat BOOKING_BUFFER_MINUTES (default 0).
+func BookingBuffer() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("BOOKING_BUFFER_MINUTES"))
+	if err != nil || minutes < 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func (q *AvailabilityQuery) validate() error {
+	if !q.End.After(q.Start) {
+		return fmt.Errorf("waktu selesai harus setelah waktu mulai")
+	}
+	if q.End.Sub(q.Start) > maxAvailabilityRange {
+		return fmt.Errorf("rentang waktu maksimal 31 hari")
+	}
+	if q.Duration <= 0 {
+		return fmt.Errorf("durasi harus lebih dari 0")
+	}
+	if q.SlotSize == 0 {
+		q.SlotSize = defaultSlotSize
+	}
+	if q.SlotSize < 5*time.Minute {
+		return fmt.Errorf("ukuran slot minimal 5 menit")
+	}
+	return nil
+}
+
+// GetRoomAvailability mengembalikan jendela waktu kosong dan bitmap free/busy
+// untuk setiap ruangan yang kapasitasnya mencukupi.
+func GetRoomAvailability(q AvailabilityQuery) ([]RoomAvailability, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	var rooms []models.Room
+	query := config.DB.Where("capacity >= ?", q.Attendees).Order("name")
+	if len(q.RoomIDs) > 0 {
+		query = query.Where("id IN ?", q.RoomIDs)
+	}
+	if err := query.Find(&rooms).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data ruangan")
+	}
+	if len(rooms) == 0 {
+		return []RoomAvailability{}, nil
+	}
+
+	busyByRoom, err := loadBusyWindows(config.DB, rooms, q.Start, q.End)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data booking")
+	}
+
+	buffer := BookingBuffer()
+	result := make([]RoomAvailability, 0, len(rooms))
+	for _, room := range rooms {
+		busy := busyByRoom[room.ID]
+		var blocking []TimeWindow
+		for _, b := range busy {
+			if b.Status == models.BookingPending && !q.IncludePending {
+				continue
+			}
+			blocking = append(blocking, TimeWindow{StartTime: b.StartTime.Add(-buffer), EndTime: b.EndTime.Add(buffer)})
+		}
+		result = append(result, RoomAvailability{
+			RoomID:   room.ID,
+			RoomName: room.Name,
+			Capacity: room.Capacity,
+			Free:     freeWindows(blocking, q.Start, q.End, q.Duration),
+			Busy:     busy,
+			FreeBusy: freeBusyBitmap(busy, q.Start, q.End, q.SlotSize),
+		})
+	}
+	return result, nil
+}
+
+func loadBusyWindows(db *gorm.DB, rooms []models.Room, start, end time.Time) (map[uuid.UUID][]BusyWindow, error) {
+	roomIDs := make([]uuid.UUID, 0, len(rooms))
+	for _, r := range rooms {
+		roomIDs = append(roomIDs, r.ID)
+	}
+
+	// Buffer diperhitungkan agar booking tepat di luar rentang tetap memotong jendela kosong
+	buffer := BookingBuffer()
+	var bookings []models.Booking
+	err := db.Where("room_id IN ? AND start_time < ? AND end_time > ?", roomIDs, end.Add(buffer), start.Add(-buffer)).
+		Where("status IN ?", models.ActiveBookingStatuses).
+		Order("start_time").
+		Find(&bookings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make(map[uuid.UUID][]BusyWindow)
+	for _, b := range bookings {
+		busy[b.RoomID] = append(busy[b.RoomID], BusyWindow{StartTime: b.StartTime, EndTime: b.EndTime, Status: b.Status})
+	}
+	return busy, nil
+}
+
+// freeWindows mengurangi interval busy dari [start, end) dan hanya
+// mengembalikan celah yang cukup untuk durasi yang diminta.
+func freeWindows(busy []TimeWindow, start, end time.Time, duration time.Duration) []TimeWindow {
+	sort.Slice(busy, func(i, j int) bool { return busy[i].StartTime.Before(busy[j].StartTime) })
+
+	free := []TimeWindow{}
+	cursor := start
+	for _, b := range busy {
+		if !b.EndTime.After(cursor) {
+			continue
+		}
+		if b.StartTime.After(cursor) {
+			gapEnd := b.StartTime
+			if gapEnd.After(end) {
+				gapEnd = end
+			}
+			if gapEnd.Sub(cursor) >= duration {
+				free = append(free, TimeWindow{StartTime: cursor, EndTime: gapEnd})
+			}
+		}
+		cursor = b.EndTime
+		if !cursor.Before(end) {
+			return free
+		}
+	}
+	if end.Sub(cursor) >= duration {
+		free = append(free, TimeWindow{StartTime: cursor, EndTime: end})
+	}
+	return free
+}
+
+// freeBusyBitmap membuat satu karakter per slot. Slot yang bersinggungan
+// dengan booking approved ditandai busy, dengan booking pending ditandai tentative.
+func freeBusyBitmap(busy []BusyWindow, start, end time.Time, slot time.Duration) string {
+	n := int((end.Sub(start) + slot - 1) / slot)
+	bitmap := []byte(strings.Repeat(string(FreeBusyFree), n))
+	for _, b := range busy {
+		mark := byte(FreeBusyBusy)
+		if b.Status == models.BookingPending {
+			mark = FreeBusyTentative
+		}
+		first := int(b.StartTime.Sub(start) / slot)
+		last := int((b.EndTime.Sub(start) + slot - 1) / slot)
+		if first < 0 {
+			first = 0
+		}
+		if last > n {
+			last = n
+		}
+		for i := first; i < last; i++ {
+			if bitmap[i] < mark {
+				bitmap[i] = mark
+			}
+		}
+	}
+	return string(bitmap)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"backendgo/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// clock mengembalikan jam:menit pada satu hari tetap untuk test ketersediaan.
+func clock(hour, minute int) time.Time {
+	return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+}
+
+func window(startHour, startMinute, endHour, endMinute int) TimeWindow {
+	return TimeWindow{StartTime: clock(startHour, startMinute), EndTime: clock(endHour, endMinute)}
+}
+
+func TestFreeWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		busy     []TimeWindow
+		duration time.Duration
+		want     []TimeWindow
+	}{
+		{
+			name:     "no bookings",
+			duration: time.Hour,
+			want:     []TimeWindow{window(8, 0, 17, 0)},
+		},
+		{
+			name:     "booking in the middle",
+			busy:     []TimeWindow{window(10, 0, 11, 0)},
+			duration: time.Hour,
+			want:     []TimeWindow{window(8, 0, 10, 0), window(11, 0, 17, 0)},
+		},
+		{
+			name:     "unsorted and overlapping bookings",
+			busy:     []TimeWindow{window(13, 0, 14, 0), window(9, 0, 11, 0), window(10, 0, 12, 0)},
+			duration: 30 * time.Minute,
+			want:     []TimeWindow{window(8, 0, 9, 0), window(12, 0, 13, 0), window(14, 0, 17, 0)},
+		},
+		{
+			name:     "gap shorter than duration is dropped",
+			busy:     []TimeWindow{window(9, 0, 10, 0), window(10, 30, 16, 0)},
+			duration: time.Hour,
+			want:     []TimeWindow{window(8, 0, 9, 0), window(16, 0, 17, 0)},
+		},
+		{
+			name:     "bookings overhanging both ends",
+			busy:     []TimeWindow{window(7, 0, 9, 0), window(16, 0, 18, 0)},
+			duration: time.Hour,
+			want:     []TimeWindow{window(9, 0, 16, 0)},
+		},
+		{
+			name:     "bookings outside the range",
+			busy:     []TimeWindow{window(6, 0, 7, 0), window(18, 0, 19, 0)},
+			duration: time.Hour,
+			want:     []TimeWindow{window(8, 0, 17, 0)},
+		},
+		{
+			name:     "fully booked",
+			busy:     []TimeWindow{window(8, 0, 17, 0)},
+			duration: 15 * time.Minute,
+			want:     []TimeWindow{},
+		},
+		{
+			name:     "exact fit",
+			busy:     []TimeWindow{window(8, 0, 12, 0), window(13, 0, 17, 0)},
+			duration: time.Hour,
+			want:     []TimeWindow{window(12, 0, 13, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := freeWindows(tt.busy, clock(8, 0), clock(17, 0), tt.duration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("freeWindows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeBusyBitmap(t *testing.T) {
+	busy := func(startHour, startMinute, endHour, endMinute int, status models.BookingStatus) BusyWindow {
+		return BusyWindow{StartTime: clock(startHour, startMinute), EndTime: clock(endHour, endMinute), Status: status}
+	}
+
+	tests := []struct {
+		name string
+		busy []BusyWindow
+		end  time.Time
+		want string
+	}{
+		{name: "empty", end: clock(12, 0), want: "00000000"},
+		{name: "approved booking", busy: []BusyWindow{busy(9, 0, 10, 0, models.BookingApproved)}, end: clock(12, 0), want: "00220000"},
+		{name: "pending booking is tentative", busy: []BusyWindow{busy(9, 0, 10, 0, models.BookingPending)}, end: clock(12, 0), want: "00110000"},
+		{name: "partial slots are marked", busy: []BusyWindow{busy(8, 15, 8, 45, models.BookingApproved)}, end: clock(12, 0), want: "22000000"},
+		{
+			name: "busy wins over tentative",
+			busy: []BusyWindow{busy(9, 0, 10, 30, models.BookingPending), busy(9, 30, 10, 0, models.BookingApproved)},
+			end:  clock(12, 0),
+			want: "00121000",
+		},
+		{
+			name: "tentative does not downgrade busy",
+			busy: []BusyWindow{busy(9, 30, 10, 0, models.BookingApproved), busy(9, 0, 10, 30, models.BookingPending)},
+			end:  clock(12, 0),
+			want: "00121000",
+		},
+		{
+			name: "bookings clipped to range",
+			busy: []BusyWindow{busy(7, 0, 8, 30, models.BookingApproved), busy(11, 30, 13, 0, models.BookingPending)},
+			end:  clock(12, 0),
+			want: "20000001",
+		},
+		{name: "bookings outside range", busy: []BusyWindow{busy(6, 0, 7, 0, models.BookingApproved), busy(13, 0, 14, 0, models.BookingApproved)}, end: clock(12, 0), want: "00000000"},
+		{name: "range not a multiple of slot", end: clock(9, 10), want: "000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := freeBusyBitmap(tt.busy, clock(8, 0), tt.end, 30*time.Minute); got != tt.want {
+				t.Fatalf("freeBusyBitmap() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
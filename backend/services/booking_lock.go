@@ -0,0 +1,46 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// withRoomLock menjalankan fn di dalam satu transaksi yang memegang lock baris
+// ruangan (SELECT ... FOR UPDATE). Setiap perubahan yang menempati slot ruangan
+// harus lewat sini agar pengecekan bentrok dan penyimpanan terjadi secara atomik:
+// request lain untuk ruangan yang sama menunggu sampai transaksi ini selesai.
+func withRoomLock(roomIDs []uuid.UUID, fn func(tx *gorm.DB) error) error {
+	// Lock diambil dengan urutan tetap agar dua transaksi yang memindahkan
+	// booking antar ruangan tidak saling deadlock.
+	ids := make([]uuid.UUID, 0, len(roomIDs))
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range roomIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			var room models.Room
+			// Ruangan yang sudah dihapus tetap dikunci agar booking lamanya bisa diproses
+			err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&room, id).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("ruangan tidak ditemukan")
+			}
+			if err != nil {
+				return fmt.Errorf("gagal mengunci ruangan")
+			}
+		}
+		return fn(tx)
+	})
+}
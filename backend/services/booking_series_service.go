@@ -0,0 +1,373 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrSeriesConflict dikembalikan bila ada kemunculan seri yang gagal validasi
+// dan pemanggil tidak meminta kemunculan tersebut dilewati.
+var ErrSeriesConflict = errors.New("sebagian jadwal seri bentrok atau tidak valid")
+
+// ErrSeriesChanged dikembalikan bila kemunculan seri dipindah ruangan saat
+// sedang diproses; pemanggil cukup mengulang request.
+var ErrSeriesChanged = errors.New("jadwal seri berubah saat diproses, coba lagi")
+
+type OccurrenceConflict struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    string    `json:"reason"`
+	// ConflictingBookingIDs berisi booking yang sudah menempati slot kemunculan ini
+	ConflictingBookingIDs []uuid.UUID `json:"conflicting_booking_ids,omitempty"`
+}
+
+type BookingSeriesResult struct {
+	Series    *models.BookingSeries `json:"series"`
+	Bookings  []models.Booking      `json:"bookings"`
+	Conflicts []OccurrenceConflict  `json:"conflicts"`
+}
+
+type UpdateBookingSeriesInput struct {
+	RoomID    string `json:"room_id"`
+	Purpose   string `json:"purpose"`
+	Attendees int    `json:"attendees"`
+}
+
+// CreateBookingSeriesService membuat seri beserta semua kemunculannya.
+// Notifikasi cukup diantrekan sekali untuk kemunculan pertama.
+func CreateBookingSeriesService(input models.CreateBookingSeriesInput, notifier *EmailService) (*BookingSeriesResult, error) {
+	roomUUID, err := uuid.Parse(input.RoomID)
+	if err != nil {
+		return nil, fmt.Errorf("format ID ruangan tidak valid")
+	}
+	if !input.EndTime.After(input.StartTime) {
+		return nil, fmt.Errorf("waktu selesai harus setelah waktu mulai")
+	}
+	rule, err := ParseRecurrenceRule(input.RRule)
+	if err != nil {
+		return nil, err
+	}
+	rule.anchorUntil(input.StartTime.Location())
+	starts, err := rule.Occurrences(input.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	series := models.BookingSeries{
+		RoomID:    roomUUID,
+		UserName:  input.UserName,
+		UserEmail: input.UserEmail,
+		Purpose:   input.Purpose,
+		Attendees: input.Attendees,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+		RRule:     rule.String(),
+	}
+	result := &BookingSeriesResult{Series: &series, Conflicts: []OccurrenceConflict{}}
+
+	duration := input.EndTime.Sub(input.StartTime)
+	var planned []models.Booking
+	err = withRoomLock([]uuid.UUID{roomUUID}, func(tx *gorm.DB) error {
+		for _, start := range starts {
+			end := start.Add(duration)
+			if conflict := occurrenceConflict(tx, roomUUID, start, end, input.Attendees, nil, planned); conflict != nil {
+				result.Conflicts = append(result.Conflicts, *conflict)
+				continue
+			}
+			booking := newBooking(roomUUID, input.UserName, input.UserEmail, input.Purpose, input.Attendees, start, end)
+			booking.UserID = input.UserID
+			planned = append(planned, booking)
+		}
+
+		if len(result.Conflicts) > 0 && !input.SkipConflicts {
+			return ErrSeriesConflict
+		}
+		if len(planned) == 0 {
+			return fmt.Errorf("tidak ada jadwal seri yang dapat dibuat")
+		}
+
+		if err := tx.Create(&series).Error; err != nil {
+			return fmt.Errorf("gagal membuat seri booking")
+		}
+		for i := range planned {
+			planned[i].SeriesID = &series.ID
+			planned[i].Locale = NormalizeLocale(input.Locale)
+		}
+		if err := tx.Create(&planned).Error; err != nil {
+			return fmt.Errorf("gagal membuat seri booking")
+		}
+		var room models.Room
+		if err := tx.First(&room, roomUUID).Error; err != nil {
+			return fmt.Errorf("ruangan tidak ditemukan")
+		}
+		return notifier.QueueBookingCreated(tx, &planned[0], &room)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Bookings = planned
+	return result, nil
+}
+
+// UpdateBookingSeriesService menerapkan perubahan ke semua kemunculan mendatang
+// yang masih aktif dan belum diubah secara individual. Semua kemunculan
+// divalidasi ulang; bila ada yang bentrok tidak ada perubahan yang disimpan.
+// Kemunculan yang sudah disetujui mendapat undangan kalender baru.
+func UpdateBookingSeriesService(seriesID uuid.UUID, input UpdateBookingSeriesInput, notifier *EmailService) (*BookingSeriesResult, error) {
+	var series models.BookingSeries
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		return nil, fmt.Errorf("seri booking tidak ditemukan")
+	}
+
+	oldRoomID := series.RoomID
+	if input.RoomID != "" {
+		roomUUID, err := uuid.Parse(input.RoomID)
+		if err != nil {
+			return nil, fmt.Errorf("format ID ruangan tidak valid")
+		}
+		series.RoomID = roomUUID
+	}
+	if input.Purpose != "" {
+		series.Purpose = input.Purpose
+	}
+	if input.Attendees != 0 {
+		series.Attendees = input.Attendees
+	}
+
+	result := &BookingSeriesResult{Series: &series, Conflicts: []OccurrenceConflict{}}
+	var occurrences []models.Booking
+	err := withRoomLock([]uuid.UUID{oldRoomID, series.RoomID}, func(tx *gorm.DB) error {
+		var err error
+		occurrences, err = upcomingSeriesOccurrences(tx.Where("status IN ?", models.ActiveBookingStatuses), seriesID)
+		if err != nil {
+			return fmt.Errorf("gagal mengambil jadwal seri")
+		}
+
+		var changed []int
+		for i := range occurrences {
+			b := &occurrences[i]
+			if b.RoomID != series.RoomID {
+				b.TokenVersion++
+			}
+			if b.Status == models.BookingApproved && (b.RoomID != series.RoomID || b.Purpose != series.Purpose) {
+				b.Sequence++
+				changed = append(changed, i)
+			}
+			b.RoomID = series.RoomID
+			b.Purpose = series.Purpose
+			b.Attendees = series.Attendees
+			if conflict := occurrenceConflict(tx, b.RoomID, b.StartTime, b.EndTime, b.Attendees, &b.ID, nil); conflict != nil {
+				result.Conflicts = append(result.Conflicts, *conflict)
+			}
+		}
+		if len(result.Conflicts) > 0 {
+			return ErrSeriesConflict
+		}
+
+		if err := tx.Save(&series).Error; err != nil {
+			return fmt.Errorf("gagal memperbarui seri booking")
+		}
+		for i := range occurrences {
+			if err := tx.Save(&occurrences[i]).Error; err != nil {
+				return fmt.Errorf("gagal memperbarui seri booking")
+			}
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		var room models.Room
+		if err := tx.First(&room, series.RoomID).Error; err != nil {
+			return fmt.Errorf("ruangan tidak ditemukan")
+		}
+		for _, i := range changed {
+			if err := notifier.QueueCalendarUpdate(tx, &occurrences[i], &room, ICalMethodRequest); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.Bookings = occurrences
+	return result, nil
+}
+
+// SetBookingSeriesStatusService mengubah status semua kemunculan mendatang yang
+// masih pending dan belum diubah secara individual. Setiap kemunculan yang
+// disetujui divalidasi ulang di bawah lock ruangannya sendiri, dan pemesan
+// mendapat notifikasi serta undangan kalender untuk setiap kemunculan.
+func SetBookingSeriesStatusService(seriesID uuid.UUID, status models.BookingStatus, actor Actor, reason string, notifier *EmailService) ([]models.Booking, error) {
+	var series models.BookingSeries
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		return nil, fmt.Errorf("seri booking tidak ditemukan")
+	}
+
+	pending := config.DB.Where("status = ?", models.BookingPending)
+	roomIDs, err := seriesOccurrenceRoomIDs(pending, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil jadwal seri")
+	}
+
+	var occurrences []models.Booking
+	err = withRoomLock(roomIDs, func(tx *gorm.DB) error {
+		var err error
+		occurrences, err = upcomingSeriesOccurrences(tx.Where("status = ?", models.BookingPending), seriesID)
+		if err != nil {
+			return fmt.Errorf("gagal mengambil jadwal seri")
+		}
+		if !roomsLocked(occurrences, roomIDs) {
+			return ErrSeriesChanged
+		}
+		for i := range occurrences {
+			b := &occurrences[i]
+			if status == models.BookingApproved {
+				if _, err := validateBookingSlot(tx, b.RoomID, b.StartTime, b.EndTime, b.Attendees, &b.ID); err != nil {
+					return err
+				}
+			}
+			if err := TransitionBooking(tx, b, status, actor, reason); err != nil {
+				return err
+			}
+			var room models.Room
+			if err := tx.Unscoped().First(&room, b.RoomID).Error; err != nil {
+				return fmt.Errorf("ruangan tidak ditemukan")
+			}
+			if err := notifier.QueueBookingStatusUpdate(tx, b, &room, models.BookingPending); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return occurrences, nil
+}
+
+// DeleteBookingSeriesService menghapus kemunculan mendatang dari sebuah seri.
+// Kemunculan yang sudah lewat tetap disimpan sebagai riwayat. Kemunculan yang
+// sudah disetujui dihapus dari kalender pemesan lewat undangan CANCEL.
+func DeleteBookingSeriesService(seriesID uuid.UUID, notifier *EmailService) (int64, error) {
+	var series models.BookingSeries
+	if err := config.DB.First(&series, seriesID).Error; err != nil {
+		return 0, fmt.Errorf("seri booking tidak ditemukan")
+	}
+
+	now := time.Now()
+	upcoming := func(db *gorm.DB) *gorm.DB {
+		return db.Where("series_id = ? AND start_time > ?", seriesID, now)
+	}
+	var roomIDs []uuid.UUID
+	if err := upcoming(config.DB.Model(&models.Booking{})).Distinct().Pluck("room_id", &roomIDs).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil jadwal seri")
+	}
+
+	var deleted int64
+	err := withRoomLock(roomIDs, func(tx *gorm.DB) error {
+		var occurrences []models.Booking
+		if err := upcoming(tx).Find(&occurrences).Error; err != nil {
+			return fmt.Errorf("gagal mengambil jadwal seri")
+		}
+		if !roomsLocked(occurrences, roomIDs) {
+			return ErrSeriesChanged
+		}
+		result := upcoming(tx).Delete(&models.Booking{})
+		if result.Error != nil {
+			return fmt.Errorf("gagal menghapus seri booking")
+		}
+		deleted = result.RowsAffected
+
+		for i := range occurrences {
+			b := &occurrences[i]
+			if b.Status != models.BookingApproved {
+				continue
+			}
+			// Sequence disimpan agar undangan saat booking dipulihkan lebih baru dari CANCEL ini
+			b.Sequence++
+			if err := tx.Model(b).Unscoped().Update("sequence", b.Sequence).Error; err != nil {
+				return fmt.Errorf("gagal menghapus seri booking")
+			}
+			var room models.Room
+			if err := tx.Unscoped().First(&room, b.RoomID).Error; err != nil {
+				return fmt.Errorf("ruangan tidak ditemukan")
+			}
+			if err := notifier.QueueCalendarUpdate(tx, b, &room, ICalMethodCancel); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// seriesOccurrenceRoomIDs mengembalikan ruangan kemunculan mendatang sebuah
+// seri agar semuanya bisa dikunci sebelum kemunculan dibaca ulang.
+func seriesOccurrenceRoomIDs(db *gorm.DB, seriesID uuid.UUID) ([]uuid.UUID, error) {
+	occurrences, err := upcomingSeriesOccurrences(db.Select("room_id"), seriesID)
+	if err != nil {
+		return nil, err
+	}
+	roomIDs := make([]uuid.UUID, 0, len(occurrences))
+	for _, b := range occurrences {
+		roomIDs = append(roomIDs, b.RoomID)
+	}
+	return roomIDs, nil
+}
+
+// roomsLocked memastikan ruangan semua kemunculan termasuk yang sudah dikunci.
+func roomsLocked(occurrences []models.Booking, roomIDs []uuid.UUID) bool {
+	locked := make(map[uuid.UUID]bool, len(roomIDs))
+	for _, id := range roomIDs {
+		locked[id] = true
+	}
+	for _, b := range occurrences {
+		if !locked[b.RoomID] {
+			return false
+		}
+	}
+	return true
+}
+
+func upcomingSeriesOccurrences(db *gorm.DB, seriesID uuid.UUID) ([]models.Booking, error) {
+	var occurrences []models.Booking
+	err := db.Where("series_id = ? AND is_exception = ? AND start_time > ?", seriesID, false, time.Now()).
+		Order("start_time").
+		Find(&occurrences).Error
+	return occurrences, err
+}
+
+// occurrenceConflict memvalidasi satu kemunculan dan mengembalikan detail
+// kegagalannya, atau nil bila kemunculan tersebut valid. planned berisi
+// kemunculan seri yang sama yang belum disimpan.
+func occurrenceConflict(db *gorm.DB, roomID uuid.UUID, start, end time.Time, attendees int, excludeID *uuid.UUID, planned []models.Booking) *OccurrenceConflict {
+	conflict := &OccurrenceConflict{StartTime: start, EndTime: end}
+	for _, p := range planned {
+		if p.StartTime.Before(end) && p.EndTime.After(start) {
+			conflict.Reason = "jadwal bentrok dengan kemunculan lain dalam seri ini"
+			return conflict
+		}
+	}
+	if _, err := validateBookingSlot(db, roomID, start, end, attendees, excludeID); err != nil {
+		conflict.Reason = err.Error()
+		var conflictErr *ConflictError
+		if errors.As(err, &conflictErr) {
+			for _, b := range conflictErr.Conflicts {
+				conflict.ConflictingBookingIDs = append(conflict.ConflictingBookingIDs, b.ID)
+			}
+		}
+		return conflict
+	}
+	return nil
+}
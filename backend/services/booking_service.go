@@ -3,61 +3,300 @@ package services
 import (
 	"backendgo/config"
 	"backendgo/models"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-func CreateBookingService(input models.CreateBookingInput) (*models.Booking, error) {
+var (
+	ErrBookingNotFound = errors.New("booking tidak ditemukan")
+	ErrBookingConflict = errors.New("jadwal booking bentrok dengan jadwal yang sudah ada")
+	ErrOverCapacity    = errors.New("jumlah peserta melebihi kapasitas ruangan")
+	// ErrBookingMoved dikembalikan bila booking dipindah ruangan antara dibaca
+	// dan dikunci, sehingga lock yang dipegang bukan milik ruangannya.
+	ErrBookingMoved = errors.New("ruangan booking berubah saat diproses, coba lagi")
+)
+
+// ConflictError membawa booking yang menempati slot yang diminta agar admin
+// dapat menyelesaikan bentrokan.
+type ConflictError struct {
+	Conflicts []models.Booking
+}
+
+func (e *ConflictError) Error() string {
+	return ErrBookingConflict.Error()
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrBookingConflict
+}
+
+// CreateBookingService membuat booking baru. Notifikasi ke pemesan dan admin
+// diantrekan di transaksi yang sama; notifier boleh nil.
+func CreateBookingService(input models.CreateBookingInput, notifier *EmailService) (*models.Booking, error) {
 	// Parse room ID as UUID
 	roomUUID, err := uuid.Parse(input.RoomID)
 	if err != nil {
 		return nil, fmt.Errorf("format ID ruangan tidak valid")
 	}
 
-	// Validate booking conflicts
-	var count int64
-	config.DB.Model(&models.Booking{}).
-		Where("room_id = ? AND ((start_time < ? AND end_time > ?) OR (start_time < ? AND end_time > ?) OR (start_time >= ? AND end_time <= ?))",
-			roomUUID, input.EndTime, input.EndTime, input.StartTime, input.StartTime, input.StartTime, input.EndTime).
-		Count(&count)
-	if count > 0 {
-		return nil, fmt.Errorf("jadwal booking bentrok dengan jadwal yang sudah ada")
+	var booking models.Booking
+	err = withRoomLock([]uuid.UUID{roomUUID}, func(tx *gorm.DB) error {
+		// Validate booking conflicts and room capacity
+		room, err := validateBookingSlot(tx, roomUUID, input.StartTime, input.EndTime, input.Attendees, nil)
+		if err != nil {
+			return err
+		}
+
+		booking = newBooking(roomUUID, input.UserName, input.UserEmail, input.Purpose, input.Attendees, input.StartTime, input.EndTime)
+		booking.Locale = NormalizeLocale(input.Locale)
+		booking.UserID = input.UserID
+		if err := tx.Create(&booking).Error; err != nil {
+			return fmt.Errorf("gagal membuat booking")
+		}
+		return notifier.QueueBookingCreated(tx, &booking, room)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &booking, nil
+}
 
-	// Validate room capacity
-	var room models.Room
-	if err := config.DB.First(&room, roomUUID).Error; err != nil {
-		return nil, fmt.Errorf("ruangan tidak ditemukan")
+// ApproveBookingService menyetujui booking setelah memastikan slotnya tidak
+// sudah ditempati booking lain. Status lama dikembalikan untuk notifikasi.
+func ApproveBookingService(bookingID uuid.UUID, actor Actor, reason string, notifier *EmailService) (*models.Booking, models.BookingStatus, error) {
+	booking, err := findBooking(config.DB, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	oldStatus := booking.Status
+	lockedRoomID := booking.RoomID
+	err = withRoomLock([]uuid.UUID{lockedRoomID}, func(tx *gorm.DB) error {
+		// Baca ulang di dalam lock agar status dan jadwal yang dipakai adalah yang terbaru
+		if err := tx.First(booking, bookingID).Error; err != nil {
+			return ErrBookingNotFound
+		}
+		if booking.RoomID != lockedRoomID {
+			return ErrBookingMoved
+		}
+		oldStatus = booking.Status
+
+		room, err := validateBookingSlot(tx, booking.RoomID, booking.StartTime, booking.EndTime, booking.Attendees, &booking.ID)
+		if err != nil {
+			return err
+		}
+		if err := TransitionBooking(tx, booking, models.BookingApproved, actor, reason); err != nil {
+			return err
+		}
+		return notifier.QueueBookingStatusUpdate(tx, booking, room, oldStatus)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return booking, oldStatus, nil
+}
+
+// RejectBookingService menolak booking. Booking dibaca ulang di dalam
+// transaksi yang memegang lock ruangannya agar status yang divalidasi adalah
+// yang terbaru. Status lama dikembalikan untuk audit.
+func RejectBookingService(bookingID uuid.UUID, actor Actor, reason string, notifier *EmailService) (*models.Booking, models.BookingStatus, error) {
+	var oldStatus models.BookingStatus
+	booking, err := withLockedBooking(bookingID, func(tx *gorm.DB, booking *models.Booking) error {
+		oldStatus = booking.Status
+		if err := TransitionBooking(tx, booking, models.BookingRejected, actor, reason); err != nil {
+			return err
+		}
+		var room models.Room
+		if err := tx.Unscoped().First(&room, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("ruangan tidak ditemukan")
+		}
+		return notifier.QueueBookingStatusUpdate(tx, booking, &room, oldStatus)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return booking, oldStatus, nil
+}
+
+// UpdateBookingService menerapkan perubahan booking. Perubahan ruangan atau
+// waktu dicek ulang terhadap booking lain di bawah lock ruangan lama dan baru.
+// Booking yang sudah approved mendapat undangan kalender baru lewat notifier.
+func UpdateBookingService(bookingID uuid.UUID, input models.UpdateBookingInput, actor Actor, notifier *EmailService) (*models.Booking, error) {
+	return updateBooking(bookingID, input, actor, notifier, nil)
+}
+
+// bookingUpdateGuard dijalankan di dalam lock setelah booking dibaca ulang dan
+// sebelum perubahan diterapkan. Guard boleh menolak perubahan atau
+// menyesuaikan input, mis. mengembalikan status ke pending.
+type bookingUpdateGuard func(booking *models.Booking, input *models.UpdateBookingInput) error
+
+func updateBooking(bookingID uuid.UUID, input models.UpdateBookingInput, actor Actor, notifier *EmailService, guard bookingUpdateGuard) (*models.Booking, error) {
+	booking, err := findBooking(config.DB, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	lockedRoomID := booking.RoomID
+	roomID := booking.RoomID
+	if input.RoomID != "" && input.RoomID != "null" {
+		roomID, err = uuid.Parse(input.RoomID)
+		if err != nil {
+			return nil, fmt.Errorf("format ID ruangan tidak valid")
+		}
+	}
+
+	err = withRoomLock([]uuid.UUID{lockedRoomID, roomID}, func(tx *gorm.DB) error {
+		if err := tx.First(booking, bookingID).Error; err != nil {
+			return ErrBookingNotFound
+		}
+		// Booking yang dipindah sejak dibaca bisa berada di ruangan yang tidak dikunci
+		if booking.RoomID != lockedRoomID {
+			return ErrBookingMoved
+		}
+		if guard != nil {
+			if err := guard(booking, &input); err != nil {
+				return err
+			}
+		}
+		oldStatus := booking.Status
+
+		// QR lama tidak boleh dipakai lagi setelah booking dipindah
+		if roomID != booking.RoomID ||
+			(!input.StartTime.IsZero() && !input.StartTime.Equal(booking.StartTime)) ||
+			(!input.EndTime.IsZero() && !input.EndTime.Equal(booking.EndTime)) {
+			booking.TokenVersion++
+		}
+		booking.RoomID = roomID
+		if !input.StartTime.IsZero() {
+			booking.StartTime = input.StartTime
+		}
+		if !input.EndTime.IsZero() {
+			booking.EndTime = input.EndTime
+		}
+		if input.Purpose != "" {
+			booking.Purpose = input.Purpose
+		}
+		if input.Attendees != 0 {
+			booking.Attendees = input.Attendees
+		}
+		// Perubahan pada satu kemunculan seri tidak lagi ikut perubahan seri
+		if booking.SeriesID != nil {
+			booking.IsException = true
+		}
+
+		// Booking yang tidak (lagi) menempati slot tidak perlu divalidasi ulang
+		target := booking.Status
+		if input.Status != "" {
+			target = input.Status
+		}
+		if target.OccupiesSlot() {
+			if _, err := validateBookingSlot(tx, booking.RoomID, booking.StartTime, booking.EndTime, booking.Attendees, &booking.ID); err != nil {
+				return err
+			}
+		}
+
+		booking.Sequence++
+		if err := tx.Save(booking).Error; err != nil {
+			return fmt.Errorf("gagal memperbarui booking")
+		}
+		// Status hanya boleh berubah lewat state machine
+		if input.Status != "" && input.Status != booking.Status {
+			if err := TransitionBooking(tx, booking, input.Status, actor, input.Reason); err != nil {
+				return err
+			}
+		}
+		// Perubahan status diberitahukan lewat email status (beserta REQUEST atau
+		// CANCEL); booking approved yang hanya diubah mendapat undangan baru
+		statusChanged := booking.Status != oldStatus
+		if notifier == nil || (!statusChanged && booking.Status != models.BookingApproved) {
+			return nil
+		}
+		var room models.Room
+		if err := tx.Unscoped().First(&room, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("ruangan tidak ditemukan")
+		}
+		if statusChanged {
+			return notifier.QueueBookingStatusUpdate(tx, booking, &room, oldStatus)
+		}
+		return notifier.QueueCalendarUpdate(tx, booking, &room, ICalMethodRequest)
+	})
+	if err != nil {
+		return nil, err
 	}
-	if input.Attendees > room.Capacity {
-		return nil, fmt.Errorf("jumlah peserta melebihi kapasitas ruangan")
+	return booking, nil
+}
+
+func findBooking(db *gorm.DB, bookingID uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	if err := db.First(&booking, bookingID).Error; err != nil {
+		return nil, ErrBookingNotFound
 	}
+	return &booking, nil
+}
 
+func newBooking(roomID uuid.UUID, userName, userEmail, purpose string, attendees int, start, end time.Time) models.Booking {
 	// Generate token for QR code
 	token := uuid.New().String()
 	createdAt := time.Now()
-	status := "pending"
-
-	booking := models.Booking{
-		RoomID:      roomUUID,
-		UserName:    input.UserName,
-		UserEmail:   input.UserEmail,
-		Purpose:     input.Purpose,
-		Attendees:   input.Attendees,
-		StartTime:   input.StartTime,
-		EndTime:     input.EndTime,
+	status := models.BookingPending
+
+	return models.Booking{
+		RoomID:      roomID,
+		UserName:    userName,
+		UserEmail:   userEmail,
+		Purpose:     purpose,
+		Attendees:   attendees,
+		StartTime:   start,
+		EndTime:     end,
 		Status:      status,
 		QRCodeToken: token,
 		CreatedAt:   createdAt,
+		// Pemesan booking baru sudah diberi tahu aturan check-in lewat email
+		CheckInRequired: true,
 	}
+}
 
-	if err := config.DB.Create(&booking).Error; err != nil {
-		return nil, fmt.Errorf("gagal membuat booking")
+// validateBookingSlot menjalankan pengecekan bentrok jadwal dan kapasitas ruangan.
+// excludeID dipakai saat memvalidasi booking yang sudah ada agar tidak bentrok dengan dirinya sendiri.
+// Pemanggil yang akan menyimpan booking harus memanggilnya di dalam withRoomLock.
+func validateBookingSlot(db *gorm.DB, roomID uuid.UUID, start, end time.Time, attendees int, excludeID *uuid.UUID) (*models.Room, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("waktu selesai harus setelah waktu mulai")
 	}
 
-	// TODO: Implement email notification to user and admin in handler if needed
+	conflicts, err := findConflictingBookings(db, roomID, start, end, excludeID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memeriksa jadwal booking")
+	}
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
 
-	return &booking, nil
+	var room models.Room
+	if err := db.First(&room, roomID).Error; err != nil {
+		return nil, fmt.Errorf("ruangan tidak ditemukan")
+	}
+	if attendees > room.Capacity {
+		return nil, ErrOverCapacity
+	}
+	return &room, nil
+}
+
+func findConflictingBookings(db *gorm.DB, roomID uuid.UUID, start, end time.Time, excludeID *uuid.UUID) ([]models.Booking, error) {
+	var conflicts []models.Booking
+	buffer := BookingBuffer()
+	query := db.Model(&models.Booking{}).Preload("Room").
+		Where("room_id = ? AND start_time < ? AND end_time > ?", roomID, end.Add(buffer), start.Add(-buffer)).
+		Where("status IN ?", models.ActiveBookingStatuses)
+	if excludeID != nil {
+		query = query.Where("id <> ?", *excludeID)
+	}
+	if err := query.Order("start_time").Find(&conflicts).Error; err != nil {
+		return nil, err
+	}
+	return conflicts, nil
 }
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB menghubungkan ke database MySQL yang ditunjuk TEST_DATABASE_DSN.
+// Lock baris (SELECT ... FOR UPDATE) hanya bisa dibuktikan di database sungguhan,
+// jadi test dilewati bila DSN tidak diset.
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set, skipping MySQL integration test")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Room{}, &models.Booking{}, &models.BookingSeries{}, &models.BookingStatusTransition{}); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	config.DB = db
+	// Approve menerbitkan token QR; secret wajib ada sejak token tidak lagi
+	// punya key cadangan
+	if os.Getenv("QR_TOKEN_SECRET") == "" {
+		os.Setenv("QR_TOKEN_SECRET", "test-qr-secret-"+uuid.NewString())
+	}
+}
+
+func createTestRoom(t *testing.T, capacity int) models.Room {
+	t.Helper()
+	room := models.Room{Name: "test-room-" + uuid.NewString(), Capacity: capacity}
+	if err := config.DB.Create(&room).Error; err != nil {
+		t.Fatalf("failed to create room: %v", err)
+	}
+	t.Cleanup(func() {
+		config.DB.Where("room_id = ?", room.ID).Delete(&models.Booking{})
+		config.DB.Delete(&room)
+	})
+	return room
+}
+
+func TestCreateBookingServiceConcurrentSameSlot(t *testing.T) {
+	setupTestDB(t)
+	room := createTestRoom(t, 10)
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Minute)
+	input := models.CreateBookingInput{
+		UserEmail: "race@example.com",
+		UserName:  "Race",
+		Purpose:   "Concurrency test",
+		Attendees: 2,
+		RoomID:    room.ID.String(),
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	}
+
+	const workers = 30
+	var (
+		wg         sync.WaitGroup
+		successes  int32
+		unexpected = make(chan error, workers)
+		ready      = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ready
+			_, err := CreateBookingService(input, nil)
+			switch {
+			case err == nil:
+				atomic.AddInt32(&successes, 1)
+			case !errors.Is(err, ErrBookingConflict):
+				unexpected <- err
+			}
+		}()
+	}
+	close(ready)
+	wg.Wait()
+	close(unexpected)
+
+	for err := range unexpected {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if successes != 1 {
+		t.Fatalf("expected exactly 1 successful booking, got %d", successes)
+	}
+
+	var stored int64
+	config.DB.Model(&models.Booking{}).Where("room_id = ?", room.ID).Count(&stored)
+	if stored != 1 {
+		t.Fatalf("expected 1 stored booking, got %d", stored)
+	}
+}
+
+func TestApproveBookingServiceRejectsOccupiedSlot(t *testing.T) {
+	setupTestDB(t)
+	room := createTestRoom(t, 10)
+
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+	approved := newBooking(room.ID, "A", "a@example.com", "Approved", 1, start, start.Add(time.Hour))
+	approved.Status = models.BookingApproved
+	// Booking lama yang tumpang tindih, misalnya dari data sebelum pengecekan bentrok diperbaiki
+	pending := newBooking(room.ID, "B", "b@example.com", "Pending", 1, start.Add(30*time.Minute), start.Add(90*time.Minute))
+	for _, b := range []*models.Booking{&approved, &pending} {
+		if err := config.DB.Create(b).Error; err != nil {
+			t.Fatalf("failed to seed bookings: %v", err)
+		}
+	}
+
+	if _, _, err := ApproveBookingService(pending.ID, SystemActor, "", nil); !errors.Is(err, ErrBookingConflict) {
+		t.Fatalf("expected ErrBookingConflict, got %v", err)
+	}
+}
+
+// queuedNotification mengembalikan pesan terakhir di outbox untuk booking.
+func queuedNotification(t *testing.T, bookingID uuid.UUID) (models.Notification, EmailMessage) {
+	t.Helper()
+	var notification models.Notification
+	if err := config.DB.Where("booking_id = ?", bookingID).Order("created_at DESC").First(&notification).Error; err != nil {
+		t.Fatalf("tidak ada notifikasi untuk booking: %v", err)
+	}
+	var message EmailMessage
+	if err := json.Unmarshal([]byte(notification.Payload), &message); err != nil {
+		t.Fatalf("payload notifikasi tidak valid: %v", err)
+	}
+	return notification, message
+}
+
+func TestUpdateBookingServiceCancelQueuesCalendarCancel(t *testing.T) {
+	setupTestDB(t)
+	if err := config.DB.AutoMigrate(&models.Notification{}, &models.EmailTemplate{}, &models.User{}); err != nil {
+		t.Fatalf("failed to migrate notifications: %v", err)
+	}
+	room := createTestRoom(t, 10)
+	notifier := NewEmailServiceWithTransport(noopTransport{}, EmailAddress{Address: "noreply@example.com"})
+
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+	booking := newBooking(room.ID, "A", "a@example.com", "Approved", 1, start, start.Add(time.Hour))
+	booking.Status = models.BookingApproved
+	if err := config.DB.Create(&booking).Error; err != nil {
+		t.Fatalf("failed to seed booking: %v", err)
+	}
+	t.Cleanup(func() { config.DB.Where("booking_id = ?", booking.ID).Delete(&models.Notification{}) })
+
+	input := models.UpdateBookingInput{Status: models.BookingCancelled, Reason: "dibatalkan admin"}
+	updated, err := UpdateBookingService(booking.ID, input, SystemActor, notifier)
+	if err != nil {
+		t.Fatalf("UpdateBookingService: %v", err)
+	}
+	if updated.Status != models.BookingCancelled || updated.Sequence <= booking.Sequence {
+		t.Fatalf("status = %s, sequence = %d, want cancelled and > %d", updated.Status, updated.Sequence, booking.Sequence)
+	}
+
+	notification, message := queuedNotification(t, booking.ID)
+	if notification.Kind != NotificationBookingStatus {
+		t.Fatalf("kind = %q, want %q", notification.Kind, NotificationBookingStatus)
+	}
+	var cancel *EmailAttachment
+	for i := range message.Attachments {
+		if strings.HasSuffix(message.Attachments[i].ContentType, "method="+ICalMethodCancel) {
+			cancel = &message.Attachments[i]
+		}
+	}
+	if cancel == nil {
+		t.Fatalf("email status tidak melampirkan CANCEL: %+v", message.Attachments)
+	}
+	if ics := string(cancel.Content); !strings.Contains(ics, "STATUS:CANCELLED") || !strings.Contains(ics, "SEQUENCE:"+strconv.Itoa(updated.Sequence)) {
+		t.Fatalf("undangan CANCEL tidak memakai SEQUENCE terbaru:\n%s", ics)
+	}
+}
+
+// TestDeleteBookingServiceDeletedRoom memastikan booking di ruangan yang sudah
+// dihapus tetap bisa dihapus dan pemesannya mendapat CANCEL.
+func TestDeleteBookingServiceDeletedRoom(t *testing.T) {
+	setupTestDB(t)
+	if err := config.DB.AutoMigrate(&models.Notification{}, &models.EmailTemplate{}, &models.User{}); err != nil {
+		t.Fatalf("failed to migrate notifications: %v", err)
+	}
+	room := createTestRoom(t, 10)
+	notifier := NewEmailServiceWithTransport(noopTransport{}, EmailAddress{Address: "noreply@example.com"})
+
+	start := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+	booking := newBooking(room.ID, "A", "a@example.com", "Approved", 1, start, start.Add(time.Hour))
+	booking.Status = models.BookingApproved
+	if err := config.DB.Create(&booking).Error; err != nil {
+		t.Fatalf("failed to seed booking: %v", err)
+	}
+	t.Cleanup(func() { config.DB.Where("booking_id = ?", booking.ID).Delete(&models.Notification{}) })
+	if err := config.DB.Delete(&room).Error; err != nil {
+		t.Fatalf("failed to delete room: %v", err)
+	}
+
+	deleted, err := DeleteBookingService(booking.ID, notifier)
+	if err != nil {
+		t.Fatalf("DeleteBookingService: %v", err)
+	}
+	var stored models.Booking
+	if err := config.DB.Unscoped().First(&stored, booking.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !stored.DeletedAt.Valid || stored.Sequence != deleted.Sequence || stored.Sequence <= booking.Sequence {
+		t.Fatalf("deleted_at valid = %t, sequence = %d, want deleted with sequence > %d", stored.DeletedAt.Valid, stored.Sequence, booking.Sequence)
+	}
+	notification, message := queuedNotification(t, booking.ID)
+	if notification.Kind != NotificationCalendarUpdate || len(message.Attachments) == 0 ||
+		!strings.HasSuffix(message.Attachments[0].ContentType, "method="+ICalMethodCancel) {
+		t.Fatalf("notifikasi = %s %+v, want CANCEL", notification.Kind, message.Attachments)
+	}
+}
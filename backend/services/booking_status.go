@@ -0,0 +1,88 @@
+package services
+
+import (
+	"backendgo/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTransition dikembalikan bila perubahan status tidak diizinkan.
+var ErrInvalidTransition = errors.New("perubahan status booking tidak diizinkan")
+
+// ErrBookingStatusChanged dikembalikan bila status booking di database sudah
+// berbeda dari yang dibaca pemanggil, mis. disetujui dan ditolak bersamaan.
+var ErrBookingStatusChanged = errors.New("status booking sudah diubah oleh proses lain, muat ulang data booking")
+
+type TransitionError struct {
+	From models.BookingStatus
+	To   models.BookingStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("status booking tidak dapat diubah dari %s ke %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Actor adalah pihak yang melakukan perubahan. ID kosong untuk proses sistem.
+type Actor struct {
+	ID   *uuid.UUID
+	Role string
+}
+
+var SystemActor = Actor{Role: "system"}
+
+// TransitionBooking memvalidasi dan menyimpan perubahan status booking beserta
+// catatan transisinya. Harus dipanggil di dalam transaksi yang sama dengan
+// perubahan lain pada booking bila ada. UPDATE hanya berhasil bila status di
+// database masih sama dengan booking.Status (compare-and-set).
+func TransitionBooking(tx *gorm.DB, booking *models.Booking, to models.BookingStatus, actor Actor, reason string) error {
+	if !to.IsValid() {
+		return fmt.Errorf("status %s tidak dikenal", to)
+	}
+	from := booking.Status
+	if !from.CanTransitionTo(to) {
+		return &TransitionError{From: from, To: to}
+	}
+
+	sequence := booking.Sequence
+	// Pembatalan dikirim ke kalender sebagai revisi baru dari event yang sama
+	if to == models.BookingRejected || to == models.BookingCancelled {
+		sequence++
+	}
+	result := tx.Model(&models.Booking{}).Where("id = ? AND status = ?", booking.ID, from).
+		Updates(map[string]interface{}{"status": to, "sequence": sequence})
+	if result.Error != nil {
+		return fmt.Errorf("gagal memperbarui status booking")
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookingStatusChanged
+	}
+	booking.Status = to
+	booking.Sequence = sequence
+
+	transition := models.BookingStatusTransition{
+		BookingID:  booking.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actor.ID,
+		ActorRole:  actor.Role,
+		Reason:     reason,
+	}
+	if err := tx.Create(&transition).Error; err != nil {
+		return fmt.Errorf("gagal mencatat perubahan status booking")
+	}
+	return nil
+}
+
+// GetBookingStatusHistory mengembalikan riwayat transisi status sebuah booking.
+func GetBookingStatusHistory(db *gorm.DB, bookingID uuid.UUID) ([]models.BookingStatusTransition, error) {
+	var history []models.BookingStatusTransition
+	err := db.Where("booking_id = ?", bookingID).Order("created_at").Find(&history).Error
+	return history, err
+}
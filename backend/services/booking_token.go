@@ -0,0 +1,167 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Aksi yang boleh dilakukan dengan token QR booking. Token untuk satu aksi
+// tidak berlaku untuk aksi lain.
+const (
+	TokenActionCheckIn = "check_in"
+	TokenActionEnd     = "end"
+	TokenActionExtend  = "extend"
+
+	bookingTokenAudience = "booking-qr"
+	// Meeting yang molor masih bisa diakhiri lewat QR sampai batas ini
+	endTokenGrace = 24 * time.Hour
+)
+
+var ErrInvalidBookingToken = errors.New("token booking tidak valid atau sudah kedaluwarsa")
+
+type BookingTokenClaims struct {
+	Action  string `json:"act"`
+	Version int    `json:"ver"`
+	jwt.RegisteredClaims
+}
+
+var ErrBookingTokenSecretMissing = errors.New("QR_TOKEN_SECRET belum diset")
+
+var (
+	bookingTokenKeyOnce sync.Once
+	bookingTokenKey     []byte
+)
+
+// LoadBookingTokenSecret membaca QR_TOKEN_SECRET, lalu JWT_SECRET. Tanpa
+// keduanya token QR tidak bisa ditandatangani dengan aman sehingga error.
+func LoadBookingTokenSecret() ([]byte, error) {
+	secret := config.Secret("QR_TOKEN_SECRET")
+	if secret == "" {
+		secret = config.Secret("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, ErrBookingTokenSecretMissing
+	}
+	return []byte(secret), nil
+}
+
+// bookingTokenSecret mengembalikan secret token QR; dibaca sekali.
+// Konfigurasi yang kosong menghentikan proses karena token QR bisa dipalsukan.
+func bookingTokenSecret() []byte {
+	bookingTokenKeyOnce.Do(func() {
+		key, err := LoadBookingTokenSecret()
+		if err != nil {
+			log.Fatalf("Konfigurasi token QR tidak valid: %v", err)
+		}
+		bookingTokenKey = key
+	})
+	return bookingTokenKey
+}
+
+// InitBookingTokens memastikan secret token QR tersedia saat start.
+func InitBookingTokens() {
+	bookingTokenSecret()
+}
+
+// bookingTokenExpiry menentukan masa berlaku token per aksi.
+func bookingTokenExpiry(booking *models.Booking, action string) time.Time {
+	if action == TokenActionEnd || action == TokenActionExtend {
+		return booking.EndTime.Add(endTokenGrace)
+	}
+	return booking.EndTime
+}
+
+// NewBookingToken menandatangani token untuk satu aksi pada booking. Token
+// otomatis tidak berlaku lagi bila TokenVersion booking dinaikkan.
+func NewBookingToken(booking *models.Booking, action string) (string, error) {
+	claims := BookingTokenClaims{
+		Action:  action,
+		Version: booking.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   booking.ID.String(),
+			Audience:  jwt.ClaimStrings{bookingTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(bookingTokenExpiry(booking, action)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(bookingTokenSecret())
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat token booking")
+	}
+	return token, nil
+}
+
+// BookingActionURL membuat URL publik untuk aksi QR booking.
+func BookingActionURL(booking *models.Booking, action string) (string, error) {
+	token, err := NewBookingToken(booking, action)
+	if err != nil {
+		return "", err
+	}
+	switch action {
+	case TokenActionCheckIn:
+		return Links().CheckInURL(token), nil
+	case TokenActionEnd:
+		return Links().EndMeetingURL(token), nil
+	case TokenActionExtend:
+		return Links().ExtendMeetingURL(token), nil
+	}
+	return "", fmt.Errorf("aksi token %s tidak dikenal", action)
+}
+
+// VerifyBookingToken memeriksa tanda tangan, masa berlaku, aksi dan versi
+// token, lalu mengembalikan booking yang dituju. Salah satu dari actions
+// harus cocok.
+func VerifyBookingToken(db *gorm.DB, tokenString string, actions ...string) (*models.Booking, string, error) {
+	claims, bookingID, err := parseBookingToken(tokenString, actions...)
+	if err != nil {
+		return nil, "", err
+	}
+	booking, err := findBooking(db, bookingID)
+	if err != nil {
+		return nil, "", err
+	}
+	if booking.TokenVersion != claims.Version {
+		return nil, "", ErrInvalidBookingToken
+	}
+	return booking, claims.Action, nil
+}
+
+// parseBookingToken memeriksa bagian token yang tidak butuh database:
+// tanda tangan, algoritma, audience, masa berlaku dan aksi.
+func parseBookingToken(tokenString string, actions ...string) (*BookingTokenClaims, uuid.UUID, error) {
+	var claims BookingTokenClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return bookingTokenSecret(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(bookingTokenAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidBookingToken
+	}
+
+	allowed := false
+	for _, action := range actions {
+		if claims.Action == action {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, uuid.Nil, ErrInvalidBookingToken
+	}
+
+	bookingID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidBookingToken
+	}
+	return &claims, bookingID, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrCalendarFeedNotFound = errors.New("feed kalender tidak ditemukan")
+
+// Rentang booking yang dimuat dalam feed langganan
+const (
+	calendarFeedPast   = 30 * 24 * time.Hour
+	calendarFeedFuture = 365 * 24 * time.Hour
+)
+
+func CreateCalendarFeed(input models.CreateCalendarFeedInput) (*models.CalendarFeed, error) {
+	token, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat token feed")
+	}
+
+	feed := models.CalendarFeed{Token: token, Kind: input.Kind}
+	switch input.Kind {
+	case models.CalendarFeedRoom:
+		roomUUID, err := uuid.Parse(input.RoomID)
+		if err != nil {
+			return nil, fmt.Errorf("format ID ruangan tidak valid")
+		}
+		var room models.Room
+		if err := config.DB.First(&room, roomUUID).Error; err != nil {
+			return nil, fmt.Errorf("ruangan tidak ditemukan")
+		}
+		feed.RoomID = &roomUUID
+	case models.CalendarFeedRequester:
+		email := strings.TrimSpace(strings.ToLower(input.UserEmail))
+		if email == "" {
+			return nil, fmt.Errorf("email pemesan wajib diisi")
+		}
+		feed.UserEmail = email
+	default:
+		return nil, fmt.Errorf("jenis feed tidak dikenal")
+	}
+
+	if err := config.DB.Create(&feed).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat feed kalender")
+	}
+	return &feed, nil
+}
+
+// CreateUserCalendarFeed membuat feed milik requester yang berisi booking
+// dengan user_id-nya.
+func CreateUserCalendarFeed(userID uuid.UUID) (*models.CalendarFeed, error) {
+	token, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat token feed")
+	}
+	feed := models.CalendarFeed{Token: token, Kind: models.CalendarFeedRequester, UserID: &userID}
+	if err := config.DB.Create(&feed).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat feed kalender")
+	}
+	return &feed, nil
+}
+
+// ListUserCalendarFeeds mengembalikan feed aktif milik requester.
+func ListUserCalendarFeeds(userID uuid.UUID) ([]models.CalendarFeed, error) {
+	var feeds []models.CalendarFeed
+	err := config.DB.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&feeds).Error
+	return feeds, err
+}
+
+func RevokeCalendarFeed(feedID uuid.UUID) error {
+	return revokeCalendarFeed(config.DB.Where("id = ?", feedID))
+}
+
+// RevokeUserCalendarFeed mencabut feed hanya bila feed itu milik requester.
+func RevokeUserCalendarFeed(feedID, userID uuid.UUID) error {
+	return revokeCalendarFeed(config.DB.Where("id = ? AND user_id = ?", feedID, userID))
+}
+
+func revokeCalendarFeed(query *gorm.DB) error {
+	now := time.Now()
+	result := query.Model(&models.CalendarFeed{}).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", &now)
+	if result.Error != nil {
+		return fmt.Errorf("gagal mencabut feed kalender")
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// RenderCalendarFeed membuat isi .ics untuk token feed yang masih aktif.
+func RenderCalendarFeed(token string) ([]byte, error) {
+	var feed models.CalendarFeed
+	if err := config.DB.Where("token = ? AND revoked_at IS NULL", token).First(&feed).Error; err != nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	now := time.Now()
+	query := config.DB.Preload("Room", IncludeDeleted).
+		Where("end_time > ? AND start_time < ?", now.Add(-calendarFeedPast), now.Add(calendarFeedFuture)).
+		Where("status IN ?", models.ActiveBookingStatuses).
+		Order("start_time")
+
+	name := "Meeting Room Bookings"
+	switch feed.Kind {
+	case models.CalendarFeedRoom:
+		var room models.Room
+		if err := config.DB.First(&room, feed.RoomID).Error; err != nil {
+			return nil, ErrCalendarFeedNotFound
+		}
+		name = room.Name
+		query = query.Where("room_id = ?", room.ID)
+	case models.CalendarFeedRequester:
+		if feed.UserID != nil {
+			// Feed milik requester berhenti bekerja bila akunnya dinonaktifkan
+			var user models.User
+			if err := config.DB.Where("disabled_at IS NULL").First(&user, feed.UserID).Error; err != nil {
+				return nil, ErrCalendarFeedNotFound
+			}
+			name = "My Bookings"
+			query = query.Where("user_id = ?", user.ID)
+			break
+		}
+		name = "Bookings " + feed.UserEmail
+		query = query.Where("LOWER(user_email) = ?", feed.UserEmail)
+	}
+
+	var bookings []models.Booking
+	if err := query.Find(&bookings).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil data booking")
+	}
+	return BuildCalendarFeed(name, bookings), nil
+}
+
+// CalendarFeedURL adalah URL langganan yang diberikan ke pengguna.
+func CalendarFeedURL(feed *models.CalendarFeed) string {
+	return Links().CalendarFeedURL(feed.Token)
+}
+
+// newSecureToken membuat token acak 256-bit dalam bentuk hex.
+func newSecureToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultCheckInOpensMinutes = 15
+	defaultNoShowMinutes       = 15
+)
+
+var (
+	ErrCheckInTooEarly = errors.New("check-in belum dibuka untuk booking ini")
+	ErrCheckInClosed   = errors.New("batas waktu check-in sudah lewat")
+)
+
+// CheckInWindow adalah rentang waktu check-in di sekitar StartTime. Booking
+// approved yang belum check-in setelah NoShowAfter dianggap tidak hadir.
+type CheckInWindow struct {
+	OpensBefore time.Duration
+	NoShowAfter time.Duration
+}
+
+// CheckInWindowFromEnv membaca CHECK_IN_OPENS_MINUTES dan NO_SHOW_MINUTES
+// (default masing-masing 15 menit).
+func CheckInWindowFromEnv() CheckInWindow {
+	return CheckInWindow{
+		OpensBefore: envMinutes("CHECK_IN_OPENS_MINUTES", defaultCheckInOpensMinutes),
+		NoShowAfter: envMinutes("NO_SHOW_MINUTES", defaultNoShowMinutes),
+	}
+}
+
+func envMinutes(key string, fallback int) time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || minutes < 0 {
+		minutes = fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Check memastikan now berada di jendela check-in booking.
+func (w CheckInWindow) Check(booking *models.Booking, now time.Time) error {
+	if now.Before(booking.StartTime.Add(-w.OpensBefore)) {
+		return ErrCheckInTooEarly
+	}
+	deadline := booking.StartTime.Add(w.NoShowAfter)
+	if booking.EndTime.Before(deadline) {
+		deadline = booking.EndTime
+	}
+	if now.After(deadline) {
+		return ErrCheckInClosed
+	}
+	return nil
+}
+
+// CheckInBookingService menandai booking checked_in berdasarkan token QR
+// check-in. Memindai ulang booking yang sudah check-in tidak dianggap error.
+func CheckInBookingService(token string, now time.Time) (*models.Booking, error) {
+	var booking models.Booking
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		verified, _, err := VerifyBookingToken(tx, token, TokenActionCheckIn)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, verified.ID).Error; err != nil {
+			return ErrBookingNotFound
+		}
+		if booking.Status == models.BookingCheckedIn {
+			return nil
+		}
+		if booking.Status == models.BookingApproved {
+			if err := CheckInWindowFromEnv().Check(&booking, now); err != nil {
+				return err
+			}
+		}
+		if err := TransitionBooking(tx, &booking, models.BookingCheckedIn, Actor{Role: "check_in"}, "check-in lewat QR"); err != nil {
+			return err
+		}
+		if err := tx.Model(&booking).Update("checked_in_at", now).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan waktu check-in")
+		}
+		booking.CheckedInAt = &now
+		return recordRequesterStat(tx, &booking, "check_in_count", now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// MarkNoShows mengubah booking approved yang tidak check-in sampai batas
+// NoShowAfter menjadi no_show sehingga slotnya bisa dipesan lagi. Booking
+// lama yang dibuat sebelum ada check-in (CheckInRequired false) dilewati dan
+// diselesaikan oleh retensi seperti biasa.
+func MarkNoShows(now time.Time) (int, error) {
+	window := CheckInWindowFromEnv()
+	var bookings []models.Booking
+	err := config.DB.Select("id", "room_id").
+		Where("status = ? AND check_in_required = ? AND start_time <= ?", models.BookingApproved, true, now.Add(-window.NoShowAfter)).
+		Find(&bookings).Error
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil booking yang belum check-in: %w", err)
+	}
+
+	marked := 0
+	reason := fmt.Sprintf("tidak check-in dalam %d menit setelah mulai", int(window.NoShowAfter/time.Minute))
+	for _, b := range bookings {
+		err := withRoomLock([]uuid.UUID{b.RoomID}, func(tx *gorm.DB) error {
+			booking, err := findBooking(tx, b.ID)
+			if err != nil {
+				return err
+			}
+			// Bisa saja sudah check-in atau dibatalkan sejak query di atas
+			if booking.Status != models.BookingApproved {
+				return nil
+			}
+			if err := TransitionBooking(tx, booking, models.BookingNoShow, SystemActor, reason); err != nil {
+				return err
+			}
+			marked++
+			return recordRequesterStat(tx, booking, "no_show_count", now)
+		})
+		if err != nil {
+			log.Printf("Failed to mark booking %s as no-show: %v", b.ID, err)
+		}
+	}
+	return marked, nil
+}
+
+// recordRequesterStat menaikkan counter kehadiran pemesan booking.
+func recordRequesterStat(tx *gorm.DB, booking *models.Booking, counter string, now time.Time) error {
+	stat := models.RequesterStat{
+		UserEmail: strings.ToLower(strings.TrimSpace(booking.UserEmail)),
+		UserName:  booking.UserName,
+		UpdatedAt: now,
+	}
+	updates := map[string]interface{}{
+		counter:      gorm.Expr(counter + " + 1"),
+		"user_name":  booking.UserName,
+		"updated_at": now,
+	}
+	if counter == "no_show_count" {
+		stat.NoShowCount = 1
+		stat.LastNoShowAt = &now
+		updates["last_no_show_at"] = now
+	} else {
+		stat.CheckInCount = 1
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_email"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&stat).Error
+	if err != nil {
+		return fmt.Errorf("gagal memperbarui statistik pemesan")
+	}
+	return nil
+}
+
+// NoShowWorker menjalankan MarkNoShows secara berkala.
+type NoShowWorker struct {
+	Interval time.Duration
+}
+
+func NewNoShowWorker() *NoShowWorker {
+	return &NoShowWorker{Interval: time.Minute}
+}
+
+func (w *NoShowWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if n, err := MarkNoShows(time.Now()); err != nil {
+			log.Println("No-show worker error:", err)
+		} else if n > 0 {
+			log.Printf("Marked %d booking(s) as no-show", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type RequesterStatQuery struct {
+	Email      string
+	MinNoShows int
+	Limit      int
+	Offset     int
+}
+
+// ListRequesterStats mengembalikan rekap pemesan, yang paling sering tidak
+// hadir lebih dulu.
+func ListRequesterStats(q RequesterStatQuery) ([]models.RequesterStat, int64, error) {
+	query := config.DB.Model(&models.RequesterStat{})
+	if q.Email != "" {
+		query = query.Where("user_email = ?", strings.ToLower(strings.TrimSpace(q.Email)))
+	}
+	if q.MinNoShows > 0 {
+		query = query.Where("no_show_count >= ?", q.MinNoShows)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	var stats []models.RequesterStat
+	err := query.Order("no_show_count DESC, user_email").Limit(q.Limit).Offset(q.Offset).Find(&stats).Error
+	return stats, total, err
+}
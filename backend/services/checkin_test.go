@@ -0,0 +1,70 @@
+package services
+
+import (
+	"backendgo/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckInWindowCheck(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	window := CheckInWindow{OpensBefore: 15 * time.Minute, NoShowAfter: 15 * time.Minute}
+	hourLong := &models.Booking{StartTime: start, EndTime: start.Add(time.Hour)}
+	// Meeting lebih pendek dari NoShowAfter: check-in ditutup saat meeting selesai
+	short := &models.Booking{StartTime: start, EndTime: start.Add(10 * time.Minute)}
+
+	tests := []struct {
+		name    string
+		window  CheckInWindow
+		booking *models.Booking
+		now     time.Time
+		want    error
+	}{
+		{name: "too early", window: window, booking: hourLong, now: start.Add(-16 * time.Minute), want: ErrCheckInTooEarly},
+		{name: "opens exactly", window: window, booking: hourLong, now: start.Add(-15 * time.Minute)},
+		{name: "at start", window: window, booking: hourLong, now: start},
+		{name: "at no-show deadline", window: window, booking: hourLong, now: start.Add(15 * time.Minute)},
+		{name: "after no-show deadline", window: window, booking: hourLong, now: start.Add(15*time.Minute + time.Second), want: ErrCheckInClosed},
+		{name: "short meeting before end", window: window, booking: short, now: start.Add(10 * time.Minute)},
+		{name: "short meeting after end", window: window, booking: short, now: start.Add(11 * time.Minute), want: ErrCheckInClosed},
+		{
+			name:    "zero window before start",
+			window:  CheckInWindow{},
+			booking: hourLong,
+			now:     start.Add(-time.Second),
+			want:    ErrCheckInTooEarly,
+		},
+		{name: "zero window at start", window: CheckInWindow{}, booking: hourLong, now: start},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.window.Check(tt.booking, tt.now); !errors.Is(err, tt.want) {
+				t.Fatalf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckInWindowFromEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		opens  string
+		noShow string
+		want   CheckInWindow
+	}{
+		{name: "defaults", want: CheckInWindow{OpensBefore: 15 * time.Minute, NoShowAfter: 15 * time.Minute}},
+		{name: "custom", opens: "30", noShow: "5", want: CheckInWindow{OpensBefore: 30 * time.Minute, NoShowAfter: 5 * time.Minute}},
+		{name: "zero is allowed", opens: "0", noShow: "0", want: CheckInWindow{}},
+		{name: "invalid falls back", opens: "-5", noShow: "sepuluh", want: CheckInWindow{OpensBefore: 15 * time.Minute, NoShowAfter: 15 * time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CHECK_IN_OPENS_MINUTES", tt.opens)
+			t.Setenv("NO_SHOW_MINUTES", tt.noShow)
+			if got := CheckInWindowFromEnv(); got != tt.want {
+				t.Fatalf("CheckInWindowFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
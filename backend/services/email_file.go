@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultOutboxDir = "tmp/outbox"
+
+// FileTransport menulis setiap email sebagai file .eml ke sebuah direktori,
+// untuk development dan integration test tanpa server email sungguhan.
+type FileTransport struct {
+	Dir string
+}
+
+func NewFileTransport(dir string) (*FileTransport, error) {
+	if dir == "" {
+		dir = defaultOutboxDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori outbox %s: %w", dir, err)
+	}
+	return &FileTransport{Dir: dir}, nil
+}
+
+func (t *FileTransport) Name() string { return EmailDriverFile }
+
+func (t *FileTransport) Send(msg *EmailMessage) error {
+	body, err := buildMIMEMessage(msg)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email: %w", err)
+	}
+
+	// Ditulis ke file sementara lalu di-rename agar pembaca tidak pernah
+	// melihat file yang setengah jadi (seperti tmp/ -> new/ pada maildir)
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), randomHex(4))
+	tmp := filepath.Join(t.Dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.Dir, name))
+}
+
+// Messages mengembalikan path file .eml di outbox, urut dari yang paling lama.
+func (t *FileTransport) Messages() ([]string, error) {
+	entries, err := os.ReadDir(t.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".eml") && !strings.HasPrefix(e.Name(), ".") {
+			paths = append(paths, filepath.Join(t.Dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	transport, err := NewFileTransport(dir)
+	if err != nil {
+		t.Fatalf("NewFileTransport: %v", err)
+	}
+
+	subjects := []string{"pertama", "kedua", "ketiga"}
+	for _, subject := range subjects {
+		err := transport.Send(&EmailMessage{
+			From:      EmailAddress{Address: "noreply@example.com"},
+			To:        []EmailAddress{{Address: "budi@example.com"}},
+			Subject:   subject,
+			PlainText: "halo",
+		})
+		if err != nil {
+			t.Fatalf("Send(%s): %v", subject, err)
+		}
+	}
+	// File sementara yang tertinggal tidak boleh dianggap pesan
+	if err := os.WriteFile(filepath.Join(dir, ".partial.eml.tmp"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := transport.Messages()
+	if err != nil {
+		t.Fatalf("Messages: %v", err)
+	}
+	if len(paths) != len(subjects) {
+		t.Fatalf("Messages() = %d file, want %d", len(paths), len(subjects))
+	}
+	for i, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := parseTestMessage(t, raw)
+		if got := msg.Header.Get("Subject"); got != subjects[i] {
+			t.Fatalf("pesan ke-%d Subject = %q, want %q (urutan harus dari yang paling lama)", i, got, subjects[i])
+		}
+		if !strings.HasSuffix(path, ".eml") {
+			t.Fatalf("path %s bukan .eml", path)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+type SendGridTransport struct {
+	client *sendgrid.Client
+}
+
+func NewSendGridTransport(apiKey string) (*SendGridTransport, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("SENDGRID_API_KEY wajib diisi untuk driver sendgrid")
+	}
+	return &SendGridTransport{client: sendgrid.NewSendClient(apiKey)}, nil
+}
+
+func (t *SendGridTransport) Name() string { return EmailDriverSendGrid }
+
+func (t *SendGridTransport) Send(msg *EmailMessage) error {
+	message := mail.NewV3Mail()
+	message.SetFrom(mail.NewEmail(msg.From.Name, msg.From.Address))
+	message.Subject = msg.Subject
+
+	p := mail.NewPersonalization()
+	for _, to := range msg.To {
+		p.AddTos(mail.NewEmail(to.Name, to.Address))
+	}
+	message.AddPersonalizations(p)
+
+	message.AddContent(mail.NewContent("text/plain", msg.PlainText))
+	if msg.HTML != "" {
+		message.AddContent(mail.NewContent("text/html", msg.HTML))
+	}
+
+	for _, a := range msg.Attachments {
+		attachment := mail.NewAttachment()
+		attachment.SetContent(base64.StdEncoding.EncodeToString(a.Content))
+		attachment.SetType(a.ContentType)
+		attachment.SetFilename(a.Filename)
+		if a.ContentID != "" {
+			attachment.SetDisposition("inline")
+			attachment.SetContentID(a.ContentID)
+		} else {
+			attachment.SetDisposition("attachment")
+		}
+		message.AddAttachment(attachment)
+	}
+
+	response, err := t.client.Send(message)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid returned status %d: %s", response.StatusCode, response.Body)
+	}
+	return nil
+}
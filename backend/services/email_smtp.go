@@ -0,0 +1,134 @@
+package services
+
+import (
+	"backendgo/config"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SMTPSecurityStartTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+	SMTPSecurityNone     = "none"
+
+	smtpDialTimeout = 15 * time.Second
+)
+
+type SMTPTransport struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Security adalah starttls (default), tls (implicit TLS, biasanya port 465) atau none
+	Security string
+}
+
+// NewSMTPTransportFromEnv membaca EMAIL_HOST, EMAIL_PORT, EMAIL_USER,
+// EMAIL_PASSWORD dan EMAIL_SECURITY.
+func NewSMTPTransportFromEnv() (*SMTPTransport, error) {
+	t := &SMTPTransport{
+		Host:     os.Getenv("EMAIL_HOST"),
+		Port:     587,
+		Username: os.Getenv("EMAIL_USER"),
+		Password: config.Secret("EMAIL_PASSWORD"),
+		Security: strings.ToLower(os.Getenv("EMAIL_SECURITY")),
+	}
+	if t.Host == "" {
+		return nil, fmt.Errorf("EMAIL_HOST wajib diisi untuk driver smtp")
+	}
+	if v := os.Getenv("EMAIL_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("EMAIL_PORT tidak valid")
+		}
+		t.Port = port
+	}
+	switch t.Security {
+	case "":
+		t.Security = SMTPSecurityStartTLS
+		if t.Port == 465 {
+			t.Security = SMTPSecurityTLS
+		}
+	case SMTPSecurityStartTLS, SMTPSecurityTLS, SMTPSecurityNone:
+	default:
+		return nil, fmt.Errorf("EMAIL_SECURITY %q tidak dikenal", t.Security)
+	}
+	return t, nil
+}
+
+func (t *SMTPTransport) Name() string { return EmailDriverSMTP }
+
+func (t *SMTPTransport) Send(msg *EmailMessage) error {
+	body, err := buildMIMEMessage(msg)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email: %w", err)
+	}
+
+	client, err := t.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if t.Security == SMTPSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server SMTP %s tidak mendukung STARTTLS", t.Host)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: t.Host}); err != nil {
+			return fmt.Errorf("STARTTLS gagal: %w", err)
+		}
+	}
+	if t.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.Username, t.Password, t.Host)); err != nil {
+			return fmt.Errorf("autentikasi SMTP gagal: %w", err)
+		}
+	}
+
+	if err := client.Mail(msg.From.Address); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to.Address); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (t *SMTPTransport) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+
+	var conn net.Conn
+	var err error
+	if t.Security == SMTPSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: t.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal terhubung ke server SMTP %s: %w", addr, err)
+	}
+	client, err := smtp.NewClient(conn, t.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewSMTPTransportFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		port         string
+		security     string
+		wantPort     int
+		wantSecurity string
+		wantErr      bool
+	}{
+		{name: "default starttls on 587", wantPort: 587, wantSecurity: SMTPSecurityStartTLS},
+		{name: "implicit tls on 465", port: "465", wantPort: 465, wantSecurity: SMTPSecurityTLS},
+		{name: "explicit none", port: "25", security: "NONE", wantPort: 25, wantSecurity: SMTPSecurityNone},
+		{name: "explicit starttls on 465", port: "465", security: "starttls", wantPort: 465, wantSecurity: SMTPSecurityStartTLS},
+		{name: "unknown security", security: "ssl", wantErr: true},
+		{name: "invalid port", port: "smtp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearSecretEnv(t, "EMAIL_PASSWORD")
+			t.Setenv("EMAIL_HOST", "smtp.example.com")
+			t.Setenv("EMAIL_USER", "")
+			t.Setenv("EMAIL_PORT", tt.port)
+			t.Setenv("EMAIL_SECURITY", tt.security)
+
+			transport, err := NewSMTPTransportFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("err = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewSMTPTransportFromEnv: %v", err)
+			}
+			if transport.Port != tt.wantPort || transport.Security != tt.wantSecurity {
+				t.Fatalf("port/security = %d/%s, want %d/%s", transport.Port, transport.Security, tt.wantPort, tt.wantSecurity)
+			}
+		})
+	}
+}
+
+// fakeSMTPServer adalah server SMTP minimal tanpa TLS untuk satu koneksi yang
+// mencatat perintah dan isi DATA yang diterimanya.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener, done: make(chan struct{})}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+		case "EHLO":
+			// STARTTLS sengaja tidak ditawarkan
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) transport(security, username string) *SMTPTransport {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return &SMTPTransport{Host: host, Port: p, Username: username, Password: "rahasia", Security: security}
+}
+
+func (s *fakeSMTPServer) hasCommand(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.commands {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSMTPTransportSend(t *testing.T) {
+	msg := &EmailMessage{
+		From:      EmailAddress{Address: "noreply@example.com"},
+		To:        []EmailAddress{{Address: "budi@example.com"}, {Address: "ani@example.com"}},
+		Subject:   "Undangan rapat",
+		PlainText: "halo",
+	}
+	plainAuth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00rahasia"))
+
+	tests := []struct {
+		name      string
+		security  string
+		username  string
+		wantErr   string
+		wantCmds  []string
+		forbidden []string
+	}{
+		{
+			name:      "none without auth",
+			security:  SMTPSecurityNone,
+			wantCmds:  []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<budi@example.com>", "RCPT TO:<ani@example.com>", "DATA", "QUIT"},
+			forbidden: []string{"AUTH", "STARTTLS"},
+		},
+		{
+			name:     "none with auth",
+			security: SMTPSecurityNone,
+			username: "mailer",
+			wantCmds: []string{plainAuth, "MAIL FROM:<noreply@example.com>", "DATA"},
+		},
+		{
+			name:      "starttls required but not offered",
+			security:  SMTPSecurityStartTLS,
+			username:  "mailer",
+			wantErr:   "tidak mendukung STARTTLS",
+			forbidden: []string{"AUTH", "MAIL"},
+		},
+		{
+			name:      "implicit tls against plaintext server",
+			security:  SMTPSecurityTLS,
+			username:  "mailer",
+			wantErr:   "gagal terhubung",
+			forbidden: []string{"EHLO", "AUTH", "MAIL"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startFakeSMTPServer(t)
+			err := server.transport(tt.security, tt.username).Send(msg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			server.listener.Close()
+			<-server.done
+
+			for _, c := range tt.wantCmds {
+				if !server.hasCommand(c) {
+					t.Errorf("perintah %q tidak dikirim; diterima %v", c, server.commands)
+				}
+			}
+			for _, c := range tt.forbidden {
+				if server.hasCommand(c) {
+					t.Errorf("perintah %q tidak boleh dikirim; diterima %v", c, server.commands)
+				}
+			}
+			if tt.wantErr == "" && !strings.Contains(server.data, "Subject: Undangan rapat") {
+				t.Errorf("DATA tidak berisi pesan: %q", server.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+//go:embed templates/email
+var defaultEmailTemplates embed.FS
+
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+
+	emailLayoutTemplate = "layout"
+	otpValidMinutes     = 10
+)
+
+var (
+	SupportedLocales   = []string{LocaleID, LocaleEN}
+	EmailTemplateNames = []string{
+		NotificationBookingCreated,
+		NotificationBookingCreatedAdmin,
+		NotificationBookingStatus,
+		NotificationCalendarUpdate,
+		NotificationPasswordOTP,
+		NotificationBookingReminder,
+	}
+
+	ErrEmailTemplateNotFound = errors.New("template email tidak ditemukan")
+)
+
+var (
+	dayNamesID   = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+	monthNamesID = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+	bookingStatusLabels = map[string]map[models.BookingStatus]string{
+		LocaleID: {
+			models.BookingPending:   "Menunggu",
+			models.BookingApproved:  "Disetujui",
+			models.BookingRejected:  "Ditolak",
+			models.BookingCancelled: "Dibatalkan",
+			models.BookingCheckedIn: "Check-in",
+			models.BookingCompleted: "Selesai",
+			models.BookingNoShow:    "Tidak Hadir",
+			models.BookingExpired:   "Kedaluwarsa",
+		},
+		LocaleEN: {
+			models.BookingPending:   "Pending",
+			models.BookingApproved:  "Approved",
+			models.BookingRejected:  "Rejected",
+			models.BookingCancelled: "Cancelled",
+			models.BookingCheckedIn: "Checked in",
+			models.BookingCompleted: "Completed",
+			models.BookingNoShow:    "No-show",
+			models.BookingExpired:   "Expired",
+		},
+	}
+)
+
+// EmailTemplateData adalah data yang tersedia di semua template email.
+// Nilai tanggal dan status sudah diformat sesuai bahasa penerima.
+type EmailTemplateData struct {
+	Locale         string
+	RecipientName  string
+	Booking        *models.Booking
+	Room           *models.Room
+	Date           string
+	EndTime        string
+	Status         string
+	StatusClass    string
+	OldStatus      string
+	OldStatusClass string
+	HasQRCode      bool
+	Method         string
+	OTP            string
+	OTPMinutes     int
+	// StartsIn adalah sisa waktu sebelum meeting dimulai, mis. "15 menit"
+	StartsIn string
+	// Link publik dari LinkBuilder; kosong bila tidak relevan
+	RoomURL    string
+	AdminURL   string
+	CheckInURL string
+}
+
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// DefaultLocale dibaca dari EMAIL_DEFAULT_LOCALE, default bahasa Indonesia.
+func DefaultLocale() string {
+	if locale := strings.ToLower(os.Getenv("EMAIL_DEFAULT_LOCALE")); isSupportedLocale(locale) {
+		return locale
+	}
+	return LocaleID
+}
+
+// NormalizeLocale menerima kode bahasa atau header Accept-Language
+// ("en-US,en;q=0.9") dan mengembalikan bahasa pertama yang didukung.
+func NormalizeLocale(value string) string {
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		tag = strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if isSupportedLocale(tag) {
+			return tag
+		}
+	}
+	return DefaultLocale()
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmailTemplateName(name string) bool {
+	for _, n := range EmailTemplateNames {
+		if n == name {
+			return true
+		}
+	}
+	return name == emailLayoutTemplate
+}
+
+// newBookingTemplateData menyiapkan data template untuk sebuah booking.
+func newBookingTemplateData(locale, recipientName string, booking *models.Booking, room *models.Room) *EmailTemplateData {
+	locale = NormalizeLocale(locale)
+	return &EmailTemplateData{
+		Locale:        locale,
+		RecipientName: recipientName,
+		Booking:       booking,
+		Room:          room,
+		Date:          formatEmailDate(booking.StartTime, locale),
+		EndTime:       booking.EndTime.Format("15:04"),
+		Status:        bookingStatusLabel(booking.Status, locale),
+		StatusClass:   string(booking.Status),
+		RoomURL:       roomURL(room),
+	}
+}
+
+func roomURL(room *models.Room) string {
+	if room == nil || room.ID == uuid.Nil {
+		return ""
+	}
+	return Links().RoomURL(room.ID.String())
+}
+
+func formatEmailDate(t time.Time, locale string) string {
+	if locale == LocaleID {
+		return fmt.Sprintf("%s, %d %s %d pukul %s", dayNamesID[t.Weekday()], t.Day(), monthNamesID[t.Month()-1], t.Year(), t.Format("15:04"))
+	}
+	return t.Format("Monday, 2 January 2006 at 15:04")
+}
+
+// formatEmailDuration menulis durasi dalam hari, jam dan menit, dibulatkan ke menit.
+func formatEmailDuration(d time.Duration, locale string) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		d = time.Minute
+	}
+	days, hours, minutes := int(d/(24*time.Hour)), int(d%(24*time.Hour)/time.Hour), int(d%time.Hour/time.Minute)
+
+	var parts []string
+	add := func(n int, id, en, enPlural string) {
+		switch {
+		case n == 0:
+		case locale == LocaleID:
+			parts = append(parts, fmt.Sprintf("%d %s", n, id))
+		case n == 1:
+			parts = append(parts, fmt.Sprintf("%d %s", n, en))
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", n, enPlural))
+		}
+	}
+	add(days, "hari", "day", "days")
+	add(hours, "jam", "hour", "hours")
+	add(minutes, "menit", "minute", "minutes")
+	return strings.Join(parts, " ")
+}
+
+func bookingStatusLabel(status models.BookingStatus, locale string) string {
+	if label, ok := bookingStatusLabels[locale][status]; ok {
+		return label
+	}
+	return string(status)
+}
+
+// RenderEmail merender subject, teks dan HTML untuk template name. Sumber
+// template dicari berurutan: tabel email_templates, EMAIL_TEMPLATE_DIR, lalu
+// template bawaan. Template HTML dirender bersama layout; template yang tidak
+// mendefinisikan blok "content" dianggap dokumen lengkap dan menggantikan layout.
+func RenderEmail(name, locale string, data *EmailTemplateData) (*RenderedEmail, error) {
+	return renderEmail(config.DB, name, locale, data)
+}
+
+func renderEmail(db *gorm.DB, name, locale string, data *EmailTemplateData) (*RenderedEmail, error) {
+	if !isEmailTemplateName(name) || name == emailLayoutTemplate {
+		return nil, ErrEmailTemplateNotFound
+	}
+	locale = NormalizeLocale(locale)
+	data.Locale = locale
+
+	sources := loadEmailTemplateSources(db, name, locale)
+	layout := loadEmailTemplateSources(db, emailLayoutTemplate, locale)
+
+	subject, err := renderTextTemplate(name+".subject", sources["subject"], data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderTextTemplate(name+".txt", sources["txt"], data)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := htmltemplate.New(emailLayoutTemplate).Parse(layout["html"])
+	if err != nil {
+		return nil, fmt.Errorf("layout email tidak valid: %w", err)
+	}
+	if _, err := tmpl.Parse(sources["html"]); err != nil {
+		return nil, fmt.Errorf("template %s.html tidak valid: %w", name, err)
+	}
+	var htmlBuf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&htmlBuf, emailLayoutTemplate, data); err != nil {
+		return nil, fmt.Errorf("gagal merender %s.html: %w", name, err)
+	}
+
+	return &RenderedEmail{
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    strings.TrimSpace(text) + "\n",
+		HTML:    htmlBuf.String(),
+	}, nil
+}
+
+func renderTextTemplate(name, source string, data *EmailTemplateData) (string, error) {
+	tmpl, err := texttemplate.New(name).Option("missingkey=zero").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("template %s tidak valid: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("gagal merender %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// loadEmailTemplateSources mengembalikan sumber per bagian (subject, txt,
+// html) dengan urutan prioritas database, direktori, lalu bawaan.
+func loadEmailTemplateSources(db *gorm.DB, name, locale string) map[string]string {
+	sources := make(map[string]string)
+	parts := []string{"subject", "txt", "html"}
+	if name == emailLayoutTemplate {
+		parts = []string{"html"}
+	}
+
+	var override models.EmailTemplate
+	hasOverride := db != nil &&
+		db.Where("name = ? AND locale = ?", name, locale).Limit(1).Find(&override).RowsAffected > 0
+
+	dir := os.Getenv("EMAIL_TEMPLATE_DIR")
+	for _, part := range parts {
+		if hasOverride {
+			if src := override.Part(part); src != "" {
+				sources[part] = src
+				continue
+			}
+		}
+		file := fmt.Sprintf("%s/%s.%s.tmpl", locale, name, part)
+		if dir != "" {
+			if b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file))); err == nil {
+				sources[part] = string(b)
+				continue
+			}
+		}
+		if b, err := defaultEmailTemplates.ReadFile("templates/email/" + file); err == nil {
+			sources[part] = string(b)
+		}
+	}
+	return sources
+}
+
+// SampleEmailTemplateData dipakai untuk preview template di halaman admin.
+func SampleEmailTemplateData(name, locale string) *EmailTemplateData {
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	room := &models.Room{ID: uuid.New(), Name: "Ruang Merapi", Capacity: 10}
+	booking := &models.Booking{
+		ID:        uuid.New(),
+		RoomID:    room.ID,
+		UserName:  "Budi Santoso",
+		UserEmail: "budi@example.com",
+		Purpose:   "Weekly Sync <Tim Produk>",
+		Attendees: 6,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		Status:    models.BookingApproved,
+	}
+
+	data := newBookingTemplateData(locale, booking.UserName, booking, room)
+	switch name {
+	case NotificationBookingCreated, NotificationBookingCreatedAdmin:
+		booking.Status = models.BookingPending
+		data = newBookingTemplateData(locale, booking.UserName, booking, room)
+		data.AdminURL = Links().AdminURL()
+	case NotificationBookingStatus:
+		data.OldStatus = bookingStatusLabel(models.BookingPending, data.Locale)
+		data.OldStatusClass = string(models.BookingPending)
+		data.HasQRCode = true
+		data.CheckInURL, _ = BookingActionURL(booking, TokenActionCheckIn)
+	case NotificationCalendarUpdate:
+		data.Method = ICalMethodRequest
+	case NotificationBookingReminder:
+		data.StartsIn = formatEmailDuration(15*time.Minute, data.Locale)
+	case NotificationPasswordOTP:
+		data = &EmailTemplateData{Locale: data.Locale, RecipientName: "Admin", OTP: "123456", OTPMinutes: otpValidMinutes}
+	}
+	return data
+}
+
+// SaveEmailTemplateOverride menyimpan override setelah memastikan template
+// bisa dirender terhadap contoh booking.
+func SaveEmailTemplateOverride(name, locale string, input models.EmailTemplateInput) (*models.EmailTemplate, error) {
+	if !isEmailTemplateName(name) {
+		return nil, ErrEmailTemplateNotFound
+	}
+	if !isSupportedLocale(locale) {
+		return nil, fmt.Errorf("bahasa %q tidak didukung", locale)
+	}
+
+	var tmpl models.EmailTemplate
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ? AND locale = ?", name, locale).First(&tmpl).Error; err != nil {
+			tmpl = models.EmailTemplate{Name: name, Locale: locale}
+		}
+		tmpl.Subject, tmpl.Text, tmpl.HTML = input.Subject, input.Text, input.HTML
+		if err := tx.Save(&tmpl).Error; err != nil {
+			return fmt.Errorf("gagal menyimpan template email")
+		}
+
+		// Dirender lewat tx agar override yang baru disimpan ikut dipakai
+		names := []string{name}
+		if name == emailLayoutTemplate {
+			names = EmailTemplateNames
+		}
+		for _, n := range names {
+			if _, err := renderEmail(tx, n, locale, SampleEmailTemplateData(n, locale)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+func DeleteEmailTemplateOverride(name, locale string) error {
+	result := config.DB.Where("name = ? AND locale = ?", name, locale).Delete(&models.EmailTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("gagal menghapus template email")
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmailTemplateNotFound
+	}
+	return nil
+}
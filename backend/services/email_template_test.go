@@ -0,0 +1,129 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		defaultLocale string
+		want          string
+	}{
+		{name: "supported code", value: "en", want: LocaleEN},
+		{name: "region is ignored", value: "en-US", want: LocaleEN},
+		{name: "first supported in Accept-Language", value: "fr-FR,fr;q=0.9,en;q=0.8,id;q=0.7", want: LocaleEN},
+		{name: "unsupported falls back to default", value: "fr", want: LocaleID},
+		{name: "empty falls back to configured default", value: "", defaultLocale: "en", want: LocaleEN},
+		{name: "unsupported configured default is ignored", value: "de", defaultLocale: "de", want: LocaleID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EMAIL_DEFAULT_LOCALE", tt.defaultLocale)
+			if got := NormalizeLocale(tt.value); got != tt.want {
+				t.Fatalf("NormalizeLocale(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadEmailTemplateSources memastikan urutan sumber per bagian template:
+// override database, lalu EMAIL_TEMPLATE_DIR, lalu template bawaan.
+func TestLoadEmailTemplateSources(t *testing.T) {
+	const name = NotificationBookingCreated
+	embedded := func(locale, part string) string {
+		b, err := defaultEmailTemplates.ReadFile("templates/email/" + locale + "/" + name + "." + part + ".tmpl")
+		if err != nil {
+			t.Fatalf("template bawaan %s/%s.%s tidak ada: %v", locale, name, part, err)
+		}
+		return string(b)
+	}
+
+	tests := []struct {
+		name     string
+		locale   string
+		dirFiles map[string]string
+		override *models.EmailTemplate
+		// want berisi sumber yang diharapkan per bagian; kosong berarti bawaan
+		want map[string]string
+	}{
+		{
+			name:   "embedded defaults",
+			locale: LocaleID,
+		},
+		{
+			name:     "directory overrides single part",
+			locale:   LocaleEN,
+			dirFiles: map[string]string{"en/" + name + ".subject.tmpl": "dir subject"},
+			want:     map[string]string{"subject": "dir subject"},
+		},
+		{
+			name:     "directory only for its own locale",
+			locale:   LocaleID,
+			dirFiles: map[string]string{"en/" + name + ".subject.tmpl": "dir subject"},
+		},
+		{
+			name:     "database wins over directory",
+			locale:   LocaleID,
+			dirFiles: map[string]string{"id/" + name + ".subject.tmpl": "dir subject", "id/" + name + ".txt.tmpl": "dir text"},
+			override: &models.EmailTemplate{Subject: "db subject"},
+			want:     map[string]string{"subject": "db subject", "txt": "dir text"},
+		},
+		{
+			name:     "empty database part falls through",
+			locale:   LocaleEN,
+			override: &models.EmailTemplate{HTML: "db html"},
+			want:     map[string]string{"html": "db html"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for file, content := range tt.dirFiles {
+				path := filepath.Join(dir, filepath.FromSlash(file))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("EMAIL_TEMPLATE_DIR", dir)
+
+			var db *gorm.DB
+			if tt.override != nil {
+				setupTestDB(t)
+				if err := config.DB.AutoMigrate(&models.EmailTemplate{}); err != nil {
+					t.Fatalf("failed to migrate email_templates: %v", err)
+				}
+				db = config.DB
+				config.DB.Where("name = ? AND locale = ?", name, tt.locale).Delete(&models.EmailTemplate{})
+				override := *tt.override
+				override.ID, override.Name, override.Locale = uuid.New(), name, tt.locale
+				if err := db.Create(&override).Error; err != nil {
+					t.Fatalf("failed to create override: %v", err)
+				}
+				t.Cleanup(func() { config.DB.Delete(&override) })
+			}
+
+			sources := loadEmailTemplateSources(db, name, tt.locale)
+			for _, part := range []string{"subject", "txt", "html"} {
+				want, ok := tt.want[part]
+				if !ok {
+					want = embedded(tt.locale, part)
+				}
+				if sources[part] != want {
+					t.Errorf("%s = %q, want %q", part, sources[part], want)
+				}
+			}
+		})
+	}
+}
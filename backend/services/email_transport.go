@@ -0,0 +1,238 @@
+package services
+
+import (
+	"backendgo/config"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	EmailDriverSendGrid = "sendgrid"
+	EmailDriverSMTP     = "smtp"
+	EmailDriverFile     = "file"
+	EmailDriverNone     = "none"
+)
+
+type EmailAddress struct {
+	Name    string
+	Address string
+}
+
+func (a EmailAddress) String() string {
+	return (&mail.Address{Name: a.Name, Address: a.Address}).String()
+}
+
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	// ContentID diisi untuk lampiran inline yang dirujuk dari HTML lewat cid:
+	ContentID string
+}
+
+type EmailMessage struct {
+	From        EmailAddress
+	To          []EmailAddress
+	Subject     string
+	PlainText   string
+	HTML        string
+	Attachments []EmailAttachment
+}
+
+// EmailTransport mengirim EmailMessage lewat satu penyedia tertentu.
+type EmailTransport interface {
+	Name() string
+	Send(msg *EmailMessage) error
+}
+
+// NewEmailTransportFromEnv memilih transport dari EMAIL_DRIVER. Bila tidak
+// diset, SendGrid dipakai ketika SENDGRID_API_KEY tersedia dan pengiriman
+// dimatikan bila tidak.
+func NewEmailTransportFromEnv() (EmailTransport, error) {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("EMAIL_DRIVER")))
+	if driver == "" {
+		driver = EmailDriverNone
+		if config.Secret("SENDGRID_API_KEY") != "" {
+			driver = EmailDriverSendGrid
+		}
+	}
+
+	switch driver {
+	case EmailDriverSendGrid:
+		return NewSendGridTransport(config.Secret("SENDGRID_API_KEY"))
+	case EmailDriverSMTP:
+		return NewSMTPTransportFromEnv()
+	case EmailDriverFile:
+		return NewFileTransport(os.Getenv("EMAIL_OUTBOX_DIR"))
+	case EmailDriverNone:
+		return noopTransport{}, nil
+	default:
+		return nil, fmt.Errorf("EMAIL_DRIVER %q tidak dikenal", driver)
+	}
+}
+
+// noopTransport dipakai bila email tidak dikonfigurasi; pesan hanya dicatat di log.
+type noopTransport struct{}
+
+func (noopTransport) Name() string { return EmailDriverNone }
+
+func (noopTransport) Send(msg *EmailMessage) error {
+	log.Printf("Email service not configured, skipping %q", msg.Subject)
+	return nil
+}
+
+// buildMIMEMessage menyusun pesan RFC 5322 lengkap untuk transport SMTP dan
+// file dengan struktur:
+//
+//	multipart/mixed
+//	├── multipart/related (hanya bila ada lampiran inline ber-ContentID)
+//	│   ├── multipart/alternative (text/plain, text/html)
+//	│   └── gambar inline yang dirujuk lewat cid:
+//	└── lampiran biasa
+func buildMIMEMessage(msg *EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+
+	recipients := make([]string, 0, len(msg.To))
+	for _, to := range msg.To {
+		recipients = append(recipients, to.String())
+	}
+	fmt.Fprintf(&buf, "From: %s\r\n", msg.From.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", randomHex(16), messageIDDomain(msg.From.Address))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mixed.Boundary())
+
+	var inline, attachments []EmailAttachment
+	for _, a := range msg.Attachments {
+		if a.ContentID != "" {
+			inline = append(inline, a)
+		} else {
+			attachments = append(attachments, a)
+		}
+	}
+
+	bodyType, body, err := buildAlternativePart(msg)
+	if err != nil {
+		return nil, err
+	}
+	// Gambar inline harus satu multipart/related dengan HTML yang merujuknya;
+	// di multipart/mixed klien email menampilkannya sebagai lampiran biasa
+	if len(inline) > 0 {
+		var related bytes.Buffer
+		relatedWriter := multipart.NewWriter(&related)
+		part, err := relatedWriter.CreatePart(textproto.MIMEHeader{"Content-Type": {bodyType}})
+		if err != nil {
+			return nil, err
+		}
+		part.Write(body)
+		for _, a := range inline {
+			if err := writeAttachmentPart(relatedWriter, a); err != nil {
+				return nil, err
+			}
+		}
+		relatedWriter.Close()
+		bodyType = fmt.Sprintf("multipart/related; boundary=%q; type=\"multipart/alternative\"", relatedWriter.Boundary())
+		body = related.Bytes()
+	}
+
+	bodyPart, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {bodyType}})
+	if err != nil {
+		return nil, err
+	}
+	bodyPart.Write(body)
+
+	for _, a := range attachments {
+		if err := writeAttachmentPart(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+	mixed.Close()
+	return buf.Bytes(), nil
+}
+
+// buildAlternativePart menyusun multipart/alternative berisi versi teks dan
+// HTML, dan mengembalikan Content-Type beserta isinya.
+func buildAlternativePart(msg *EmailMessage) (string, []byte, error) {
+	var alt bytes.Buffer
+	altWriter := multipart.NewWriter(&alt)
+	if err := writeTextPart(altWriter, "text/plain; charset=UTF-8", msg.PlainText); err != nil {
+		return "", nil, err
+	}
+	if msg.HTML != "" {
+		if err := writeTextPart(altWriter, "text/html; charset=UTF-8", msg.HTML); err != nil {
+			return "", nil, err
+		}
+	}
+	altWriter.Close()
+	return fmt.Sprintf("multipart/alternative; boundary=%q", altWriter.Boundary()), alt.Bytes(), nil
+}
+
+func writeAttachmentPart(w *multipart.Writer, a EmailAttachment) error {
+	header := textproto.MIMEHeader{
+		"Content-Type":              {a.ContentType},
+		"Content-Transfer-Encoding": {"base64"},
+	}
+	if a.ContentID != "" {
+		header.Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", a.Filename))
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	} else {
+		header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Filename))
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	writeBase64Lines(part, a.Content)
+	return nil
+}
+
+func writeTextPart(w *multipart.Writer, contentType, body string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	writeBase64Lines(part, []byte(body))
+	return nil
+}
+
+// writeBase64Lines menulis base64 dengan panjang baris 76 karakter (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+func messageIDDomain(from string) string {
+	if i := strings.LastIndex(from, "@"); i >= 0 && i < len(from)-1 {
+		return from[i+1:]
+	}
+	return "localhost"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
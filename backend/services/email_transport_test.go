@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// mimeStructure meringkas pohon MIME menjadi string seperti
+// "multipart/mixed[multipart/alternative[text/plain,text/html]]".
+func mimeStructure(t *testing.T, contentType string, body io.Reader) string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("Content-Type %q tidak valid: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return mediaType
+	}
+	var children []string
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("bagian %s tidak dapat dibaca: %v", mediaType, err)
+		}
+		children = append(children, mimeStructure(t, part.Header.Get("Content-Type"), part))
+	}
+	return mediaType + "[" + strings.Join(children, ",") + "]"
+}
+
+func parseTestMessage(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("pesan tidak dapat dibaca: %v", err)
+	}
+	return msg
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	invite := EmailAttachment{Filename: "invite.ics", ContentType: "text/calendar", Content: []byte("BEGIN:VCALENDAR")}
+	logo := EmailAttachment{Filename: "qr.png", ContentType: "image/png", Content: []byte{0x89, 'P', 'N', 'G'}, ContentID: "qr"}
+
+	tests := []struct {
+		name string
+		msg  EmailMessage
+		want string
+	}{
+		{
+			name: "plain text only",
+			msg:  EmailMessage{PlainText: "halo"},
+			want: "multipart/mixed[multipart/alternative[text/plain]]",
+		},
+		{
+			name: "html with attachment",
+			msg:  EmailMessage{PlainText: "halo", HTML: "<p>halo</p>", Attachments: []EmailAttachment{invite}},
+			want: "multipart/mixed[multipart/alternative[text/plain,text/html],text/calendar]",
+		},
+		{
+			name: "inline image goes under related",
+			msg:  EmailMessage{PlainText: "halo", HTML: `<img src="cid:qr">`, Attachments: []EmailAttachment{logo}},
+			want: "multipart/mixed[multipart/related[multipart/alternative[text/plain,text/html],image/png]]",
+		},
+		{
+			name: "inline image and attachment",
+			msg:  EmailMessage{PlainText: "halo", HTML: `<img src="cid:qr">`, Attachments: []EmailAttachment{invite, logo}},
+			want: "multipart/mixed[multipart/related[multipart/alternative[text/plain,text/html],image/png],text/calendar]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.From = EmailAddress{Name: "Booking", Address: "noreply@example.com"}
+			tt.msg.To = []EmailAddress{{Name: "Budi", Address: "budi@example.com"}}
+			tt.msg.Subject = "Undangan rapat"
+
+			raw, err := buildMIMEMessage(&tt.msg)
+			if err != nil {
+				t.Fatalf("buildMIMEMessage: %v", err)
+			}
+			msg := parseTestMessage(t, raw)
+			if got := mimeStructure(t, msg.Header.Get("Content-Type"), msg.Body); got != tt.want {
+				t.Fatalf("struktur = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildMIMEMessageHeaders(t *testing.T) {
+	raw, err := buildMIMEMessage(&EmailMessage{
+		From:      EmailAddress{Name: "Booking", Address: "noreply@example.com"},
+		To:        []EmailAddress{{Address: "a@example.com"}, {Address: "b@example.com"}},
+		Subject:   "Rapat disetujui ✓",
+		PlainText: "halo",
+	})
+	if err != nil {
+		t.Fatalf("buildMIMEMessage: %v", err)
+	}
+	msg := parseTestMessage(t, raw)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Rapat disetujui ✓" {
+		t.Fatalf("Subject = %q (%v)", subject, err)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil || len(to) != 2 {
+		t.Fatalf("To = %v (%v)", to, err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Fatalf("Message-ID = %q, want domain pengirim", id)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExternalEmailMissing    = errors.New("identity provider tidak mengirim email")
+	ErrExternalEmailUnverified = errors.New("email sudah dipakai akun lain dan belum diverifikasi oleh identity provider")
+	ErrExternalAccountConflict = errors.New("email sudah terhubung dengan akun SSO lain")
+	ErrExternalStaffLink       = errors.New("email sudah dipakai akun staf lokal dan tidak dapat dikaitkan otomatis")
+)
+
+// ExternalIdentity adalah user yang sudah diautentikasi oleh identity provider.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Name          string
+	Groups        []string
+}
+
+// RoleMapping memetakan grup/claim dari identity provider ke role aplikasi.
+type RoleMapping struct {
+	// Groups: nama grup -> role. Hanya grup yang dipetakan eksplisit yang
+	// memberi role; nama grup yang kebetulan sama dengan nama role tidak
+	// dipercaya karena bisa dibuat user di identity provider.
+	Groups      map[string]string
+	DefaultRole string
+	// Sync: role user lama ikut diperbarui setiap login. Bila false role hanya
+	// ditentukan saat user pertama kali dibuat dan selanjutnya diatur admin.
+	Sync bool
+}
+
+// rolePriority dipakai bila user berada di beberapa grup: role dengan hak
+// terbesar yang menang.
+var rolePriority = []string{
+	models.RoleSuperAdmin, models.RoleBuildingAdmin, models.RoleRoomManager,
+	models.RoleApprover, models.RoleViewer, models.RoleRequester,
+}
+
+// ParseRoleMapping membaca format "grup=role,grup2=role2".
+func ParseRoleMapping(value, defaultRole string, sync bool) (RoleMapping, error) {
+	m := RoleMapping{Groups: map[string]string{}, DefaultRole: models.RoleRequester, Sync: sync}
+	if defaultRole != "" {
+		if !models.IsValidRole(defaultRole) {
+			return m, fmt.Errorf("role default %q tidak dikenal", defaultRole)
+		}
+		m.DefaultRole = models.NormalizeRole(defaultRole)
+	}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !ok || group == "" || !models.IsValidRole(role) {
+			return m, fmt.Errorf("pemetaan role %q tidak valid", pair)
+		}
+		m.Groups[group] = models.NormalizeRole(role)
+	}
+	return m, nil
+}
+
+// Resolve mengembalikan role untuk daftar grup user, atau DefaultRole bila
+// tidak ada grup yang dipetakan.
+func (m RoleMapping) Resolve(groups []string) string {
+	found := make(map[string]bool)
+	for _, g := range groups {
+		if role, ok := m.Groups[g]; ok {
+			found[role] = true
+		}
+	}
+	for _, role := range rolePriority {
+		if found[role] {
+			return role
+		}
+	}
+	return m.DefaultRole
+}
+
+// ProvisionExternalUser mencari user untuk identitas dari identity provider,
+// mengaitkan akun requester lokal dengan email yang sama, atau membuat user
+// baru. Akun staf lokal tidak dikaitkan otomatis.
+// created bernilai true bila user baru dibuat.
+func ProvisionExternalUser(identity ExternalIdentity, mapping RoleMapping) (user *models.User, created bool, err error) {
+	identity.Email = strings.ToLower(strings.TrimSpace(identity.Email))
+	if identity.Email == "" {
+		return nil, false, ErrExternalEmailMissing
+	}
+	role := mapping.Resolve(identity.Groups)
+
+	user = &models.User{}
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("auth_provider = ? AND external_id = ?", identity.Provider, identity.Subject).First(user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Where("email = ?", identity.Email).First(user).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				created = true
+				return createExternalUser(tx, user, identity, role)
+			case err != nil:
+				return err
+			case !identity.EmailVerified:
+				return ErrExternalEmailUnverified
+			case user.ExternalID != nil:
+				return ErrExternalAccountConflict
+			case models.IsStaffRole(user.Role):
+				// Email yang sama belum membuktikan pemilik akun staf; akun dengan
+				// hak admin tidak pernah dikaitkan otomatis
+				return ErrExternalStaffLink
+			}
+			// Akun requester lokal dengan email yang sama dikaitkan ke identity provider
+			subject := identity.Subject
+			if err := tx.Model(user).Updates(map[string]interface{}{"auth_provider": identity.Provider, "external_id": subject}).Error; err != nil {
+				return err
+			}
+			user.AuthProvider, user.ExternalID = identity.Provider, &subject
+		} else if err != nil {
+			return err
+		}
+
+		if identity.Name != "" && identity.Name != user.Name {
+			if err := tx.Model(user).Update("name", identity.Name).Error; err != nil {
+				return err
+			}
+			user.Name = identity.Name
+		}
+		if mapping.Sync && models.NormalizeRole(user.Role) != role {
+			if _, err := assignRole(tx, user, role, nil); errors.Is(err, ErrLastSuperAdmin) {
+				log.Printf("SSO: role %s tidak diturunkan karena super admin terakhir", user.Username)
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return user, created, nil
+}
+
+func createExternalUser(tx *gorm.DB, user *models.User, identity ExternalIdentity, role string) error {
+	base := identity.Username
+	if base == "" {
+		base, _, _ = strings.Cut(identity.Email, "@")
+	}
+	username := base
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			break
+		}
+		username = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	subject := identity.Subject
+	*user = models.User{
+		Email:        identity.Email,
+		Username:     username,
+		Name:         identity.Name,
+		Role:         role,
+		AuthProvider: identity.Provider,
+		ExternalID:   &subject,
+	}
+	// Password kosong: akun SSO tidak bisa login dengan password
+	return tx.Create(user).Error
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"backendgo/models"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ICalMethodRequest = "REQUEST"
+	ICalMethodCancel  = "CANCEL"
+	ICalMethodPublish = "PUBLISH"
+
+	icalProdID    = "-//BookingMeetings//Meeting Room Booking//EN"
+	icalUIDDomain = "bookingmeetings"
+	icalTimestamp = "20060102T150405Z"
+)
+
+// BookingUID adalah UID iCalendar yang stabil untuk sebuah booking sehingga
+// update dan pembatalan menimpa event yang sama di aplikasi kalender.
+func BookingUID(booking *models.Booking) string {
+	return fmt.Sprintf("%s@%s", booking.ID, icalUIDDomain)
+}
+
+// BuildBookingInvite membuat VCALENDAR berisi satu VEVENT untuk dikirim
+// sebagai undangan (METHOD:REQUEST) atau pembatalan (METHOD:CANCEL).
+func BuildBookingInvite(booking *models.Booking, room *models.Room, method, organizerEmail string) []byte {
+	var buf bytes.Buffer
+	writeICalHeader(&buf, method, "")
+	writeBookingEvent(&buf, booking, room, method, organizerEmail)
+	writeICalLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// BuildCalendarFeed membuat kalender langganan (METHOD:PUBLISH) dari
+// kumpulan booking. Room setiap booking harus sudah di-preload.
+func BuildCalendarFeed(name string, bookings []models.Booking) []byte {
+	var buf bytes.Buffer
+	writeICalHeader(&buf, ICalMethodPublish, name)
+	for i := range bookings {
+		writeBookingEvent(&buf, &bookings[i], &bookings[i].Room, ICalMethodPublish, "")
+	}
+	writeICalLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+func writeICalHeader(buf *bytes.Buffer, method, name string) {
+	writeICalLine(buf, "BEGIN:VCALENDAR")
+	writeICalLine(buf, "VERSION:2.0")
+	writeICalLine(buf, "PRODID:"+icalProdID)
+	writeICalLine(buf, "CALSCALE:GREGORIAN")
+	writeICalLine(buf, "METHOD:"+method)
+	if name != "" {
+		writeICalLine(buf, "X-WR-CALNAME:"+escapeICalText(name))
+	}
+}
+
+func writeBookingEvent(buf *bytes.Buffer, booking *models.Booking, room *models.Room, method, organizerEmail string) {
+	status := "CONFIRMED"
+	switch {
+	case method == ICalMethodCancel:
+		status = "CANCELLED"
+	case booking.Status == models.BookingPending:
+		status = "TENTATIVE"
+	}
+
+	writeICalLine(buf, "BEGIN:VEVENT")
+	writeICalLine(buf, "UID:"+BookingUID(booking))
+	writeICalLine(buf, fmt.Sprintf("SEQUENCE:%d", booking.Sequence))
+	writeICalLine(buf, "DTSTAMP:"+time.Now().UTC().Format(icalTimestamp))
+	writeICalLine(buf, "DTSTART:"+booking.StartTime.UTC().Format(icalTimestamp))
+	writeICalLine(buf, "DTEND:"+booking.EndTime.UTC().Format(icalTimestamp))
+	writeICalLine(buf, "SUMMARY:"+escapeICalText(booking.Purpose))
+	if room != nil && room.Name != "" {
+		writeICalLine(buf, "LOCATION:"+escapeICalText(room.Name))
+	}
+	if url := roomURL(room); url != "" {
+		writeICalLine(buf, "URL:"+url)
+	}
+	// Feed langganan bisa dibaca banyak orang, jadi email pemesan tidak ikut
+	bookedBy := booking.UserName
+	if method != ICalMethodPublish && booking.UserEmail != "" {
+		bookedBy = fmt.Sprintf("%s (%s)", booking.UserName, booking.UserEmail)
+	}
+	writeICalLine(buf, "DESCRIPTION:"+escapeICalText(fmt.Sprintf("Booked by %s\nAttendees: %d\nBooking ID: %s",
+		bookedBy, booking.Attendees, booking.ID)))
+	writeICalLine(buf, "STATUS:"+status)
+	if organizerEmail != "" {
+		writeICalLine(buf, "ORGANIZER;CN=Meeting Room System:mailto:"+organizerEmail)
+	}
+	if method != ICalMethodPublish && booking.UserEmail != "" {
+		writeICalLine(buf, fmt.Sprintf("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:%s",
+			escapeICalParam(booking.UserName), booking.UserEmail))
+	}
+	writeICalLine(buf, "END:VEVENT")
+}
+
+// escapeICalText meng-escape nilai TEXT sesuai RFC 5545 bagian 3.3.11.
+func escapeICalText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// escapeICalParam membungkus nilai parameter dengan kutip bila perlu.
+func escapeICalParam(s string) string {
+	s = strings.ReplaceAll(s, `"`, "'")
+	if strings.ContainsAny(s, ";:,") {
+		return `"` + s + `"`
+	}
+	return s
+}
+
+// writeICalLine menulis satu content line dengan CRLF dan melipat baris yang
+// lebih dari 75 oktet tanpa memotong karakter UTF-8.
+func writeICalLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// Baris lanjutan diawali spasi yang ikut dihitung
+		limit = 74
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"backendgo/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+func TestEscapeICalText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Rapat mingguan", want: "Rapat mingguan"},
+		{name: "comma and semicolon", in: "Rapat; tim A, tim B", want: `Rapat\; tim A\, tim B`},
+		{name: "backslash first", in: `C:\share;x`, want: `C:\\share\;x`},
+		{name: "unix newline", in: "baris 1\nbaris 2", want: `baris 1\nbaris 2`},
+		{name: "windows newline", in: "baris 1\r\nbaris 2", want: `baris 1\nbaris 2`},
+		{name: "colon is not escaped", in: "Agenda: review", want: "Agenda: review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := escapeICalText(tt.in)
+			if got != tt.want {
+				t.Fatalf("escapeICalText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			// Importer harus bisa membaca kembali nilai yang kita tulis
+			if back := unescapeICalText(got); back != strings.ReplaceAll(tt.in, "\r\n", "\n") {
+				t.Fatalf("unescapeICalText(%q) = %q, want %q", got, back, tt.in)
+			}
+		})
+	}
+}
+
+func TestEscapeICalParam(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Budi Santoso", want: "Budi Santoso"},
+		{in: "Santoso, Budi", want: `"Santoso, Budi"`},
+		{in: "Tim;Ops", want: `"Tim;Ops"`},
+		{in: "a:b", want: `"a:b"`},
+		{in: `Budi "Bud" S`, want: "Budi 'Bud' S"},
+		{in: `"Santoso, Budi"`, want: `"'Santoso, Budi'"`},
+	}
+	for _, tt := range tests {
+		if got := escapeICalParam(tt.in); got != tt.want {
+			t.Errorf("escapeICalParam(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteICalLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		wantLines int
+	}{
+		{name: "short", line: "SUMMARY:Rapat", wantLines: 1},
+		{name: "exactly 75 octets", line: "DESCRIPTION:" + strings.Repeat("a", 63), wantLines: 1},
+		{name: "76 octets", line: "DESCRIPTION:" + strings.Repeat("a", 64), wantLines: 2},
+		{name: "long ascii", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 30), wantLines: 5},
+		{name: "multibyte not split", line: "SUMMARY:" + strings.Repeat("rapat ☕ ", 30), wantLines: 5},
+		{name: "four byte runes", line: "SUMMARY:" + strings.Repeat("🎉", 60), wantLines: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeICalLine(&buf, tt.line)
+			out := buf.String()
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output tidak diakhiri CRLF: %q", out)
+			}
+
+			physical := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(physical) != tt.wantLines {
+				t.Fatalf("jumlah baris = %d, want %d", len(physical), tt.wantLines)
+			}
+			for i, l := range physical {
+				if len(l) > 75 {
+					t.Errorf("baris %d panjangnya %d oktet (> 75)", i, len(l))
+				}
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("baris lanjutan %d tidak diawali spasi: %q", i, l)
+				}
+				if !utf8.ValidString(l) {
+					t.Errorf("baris %d memotong karakter UTF-8: %q", i, l)
+				}
+			}
+
+			unfolded, err := unfoldICalLines(strings.NewReader(out))
+			if err != nil || len(unfolded) != 1 || unfolded[0] != tt.line {
+				t.Fatalf("unfold = %q (%v), want %q", unfolded, err, tt.line)
+			}
+		})
+	}
+}
+
+func TestBookingEventRequesterEmail(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	booking := models.Booking{
+		ID: uuid.New(), UserName: "Ani", UserEmail: "ani@example.com", Purpose: "Rapat",
+		Attendees: 4, StartTime: start, EndTime: start.Add(time.Hour), Status: models.BookingApproved,
+	}
+	room := &models.Room{Name: "Ruang A"}
+
+	// Feed langganan bisa dibaca orang lain, undangan hanya dikirim ke pemesan
+	feed := string(BuildCalendarFeed("Ruang A", []models.Booking{booking}))
+	if strings.Contains(feed, booking.UserEmail) {
+		t.Errorf("feed memuat email pemesan:\n%s", feed)
+	}
+	if !strings.Contains(feed, "Booked by Ani") {
+		t.Errorf("feed tidak memuat nama pemesan:\n%s", feed)
+	}
+	invite := string(BuildBookingInvite(&booking, room, ICalMethodRequest, "noreply@example.com"))
+	if !strings.Contains(invite, "Booked by Ani (ani@example.com)") {
+		t.Errorf("undangan tidak memuat email pemesan:\n%s", invite)
+	}
+}
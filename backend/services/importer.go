@@ -0,0 +1,528 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ImportAllOrNothing = "all_or_nothing"
+	ImportBestEffort   = "best_effort"
+
+	ImportRowValid    = "valid"
+	ImportRowImported = "imported"
+	ImportRowFailed   = "failed"
+	ImportRowSkipped  = "skipped"
+
+	maxImportRows = 5000
+)
+
+var (
+	errImportRollback = errors.New("import dibatalkan")
+	icalDurationRe    = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+// ImportRow adalah satu baris hasil parsing file sebelum divalidasi.
+type ImportRow struct {
+	Line      int
+	RoomName  string
+	UserName  string
+	UserEmail string
+	Purpose   string
+	Attendees int
+	StartTime time.Time
+	EndTime   time.Time
+	// ParseError diisi bila baris tidak dapat dibaca sama sekali
+	ParseError string
+}
+
+type ImportOptions struct {
+	DryRun bool
+	// Mode adalah ImportAllOrNothing (default) atau ImportBestEffort
+	Mode string
+	// Approve menyetujui booking hasil import lewat state machine dan
+	// mengirim undangan kalender seperti persetujuan biasa
+	Approve bool
+	// Actor dicatat sebagai pemberi persetujuan bila Approve
+	Actor    Actor
+	Notifier *EmailService
+}
+
+type ImportRowResult struct {
+	Line                  int         `json:"line"`
+	RoomName              string      `json:"room_name"`
+	StartTime             time.Time   `json:"start_time"`
+	EndTime               time.Time   `json:"end_time"`
+	Status                string      `json:"status"`
+	Error                 string      `json:"error,omitempty"`
+	BookingID             *uuid.UUID  `json:"booking_id,omitempty"`
+	ConflictingBookingIDs []uuid.UUID `json:"conflicting_booking_ids,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Mode     string            `json:"mode"`
+	Total    int               `json:"total"`
+	Valid    int               `json:"valid"`
+	Failed   int               `json:"failed"`
+	Imported int               `json:"imported"`
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+// ParseBookingsCSV membaca CSV dengan header. Kolom yang dikenali: room
+// (atau room_name), user_name, user_email, purpose, attendees, start_time,
+// end_time. Waktu boleh RFC3339 atau "2006-01-02 15:04" (zona waktu lokal).
+func ParseBookingsCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header CSV tidak dapat dibaca")
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if name == "room_name" {
+			name = "room"
+		}
+		columns[name] = i
+	}
+	for _, required := range []string{"room", "user_name", "user_email", "purpose", "attendees", "start_time", "end_time"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("kolom %s tidak ditemukan di header CSV", required)
+		}
+	}
+
+	var rows []ImportRow
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if len(rows) >= maxImportRows {
+			return nil, fmt.Errorf("maksimal %d baris per import", maxImportRows)
+		}
+		row := ImportRow{Line: line}
+		if err != nil {
+			row.ParseError = "baris CSV tidak valid"
+			rows = append(rows, row)
+			continue
+		}
+		get := func(col string) string {
+			if i := columns[col]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row.RoomName = get("room")
+		row.UserName = get("user_name")
+		row.UserEmail = get("user_email")
+		row.Purpose = get("purpose")
+		if row.Attendees, err = strconv.Atoi(get("attendees")); err != nil {
+			row.ParseError = "kolom attendees harus berupa angka"
+		} else if row.StartTime, err = parseImportTime(get("start_time")); err != nil {
+			row.ParseError = "format start_time tidak valid"
+		} else if row.EndTime, err = parseImportTime(get("end_time")); err != nil {
+			row.ParseError = "format end_time tidak valid"
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseImportTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("format waktu tidak dikenal: %s", value)
+}
+
+// ParseBookingsICS membaca VEVENT dari file iCalendar. LOCATION dipetakan ke
+// nama ruangan, SUMMARY ke keperluan, ORGANIZER ke pemesan dan jumlah ATTENDEE
+// ke jumlah peserta. Event dengan RRULE dipecah menjadi beberapa baris tanpa
+// tanggal EXDATE; event berstatus CANCELLED dilewati.
+func ParseBookingsICS(r io.Reader) ([]ImportRow, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("file iCalendar tidak dapat dibaca")
+	}
+
+	var rows []ImportRow
+	var event map[string]icalProperty
+	var exdates []icalProperty
+	var attendees, eventLine int
+	for i, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]icalProperty)
+			exdates = nil
+			attendees = 0
+			eventLine = i + 1
+		case line == "END:VEVENT":
+			if event != nil && !strings.EqualFold(event["STATUS"].value, "CANCELLED") {
+				rows = append(rows, icalEventRows(eventLine, event, exdates, attendees)...)
+				if len(rows) > maxImportRows {
+					return nil, fmt.Errorf("maksimal %d baris per import", maxImportRows)
+				}
+			}
+			event = nil
+		case event != nil:
+			prop := parseICalProperty(line)
+			switch prop.name {
+			case "ATTENDEE":
+				attendees++
+				continue
+			case "EXDATE":
+				exdates = append(exdates, prop)
+				continue
+			}
+			if _, seen := event[prop.name]; !seen {
+				event[prop.name] = prop
+			}
+		}
+	}
+	return rows, nil
+}
+
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseICalProperty(line string) icalProperty {
+	prop := icalProperty{params: make(map[string]string)}
+	// Titik dua pertama di luar tanda kutip memisahkan nama+parameter dari nilai
+	inQuote := false
+	split := len(line)
+	for i, ch := range line {
+		if ch == '"' {
+			inQuote = !inQuote
+		} else if ch == ':' && !inQuote {
+			split = i
+			break
+		}
+	}
+	head := line[:split]
+	if split < len(line) {
+		prop.value = line[split+1:]
+	}
+	parts := strings.Split(head, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prop
+}
+
+func unescapeICalText(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
+
+func parseICalTime(prop icalProperty) (time.Time, error) {
+	loc := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	value := prop.value
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalTimestamp, value)
+	}
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		return time.ParseInLocation("20060102", value, loc)
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+func parseICalDuration(value string) (time.Duration, error) {
+	m := icalDurationRe.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("DURATION tidak valid")
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] != "" {
+			n, _ := strconv.Atoi(m[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
+
+func icalEventRows(line int, event map[string]icalProperty, exdates []icalProperty, attendees int) []ImportRow {
+	row := ImportRow{
+		Line:      line,
+		RoomName:  unescapeICalText(event["LOCATION"].value),
+		Purpose:   unescapeICalText(event["SUMMARY"].value),
+		Attendees: attendees,
+	}
+	if row.Attendees == 0 {
+		row.Attendees = 1
+	}
+	if x, ok := event["X-ATTENDEES"]; ok {
+		if n, err := strconv.Atoi(x.value); err == nil {
+			row.Attendees = n
+		}
+	}
+	if organizer, ok := event["ORGANIZER"]; ok {
+		row.UserEmail = strings.TrimPrefix(strings.TrimPrefix(organizer.value, "mailto:"), "MAILTO:")
+		row.UserName = organizer.params["CN"]
+		if row.UserName == "" {
+			row.UserName = row.UserEmail
+		}
+	}
+
+	start, err := parseICalTime(event["DTSTART"])
+	if err != nil {
+		row.ParseError = "DTSTART tidak valid"
+		return []ImportRow{row}
+	}
+	var duration time.Duration
+	if dtend, ok := event["DTEND"]; ok {
+		end, err := parseICalTime(dtend)
+		if err != nil {
+			row.ParseError = "DTEND tidak valid"
+			return []ImportRow{row}
+		}
+		duration = end.Sub(start)
+	} else if d, ok := event["DURATION"]; ok {
+		if duration, err = parseICalDuration(d.value); err != nil {
+			row.ParseError = err.Error()
+			return []ImportRow{row}
+		}
+	}
+	row.StartTime, row.EndTime = start, start.Add(duration)
+
+	rrule, ok := event["RRULE"]
+	if !ok {
+		return []ImportRow{row}
+	}
+	rule, err := ParseRecurrenceRule(rrule.value)
+	if err != nil {
+		row.ParseError = err.Error()
+		return []ImportRow{row}
+	}
+	occurrences, err := rule.Occurrences(start)
+	if err != nil {
+		row.ParseError = err.Error()
+		return []ImportRow{row}
+	}
+	excluded, err := parseICalExdates(exdates)
+	if err != nil {
+		row.ParseError = err.Error()
+		return []ImportRow{row}
+	}
+	var rows []ImportRow
+	for _, occurrence := range occurrences {
+		if excluded(occurrence) {
+			continue
+		}
+		r := row
+		r.StartTime, r.EndTime = occurrence, occurrence.Add(duration)
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// parseICalExdates mengembalikan fungsi yang menandai kemunculan yang
+// dikecualikan oleh EXDATE. EXDATE berupa tanggal (VALUE=DATE) mengecualikan
+// kemunculan pada tanggal tersebut di zona waktu kemunculannya.
+func parseICalExdates(props []icalProperty) (func(time.Time) bool, error) {
+	var times []time.Time
+	dates := make(map[string]bool)
+	for _, prop := range props {
+		for _, value := range strings.Split(prop.value, ",") {
+			if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+				if _, err := time.Parse("20060102", value); err != nil {
+					return nil, fmt.Errorf("EXDATE tidak valid")
+				}
+				dates[value] = true
+				continue
+			}
+			t, err := parseICalTime(icalProperty{params: prop.params, value: value})
+			if err != nil {
+				return nil, fmt.Errorf("EXDATE tidak valid")
+			}
+			times = append(times, t)
+		}
+	}
+	return func(occurrence time.Time) bool {
+		if dates[occurrence.Format("20060102")] {
+			return true
+		}
+		for _, t := range times {
+			if occurrence.Equal(t) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// ImportBookings memvalidasi setiap baris dengan aturan yang sama seperti
+// pembuatan booking biasa lalu menyimpannya. Pada mode all-or-nothing satu
+// baris gagal membatalkan seluruh import; pada mode best-effort baris yang
+// valid tetap disimpan. DryRun selalu membatalkan transaksi.
+func ImportBookings(rows []ImportRow, opts ImportOptions) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ImportAllOrNothing
+	}
+	if opts.Mode != ImportAllOrNothing && opts.Mode != ImportBestEffort {
+		return nil, fmt.Errorf("mode import tidak dikenal: %s", opts.Mode)
+	}
+
+	report := &ImportReport{DryRun: opts.DryRun, Mode: opts.Mode, Total: len(rows), Rows: make([]ImportRowResult, len(rows))}
+
+	rooms, err := roomsByName()
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data ruangan")
+	}
+	var roomIDs []uuid.UUID
+	for _, room := range rooms {
+		roomIDs = append(roomIDs, room.ID)
+	}
+
+	err = withRoomLock(roomIDs, func(tx *gorm.DB) error {
+		for i, row := range rows {
+			result := &report.Rows[i]
+			*result = ImportRowResult{Line: row.Line, RoomName: row.RoomName, StartTime: row.StartTime, EndTime: row.EndTime}
+
+			room, rowErr := validateImportRow(row, rooms)
+			if rowErr == nil {
+				// Baris yang sudah masuk di transaksi ini ikut diperhitungkan sebagai bentrok
+				_, rowErr = validateBookingSlot(tx, room.ID, row.StartTime, row.EndTime, row.Attendees, nil)
+			}
+			if rowErr != nil {
+				result.Status = ImportRowFailed
+				result.Error = rowErr.Error()
+				var conflictErr *ConflictError
+				if errors.As(rowErr, &conflictErr) {
+					for _, b := range conflictErr.Conflicts {
+						result.ConflictingBookingIDs = append(result.ConflictingBookingIDs, b.ID)
+					}
+				}
+				report.Failed++
+				continue
+			}
+
+			booking := newBooking(room.ID, row.UserName, row.UserEmail, row.Purpose, row.Attendees, row.StartTime, row.EndTime)
+			// Booking impor berasal dari sistem lain; pemesannya tidak tahu
+			// harus check-in sehingga tidak ditandai no_show
+			booking.CheckInRequired = false
+			if err := tx.Create(&booking).Error; err != nil {
+				return fmt.Errorf("gagal menyimpan baris %d", row.Line)
+			}
+			if opts.Approve {
+				if err := TransitionBooking(tx, &booking, models.BookingApproved, opts.Actor, "import"); err != nil {
+					return fmt.Errorf("gagal menyetujui baris %d: %w", row.Line, err)
+				}
+				if err := opts.Notifier.QueueBookingStatusUpdate(tx, &booking, room, models.BookingPending); err != nil {
+					return fmt.Errorf("gagal menyetujui baris %d: %w", row.Line, err)
+				}
+			}
+			result.Status = ImportRowValid
+			result.BookingID = &booking.ID
+			report.Valid++
+		}
+
+		if opts.DryRun || (opts.Mode == ImportAllOrNothing && report.Failed > 0) {
+			return errImportRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return nil, err
+	}
+
+	committed := err == nil
+	for i := range report.Rows {
+		r := &report.Rows[i]
+		if r.Status != ImportRowValid {
+			continue
+		}
+		switch {
+		case committed:
+			r.Status = ImportRowImported
+			report.Imported++
+		case opts.DryRun:
+			// ID hanya sementara di dalam transaksi yang dibatalkan
+			r.BookingID = nil
+		default:
+			r.Status = ImportRowSkipped
+			r.BookingID = nil
+		}
+	}
+	return report, nil
+}
+
+func roomsByName() (map[string]models.Room, error) {
+	var rooms []models.Room
+	if err := config.DB.Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+	byName := make(map[string]models.Room, len(rooms))
+	for _, room := range rooms {
+		byName[strings.ToLower(strings.TrimSpace(room.Name))] = room
+	}
+	return byName, nil
+}
+
+func validateImportRow(row ImportRow, rooms map[string]models.Room) (*models.Room, error) {
+	if row.ParseError != "" {
+		return nil, errors.New(row.ParseError)
+	}
+	if row.UserName == "" || row.UserEmail == "" || row.Purpose == "" {
+		return nil, fmt.Errorf("user_name, user_email dan purpose wajib diisi")
+	}
+	if row.Attendees < 1 {
+		return nil, fmt.Errorf("jumlah peserta minimal 1")
+	}
+	room, ok := rooms[strings.ToLower(strings.TrimSpace(row.RoomName))]
+	if !ok {
+		return nil, fmt.Errorf("ruangan %q tidak ditemukan", row.RoomName)
+	}
+	return &room, nil
+}
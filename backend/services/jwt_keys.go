@@ -0,0 +1,364 @@
+package services
+
+import (
+	"backendgo/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+
+	minRSAKeyBits = 2048
+)
+
+var ErrJWTUnknownKey = errors.New("kid token tidak dikenal")
+
+// JWTKey adalah satu key penandatangan access token. Key yang hanya berisi
+// public key (atau key lama yang sedang dirotasi keluar) hanya dipakai untuk
+// verifikasi.
+type JWTKey struct {
+	ID        string
+	Algorithm string
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func (k *JWTKey) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// CanSign bernilai true bila private key/secret-nya tersedia.
+func (k *JWTKey) CanSign() bool {
+	return k.signKey != nil
+}
+
+// JWTKeySet berisi semua key yang diterima saat verifikasi dan satu key aktif
+// untuk menandatangani token baru. Rotasi: tambahkan key baru, jadikan aktif,
+// lalu hapus key lama setelah ACCESS_TOKEN_TTL lewat. Token dengan key lama
+// tetap valid selama key itu masih ada di set.
+type JWTKeySet struct {
+	Issuer string
+	active *JWTKey
+	keys   map[string]*JWTKey
+}
+
+// NewJWTKeySet membuat key set dengan activeKID sebagai key penandatangan.
+func NewJWTKeySet(keys []*JWTKey, activeKID, issuer string) (*JWTKeySet, error) {
+	set := &JWTKeySet{Issuer: issuer, keys: map[string]*JWTKey{}}
+	for _, k := range keys {
+		if _, dup := set.keys[k.ID]; dup {
+			return nil, fmt.Errorf("kid %q dipakai lebih dari satu key", k.ID)
+		}
+		set.keys[k.ID] = k
+	}
+	active, ok := set.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("key aktif %q tidak ditemukan", activeKID)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("key aktif %q hanya berisi public key", activeKID)
+	}
+	set.active = active
+	return set, nil
+}
+
+// ActiveKeyID mengembalikan kid yang dipakai untuk token baru.
+func (s *JWTKeySet) ActiveKeyID() string {
+	return s.active.ID
+}
+
+// Sign menandatangani claims dengan key aktif dan mengisi header kid.
+func (s *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method(), claims)
+	token.Header["kid"] = s.active.ID
+	return token.SignedString(s.active.signKey)
+}
+
+// Parse memverifikasi token dengan key sesuai kid di header. Algoritma token
+// harus sama dengan algoritma key agar public key RSA tidak bisa dipakai
+// sebagai secret HMAC.
+func (s *JWTKeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{JWTAlgHS256, JWTAlgRS256, JWTAlgEdDSA}),
+		jwt.WithExpirationRequired(),
+	}
+	if s.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.Issuer))
+	}
+	return jwt.ParseWithClaims(tokenString, claims, s.keyFunc, opts...)
+}
+
+func (s *JWTKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrJWTUnknownKey
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("algoritma %s tidak cocok dengan key %s", token.Method.Alg(), kid)
+	}
+	return key.verifyKey, nil
+}
+
+// JWK adalah public key dalam format JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS mengembalikan public key semua key asimetris, key aktif lebih dulu.
+// Secret HS256 tidak pernah dipublikasikan.
+func (s *JWTKeySet) JWKS() []JWK {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if (ids[i] == s.active.ID) != (ids[j] == s.active.ID) {
+			return ids[i] == s.active.ID
+		}
+		return ids[i] < ids[j]
+	})
+
+	jwks := []JWK{}
+	for _, id := range ids {
+		key := s.keys[id]
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// NewHMACKey membuat key HS256 dari secret. Bila kid kosong, kid diturunkan
+// dari hash secret.
+func NewHMACKey(kid string, secret []byte) (*JWTKey, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret HS256 kosong")
+	}
+	if len(secret) < 32 {
+		log.Println("Warning: JWT HS256 secret is shorter than 32 bytes")
+	}
+	if kid == "" {
+		sum := sha256.Sum256(append([]byte("jwt-kid:"), secret...))
+		kid = "hs-" + hex.EncodeToString(sum[:6])
+	}
+	return &JWTKey{ID: kid, Algorithm: JWTAlgHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// ParseJWTKeyPEM membaca private key (PKCS#1/PKCS#8) atau public key (PKIX)
+// RSA maupun Ed25519. Algoritma ditentukan dari jenis key. Bila kid kosong,
+// kid diturunkan dari hash public key.
+func ParseJWTKeyPEM(kid string, data []byte) (*JWTKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("bukan file PEM")
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jenis PEM %q tidak didukung", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &JWTKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm, key.signKey, key.verifyKey = JWTAlgRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Algorithm, key.verifyKey = JWTAlgRS256, k
+	case ed25519.PrivateKey:
+		key.Algorithm, key.signKey, key.verifyKey = JWTAlgEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Algorithm, key.verifyKey = JWTAlgEdDSA, k
+	default:
+		return nil, fmt.Errorf("hanya key RSA dan Ed25519 yang didukung, bukan %T", parsed)
+	}
+	if pub, ok := key.verifyKey.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("key RSA minimal %d bit", minRSAKeyBits)
+	}
+	if key.ID == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(der)
+		key.ID = strings.ToLower(key.Algorithm[:2]) + "-" + hex.EncodeToString(sum[:6])
+	}
+	return key, nil
+}
+
+// loadJWTKeysDir membaca setiap <kid>.pem (RSA/Ed25519) dan <kid>.key
+// (secret HS256) di dir. Nama file tanpa ekstensi menjadi kid.
+func loadJWTKeysDir(dir string) ([]*JWTKey, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*JWTKey
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".pem" && ext != ".key") {
+			continue
+		}
+		kid := strings.TrimSuffix(entry.Name(), ext)
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var key *JWTKey
+		if ext == ".pem" {
+			key, err = ParseJWTKeyPEM(kid, data)
+		} else {
+			key, err = NewHMACKey(kid, []byte(strings.TrimSpace(string(data))))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// LoadJWTKeySetFromEnv menyusun key set dari:
+//   - JWT_KEYS_DIR: direktori berisi <kid>.pem dan/atau <kid>.key;
+//   - secret JWT_PRIVATE_KEY: private key PEM dengan kid JWT_KEY_ID;
+//   - secret JWT_SECRET: secret HS256 dengan kid JWT_KEY_ID.
+//
+// Secret dibaca lewat config.LookupSecret sehingga bisa berupa env, file
+// (*_FILE) atau Docker secret. JWT_ACTIVE_KID memilih key penandatangan;
+// default JWT_PRIVATE_KEY, lalu JWT_SECRET, lalu satu-satunya key di
+// JWT_KEYS_DIR. JWT_ISSUER mengisi claim iss (default PUBLIC_API_BASE_URL).
+func LoadJWTKeySetFromEnv() (*JWTKeySet, error) {
+	var keys []*JWTKey
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		dirKeys, err := loadJWTKeysDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_KEYS_DIR: %w", err)
+		}
+		keys = append(keys, dirKeys...)
+	}
+
+	activeKID := os.Getenv("JWT_ACTIVE_KID")
+	envKID := os.Getenv("JWT_KEY_ID")
+	privateKey, ok, err := config.LookupSecret("JWT_PRIVATE_KEY")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		key, err := ParseJWTKeyPEM(envKID, []byte(privateKey))
+		if err != nil {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+		}
+		keys = append(keys, key)
+		if activeKID == "" {
+			activeKID = key.ID
+		}
+	} else {
+		secret, ok, err := config.LookupSecret("JWT_SECRET")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			key, err := NewHMACKey(envKID, []byte(secret))
+			if err != nil {
+				return nil, fmt.Errorf("JWT_SECRET: %w", err)
+			}
+			keys = append(keys, key)
+			if activeKID == "" {
+				activeKID = key.ID
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		// Tanpa konfigurasi (development) dipakai key acak: access token lama
+		// tidak berlaku setelah restart, client cukup refresh
+		log.Println("Warning: JWT signing key is not configured, using a random key until restart")
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		pub := priv.Public().(ed25519.PublicKey)
+		keys = append(keys, &JWTKey{ID: "dev-" + hex.EncodeToString(pub[:6]), Algorithm: JWTAlgEdDSA, signKey: priv, verifyKey: pub})
+	}
+	if activeKID == "" {
+		if len(keys) > 1 {
+			return nil, errors.New("JWT_ACTIVE_KID wajib diisi bila ada lebih dari satu key")
+		}
+		activeKID = keys[0].ID
+	}
+
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = Links().APIBaseURL
+	}
+	return NewJWTKeySet(keys, activeKID, issuer)
+}
+
+var (
+	jwtKeysOnce sync.Once
+	jwtKeys     *JWTKeySet
+)
+
+// JWTKeys mengembalikan key set dari environment; dibaca sekali saat pertama
+// dipakai. Konfigurasi yang salah menghentikan proses karena token tidak bisa
+// diterbitkan maupun diverifikasi.
+func JWTKeys() *JWTKeySet {
+	jwtKeysOnce.Do(func() {
+		set, err := LoadJWTKeySetFromEnv()
+		if err != nil {
+			log.Fatalf("Konfigurasi JWT key tidak valid: %v", err)
+		}
+		jwtKeys = set
+	})
+	return jwtKeys
+}
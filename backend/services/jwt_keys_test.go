@@ -0,0 +1,318 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	testRSAKeysOnce sync.Once
+	testRSAKeys     [2]*rsa.PrivateKey
+)
+
+// testRSAKey mengembalikan salah satu dari dua key RSA 2048 bit yang dibuat
+// sekali per proses test karena pembuatannya lambat.
+func testRSAKey(t *testing.T, i int) *rsa.PrivateKey {
+	t.Helper()
+	testRSAKeysOnce.Do(func() {
+		for n := range testRSAKeys {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			if err != nil {
+				panic(err)
+			}
+			testRSAKeys[n] = key
+		}
+	})
+	return testRSAKeys[i]
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func pemBlock(t *testing.T, blockType string, der []byte, err error) []byte {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func pkcs8PEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	return pemBlock(t, "PRIVATE KEY", der, err)
+}
+
+func pkixPEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	return pemBlock(t, "PUBLIC KEY", der, err)
+}
+
+func TestParseJWTKeyPEM(t *testing.T) {
+	rsaKey := testRSAKey(t, 0)
+	edKey := testEd25519Key(t)
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		kid        string
+		pem        []byte
+		wantAlg    string
+		wantSign   bool
+		wantKIDPre string
+		wantErr    string
+	}{
+		{name: "RSA PKCS#1 private", pem: pemBlock(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey), nil), wantAlg: JWTAlgRS256, wantSign: true, wantKIDPre: "rs-"},
+		{name: "RSA PKCS#8 private", pem: pkcs8PEM(t, rsaKey), wantAlg: JWTAlgRS256, wantSign: true, wantKIDPre: "rs-"},
+		{name: "RSA PKCS#1 public", pem: pemBlock(t, "RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey), nil), wantAlg: JWTAlgRS256, wantKIDPre: "rs-"},
+		{name: "RSA PKIX public", pem: pkixPEM(t, &rsaKey.PublicKey), wantAlg: JWTAlgRS256, wantKIDPre: "rs-"},
+		{name: "Ed25519 private", pem: pkcs8PEM(t, edKey), wantAlg: JWTAlgEdDSA, wantSign: true, wantKIDPre: "ed-"},
+		{name: "Ed25519 public", pem: pkixPEM(t, edKey.Public()), wantAlg: JWTAlgEdDSA, wantKIDPre: "ed-"},
+		{name: "explicit kid is kept", kid: "2026-03", pem: pkcs8PEM(t, edKey), wantAlg: JWTAlgEdDSA, wantSign: true, wantKIDPre: "2026-03"},
+		{name: "weak RSA key", pem: pkcs8PEM(t, weakRSA), wantErr: "minimal 2048"},
+		{name: "ECDSA key", pem: pkcs8PEM(t, ecKey), wantErr: "RSA dan Ed25519"},
+		{name: "certificate block", pem: pemBlock(t, "CERTIFICATE", []byte{1, 2, 3}, nil), wantErr: "tidak didukung"},
+		{name: "not PEM", pem: []byte("-----BEGIN nothing"), wantErr: "bukan file PEM"},
+		{name: "corrupt DER", pem: pemBlock(t, "PRIVATE KEY", []byte{1, 2, 3}, nil), wantErr: "asn1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseJWTKeyPEM(tt.kid, tt.pem)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJWTKeyPEM: %v", err)
+			}
+			if key.Algorithm != tt.wantAlg || key.CanSign() != tt.wantSign || !strings.HasPrefix(key.ID, tt.wantKIDPre) {
+				t.Fatalf("key = {ID:%s Algorithm:%s CanSign:%v}, want alg %s, sign %v, kid prefix %s",
+					key.ID, key.Algorithm, key.CanSign(), tt.wantAlg, tt.wantSign, tt.wantKIDPre)
+			}
+		})
+	}
+
+	// Kid turunan sama untuk private key dan public key-nya agar verifier
+	// yang hanya punya public key menerima token penandatangan
+	private, _ := ParseJWTKeyPEM("", pkcs8PEM(t, rsaKey))
+	public, _ := ParseJWTKeyPEM("", pkixPEM(t, &rsaKey.PublicKey))
+	if private.ID != public.ID {
+		t.Fatalf("kid private %s != kid public %s", private.ID, public.ID)
+	}
+}
+
+func TestNewJWTKeySet(t *testing.T) {
+	hmac, _ := NewHMACKey("hs", []byte("0123456789abcdef0123456789abcdef"))
+	public, _ := ParseJWTKeyPEM("pub", pkixPEM(t, testEd25519Key(t).Public()))
+	duplicate, _ := NewHMACKey("hs", []byte("fedcba9876543210fedcba9876543210"))
+
+	tests := []struct {
+		name    string
+		keys    []*JWTKey
+		active  string
+		wantErr string
+	}{
+		{name: "valid", keys: []*JWTKey{hmac, public}, active: "hs"},
+		{name: "duplicate kid", keys: []*JWTKey{hmac, duplicate}, active: "hs", wantErr: "lebih dari satu"},
+		{name: "active missing", keys: []*JWTKey{hmac}, active: "rs", wantErr: "tidak ditemukan"},
+		{name: "active is public only", keys: []*JWTKey{hmac, public}, active: "pub", wantErr: "hanya berisi public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTKeySet(tt.keys, tt.active, "")
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("NewJWTKeySet: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTKeySetJWKS(t *testing.T) {
+	rsaKey := testRSAKey(t, 0)
+	edKey := testEd25519Key(t)
+	active, _ := ParseJWTKeyPEM("z-active", pkcs8PEM(t, rsaKey))
+	old, _ := ParseJWTKeyPEM("a-old", pkixPEM(t, edKey.Public()))
+	hmac, _ := NewHMACKey("m-hs", []byte("0123456789abcdef0123456789abcdef"))
+	set, err := NewJWTKeySet([]*JWTKey{old, hmac, active}, "z-active", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := set.JWKS()
+	// Key aktif lebih dulu meski kid-nya terakhir secara abjad; HS256 tidak dipublikasikan
+	if len(jwks) != 2 || jwks[0].KeyID != "z-active" || jwks[1].KeyID != "a-old" {
+		t.Fatalf("JWKS kid = %+v, want [z-active a-old]", jwks)
+	}
+
+	rsaJWK := jwks[0]
+	n, _ := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	e, _ := base64.RawURLEncoding.DecodeString(rsaJWK.E)
+	if rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != JWTAlgRS256 || rsaJWK.Use != "sig" ||
+		new(big.Int).SetBytes(n).Cmp(rsaKey.N) != 0 || int(new(big.Int).SetBytes(e).Int64()) != rsaKey.E {
+		t.Fatalf("RSA JWK tidak cocok dengan public key: %+v", rsaJWK)
+	}
+
+	edJWK := jwks[1]
+	x, _ := base64.RawURLEncoding.DecodeString(edJWK.X)
+	if edJWK.KeyType != "OKP" || edJWK.Curve != "Ed25519" || edJWK.Algorithm != JWTAlgEdDSA ||
+		!ed25519.PublicKey(x).Equal(edKey.Public()) {
+		t.Fatalf("Ed25519 JWK tidak cocok dengan public key: %+v", edJWK)
+	}
+	if edJWK.N != "" || rsaJWK.X != "" {
+		t.Fatalf("JWK berisi field key lain: %+v", jwks)
+	}
+}
+
+func TestJWTKeySetParse(t *testing.T) {
+	rsaKey := testRSAKey(t, 0)
+	nextRSA := testRSAKey(t, 1)
+	edKey := testEd25519Key(t)
+	rsaPublicPEM := pkixPEM(t, &rsaKey.PublicKey)
+
+	active, _ := ParseJWTKeyPEM("rs-active", pkcs8PEM(t, rsaKey))
+	next, _ := ParseJWTKeyPEM("rs-next", pkcs8PEM(t, nextRSA))
+	ed, _ := ParseJWTKeyPEM("ed-old", pkcs8PEM(t, edKey))
+	hmac, _ := NewHMACKey("hs-old", []byte("0123456789abcdef0123456789abcdef"))
+	set, err := NewJWTKeySet([]*JWTKey{active, next, ed, hmac}, "rs-active", "https://booking.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(issuer string, exp time.Duration) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{Issuer: issuer, Subject: "user", ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp))}
+	}
+	sign := func(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, c jwt.RegisteredClaims) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	valid := claims("https://booking.example.com", time.Minute)
+
+	tests := []struct {
+		name      string
+		token     func(t *testing.T) string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "active key via Sign",
+			token: func(t *testing.T) string {
+				s, err := set.Sign(valid)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return s
+			},
+		},
+		{name: "other RSA key in set", token: func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "rs-next", nextRSA, valid) }},
+		{name: "Ed25519 key in set", token: func(t *testing.T) string { return sign(t, jwt.SigningMethodEdDSA, "ed-old", edKey, valid) }},
+		{name: "HS256 key in set", token: func(t *testing.T) string {
+			return sign(t, jwt.SigningMethodHS256, "hs-old", []byte("0123456789abcdef0123456789abcdef"), valid)
+		}},
+		{
+			name:      "unknown kid",
+			token:     func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "rs-removed", rsaKey, valid) },
+			wantErrIs: ErrJWTUnknownKey,
+		},
+		{
+			name:      "missing kid",
+			token:     func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "", rsaKey, valid) },
+			wantErrIs: ErrJWTUnknownKey,
+		},
+		{
+			name:    "kid signed by a different key",
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodRS256, "rs-active", nextRSA, valid) },
+			wantErr: true,
+		},
+		{
+			// Serangan klasik: public key RSA dipakai sebagai secret HMAC
+			name:    "HS256 with RSA public key as secret",
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodHS256, "rs-active", rsaPublicPEM, valid) },
+			wantErr: true,
+		},
+		{
+			name:    "EdDSA token with RSA kid",
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodEdDSA, "rs-active", edKey, valid) },
+			wantErr: true,
+		},
+		{
+			name:    "RS512 is not accepted",
+			token:   func(t *testing.T) string { return sign(t, jwt.SigningMethodRS512, "rs-active", rsaKey, valid) },
+			wantErr: true,
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rs-active", rsaKey, claims("https://evil.example.com", time.Minute))
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodRS256, "rs-active", rsaKey, claims("https://booking.example.com", -time.Minute))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed jwt.RegisteredClaims
+			_, err := set.Parse(tt.token(t), &parsed)
+			switch {
+			case tt.wantErrIs != nil:
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErrIs)
+				}
+			case tt.wantErr:
+				if err == nil {
+					t.Fatal("err = nil, want error")
+				}
+			case err != nil:
+				t.Fatalf("Parse: %v", err)
+			case parsed.Subject != "user":
+				t.Fatalf("subject = %q", parsed.Subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLDAPDisabled           = errors.New("autentikasi LDAP belum dikonfigurasi")
+	ErrLDAPInvalidCredentials = errors.New("username atau password LDAP salah")
+)
+
+const (
+	defaultLDAPSyncInterval = time.Hour
+	ldapTimeout             = 10 * time.Second
+	ldapPageSize            = 500
+	// Bit ACCOUNTDISABLE pada userAccountControl Active Directory
+	adAccountDisabled = 0x2
+)
+
+// LDAPConfig adalah konfigurasi koneksi dan pemetaan atribut directory. Nilai
+// default cocok untuk OpenLDAP; untuk Active Directory biasanya cukup
+// mengganti filter dan atribut, lihat LoadLDAPConfigFromEnv.
+type LDAPConfig struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// BindDN/BindPassword adalah service account untuk mencari user dan sync.
+	// Kosong berarti anonymous bind.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter mencari satu user saat login; {username} diganti username yang
+	// sudah di-escape. SyncFilter memilih semua user yang disinkronkan.
+	UserFilter string
+	SyncFilter string
+
+	AttrID       string
+	AttrUsername string
+	AttrEmail    string
+	AttrName     string
+	AttrGroups   string
+
+	Roles        RoleMapping
+	SyncInterval time.Duration
+}
+
+// LoadLDAPConfigFromEnv membaca LDAP_URL, LDAP_START_TLS,
+// LDAP_INSECURE_SKIP_VERIFY, LDAP_BIND_DN, LDAP_BIND_PASSWORD, LDAP_BASE_DN,
+// LDAP_USER_FILTER, LDAP_SYNC_FILTER, LDAP_ATTR_{ID,USERNAME,EMAIL,NAME,GROUPS},
+// LDAP_ROLE_MAP, LDAP_DEFAULT_ROLE, LDAP_ROLE_SYNC dan LDAP_SYNC_INTERVAL
+// (default 1h, 0 mematikan sync berkala). LDAP nonaktif bila LDAP_URL kosong.
+//
+// Contoh Active Directory:
+//
+//	LDAP_USER_FILTER=(&(objectClass=user)(sAMAccountName={username}))
+//	LDAP_SYNC_FILTER=(&(objectClass=user)(objectCategory=person))
+//	LDAP_ATTR_ID=objectGUID
+//	LDAP_ATTR_USERNAME=sAMAccountName
+//	LDAP_ATTR_NAME=displayName
+func LoadLDAPConfigFromEnv() (LDAPConfig, error) {
+	cfg := LDAPConfig{
+		URL:          strings.TrimSpace(os.Getenv("LDAP_URL")),
+		BindDN:       os.Getenv("LDAP_BIND_DN"),
+		BindPassword: config.Secret("LDAP_BIND_PASSWORD"),
+		BaseDN:       os.Getenv("LDAP_BASE_DN"),
+		UserFilter:   envOr("LDAP_USER_FILTER", "(&(objectClass=inetOrgPerson)(uid={username}))"),
+		SyncFilter:   envOr("LDAP_SYNC_FILTER", "(objectClass=inetOrgPerson)"),
+		AttrID:       envOr("LDAP_ATTR_ID", "entryUUID"),
+		AttrUsername: envOr("LDAP_ATTR_USERNAME", "uid"),
+		AttrEmail:    envOr("LDAP_ATTR_EMAIL", "mail"),
+		AttrName:     envOr("LDAP_ATTR_NAME", "cn"),
+		AttrGroups:   envOr("LDAP_ATTR_GROUPS", "memberOf"),
+		SyncInterval: defaultLDAPSyncInterval,
+	}
+	if cfg.URL == "" {
+		return cfg, nil
+	}
+	if cfg.BaseDN == "" {
+		return cfg, fmt.Errorf("LDAP_BASE_DN wajib diisi")
+	}
+	cfg.StartTLS, _ = strconv.ParseBool(os.Getenv("LDAP_START_TLS"))
+	cfg.InsecureSkipVerify, _ = strconv.ParseBool(os.Getenv("LDAP_INSECURE_SKIP_VERIFY"))
+	if v := os.Getenv("LDAP_SYNC_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval < 0 {
+			return cfg, fmt.Errorf("LDAP_SYNC_INTERVAL %q tidak valid", v)
+		}
+		cfg.SyncInterval = interval
+	}
+	roleSync := true
+	if v, err := strconv.ParseBool(os.Getenv("LDAP_ROLE_SYNC")); err == nil {
+		roleSync = v
+	}
+	roles, err := ParseRoleMapping(os.Getenv("LDAP_ROLE_MAP"), os.Getenv("LDAP_DEFAULT_ROLE"), roleSync)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Roles = roles
+	return cfg, nil
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// LDAPService mengautentikasi user dengan bind ke directory dan
+// menyinkronkan user directory ke tabel users.
+type LDAPService struct {
+	cfg LDAPConfig
+	// syncMu mencegah worker dan admin menjalankan sync bersamaan
+	syncMu   sync.Mutex
+	lastSync *DirectorySyncReport
+}
+
+func NewLDAPService(cfg LDAPConfig) *LDAPService {
+	return &LDAPService{cfg: cfg}
+}
+
+var (
+	ldapOnce    sync.Once
+	ldapService *LDAPService
+)
+
+// LDAP mengembalikan LDAPService dari environment, atau nil bila LDAP tidak
+// dikonfigurasi. Method LDAPService aman dipanggil pada nil.
+func LDAP() *LDAPService {
+	ldapOnce.Do(func() {
+		cfg, err := LoadLDAPConfigFromEnv()
+		if err != nil {
+			log.Printf("LDAP dinonaktifkan: %v", err)
+			return
+		}
+		if cfg.URL != "" {
+			ldapService = NewLDAPService(cfg)
+		}
+	})
+	return ldapService
+}
+
+func (s *LDAPService) Enabled() bool {
+	return s != nil
+}
+
+// connect membuka koneksi dan bind sebagai service account.
+func (s *LDAPService) connect() (*ldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.cfg.InsecureSkipVerify}
+	conn, err := ldap.DialURL(s.cfg.URL, ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("gagal terhubung ke LDAP: %w", err)
+	}
+	conn.SetTimeout(ldapTimeout)
+	if s.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("gagal StartTLS ke LDAP: %w", err)
+		}
+	}
+	if s.cfg.BindDN != "" {
+		err = conn.Bind(s.cfg.BindDN, s.cfg.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("gagal bind service account LDAP: %w", err)
+	}
+	return conn, nil
+}
+
+func (s *LDAPService) attributes() []string {
+	return []string{s.cfg.AttrID, s.cfg.AttrUsername, s.cfg.AttrEmail, s.cfg.AttrName, s.cfg.AttrGroups, "userAccountControl"}
+}
+
+// Authenticate memeriksa password dengan bind sebagai user lalu
+// mengembalikan user lokal yang sudah diprovision.
+func (s *LDAPService) Authenticate(username, password string) (*models.User, error) {
+	if s == nil {
+		return nil, ErrLDAPDisabled
+	}
+	// Bind dengan password kosong adalah unauthenticated bind yang selalu
+	// berhasil di banyak server, jadi harus ditolak di sini
+	if username == "" || password == "" {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	conn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	filter := strings.ReplaceAll(s.cfg.UserFilter, "{username}", ldap.EscapeFilter(username))
+	res, err := conn.Search(ldap.NewSearchRequest(s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(ldapTimeout/time.Second), false, filter, s.attributes(), nil))
+	if err != nil {
+		return nil, fmt.Errorf("gagal mencari user LDAP: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return nil, ErrLDAPInvalidCredentials
+	}
+	entry := res.Entries[0]
+	if adDisabled(entry) {
+		return nil, ErrUserDisabled
+	}
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrLDAPInvalidCredentials
+		}
+		return nil, fmt.Errorf("gagal bind user LDAP: %w", err)
+	}
+
+	user, _, err := s.provision(entry)
+	return user, err
+}
+
+func (s *LDAPService) identity(entry *ldap.Entry) ExternalIdentity {
+	var groups []string
+	for _, dn := range entry.GetAttributeValues(s.cfg.AttrGroups) {
+		// memberOf berisi DN grup; pemetaan boleh memakai DN lengkap atau CN
+		groups = append(groups, dn)
+		if parsed, err := ldap.ParseDN(dn); err == nil && len(parsed.RDNs) > 0 && len(parsed.RDNs[0].Attributes) > 0 {
+			groups = append(groups, parsed.RDNs[0].Attributes[0].Value)
+		}
+	}
+	return ExternalIdentity{
+		Provider: models.AuthProviderLDAP,
+		Subject:  s.entryID(entry),
+		Email:    entry.GetAttributeValue(s.cfg.AttrEmail),
+		// Email dari directory dianggap milik user; akun staf lokal dengan email
+		// yang sama tetap tidak dikaitkan otomatis (lihat ProvisionExternalUser)
+		EmailVerified: true,
+		Username:      entry.GetAttributeValue(s.cfg.AttrUsername),
+		Name:          entry.GetAttributeValue(s.cfg.AttrName),
+		Groups:        groups,
+	}
+}
+
+// entryID mengembalikan ID stabil entry. objectGUID AD berupa biner sehingga
+// disimpan sebagai hex; DN dipakai bila atribut ID tidak ada.
+func (s *LDAPService) entryID(entry *ldap.Entry) string {
+	raw := entry.GetRawAttributeValue(s.cfg.AttrID)
+	switch {
+	case len(raw) == 0:
+		return strings.ToLower(entry.DN)
+	case utf8.Valid(raw):
+		return string(raw)
+	default:
+		return hex.EncodeToString(raw)
+	}
+}
+
+// provision membuat atau memperbarui user lokal dari entry directory dan
+// mengaktifkan kembali user yang sebelumnya dinonaktifkan sync.
+func (s *LDAPService) provision(entry *ldap.Entry) (*models.User, bool, error) {
+	user, created, err := ProvisionExternalUser(s.identity(entry), s.cfg.Roles)
+	if err != nil {
+		return nil, false, err
+	}
+	if user.IsDisabled() {
+		if err := config.DB.Model(user).Update("disabled_at", nil).Error; err != nil {
+			return nil, false, err
+		}
+		user.DisabledAt = nil
+	}
+	return user, created, nil
+}
+
+func adDisabled(entry *ldap.Entry) bool {
+	flags, err := strconv.Atoi(entry.GetAttributeValue("userAccountControl"))
+	return err == nil && flags&adAccountDisabled != 0
+}
+
+type DirectorySyncReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Entries    int       `json:"entries"`
+	Created    int       `json:"created"`
+	Updated    int       `json:"updated"`
+	Disabled   int       `json:"disabled"`
+	Errors     []string  `json:"errors,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// LastSync mengembalikan hasil sync terakhir sejak server berjalan.
+func (s *LDAPService) LastSync() *DirectorySyncReport {
+	if s == nil {
+		return nil
+	}
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	return s.lastSync
+}
+
+// Sync membuat/memperbarui user untuk setiap entry directory yang cocok
+// dengan SyncFilter, lalu menonaktifkan user LDAP yang sudah tidak ada atau
+// dinonaktifkan di directory.
+func (s *LDAPService) Sync() (*DirectorySyncReport, error) {
+	if s == nil {
+		return nil, ErrLDAPDisabled
+	}
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	report := &DirectorySyncReport{StartedAt: time.Now()}
+	err := s.sync(report)
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	if err != nil {
+		report.Error = err.Error()
+	}
+	s.lastSync = report
+	return report, err
+}
+
+func (s *LDAPService) sync(report *DirectorySyncReport) error {
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := conn.SearchWithPaging(ldap.NewSearchRequest(s.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, s.cfg.SyncFilter, s.attributes(), nil), ldapPageSize)
+	if err != nil {
+		return fmt.Errorf("gagal mencari user LDAP: %w", err)
+	}
+
+	active := []string{}
+	for _, entry := range res.Entries {
+		if adDisabled(entry) {
+			continue
+		}
+		report.Entries++
+		// Entry yang gagal diprovision tetap dianggap aktif agar user lama tidak
+		// ikut dinonaktifkan karena, mis., atribut email sementara kosong
+		active = append(active, s.entryID(entry))
+		_, created, err := s.provision(entry)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", entry.DN, err))
+			continue
+		}
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	// Hasil kosong hampir selalu berarti filter/base DN salah; jangan sampai
+	// semua user LDAP ikut dinonaktifkan
+	if len(active) == 0 {
+		return fmt.Errorf("directory tidak mengembalikan user aktif, user tidak dinonaktifkan")
+	}
+	var missing []uuid.UUID
+	err = config.DB.Model(&models.User{}).
+		Where("auth_provider = ? AND disabled_at IS NULL AND external_id NOT IN ?", models.AuthProviderLDAP, active).
+		Pluck("id", &missing).Error
+	if err != nil {
+		return fmt.Errorf("gagal mencari user yang dinonaktifkan: %w", err)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	disabled := config.DB.Model(&models.User{}).Where("id IN ?", missing).Update("disabled_at", time.Now())
+	if disabled.Error != nil {
+		return fmt.Errorf("gagal menonaktifkan user: %w", disabled.Error)
+	}
+	report.Disabled = int(disabled.RowsAffected)
+	// User yang sedang login langsung keluar, tidak menunggu access token habis
+	if _, err := revokeSessionsWhere(config.DB.Where("user_id IN ?", missing), SessionRevokedDisabled); err != nil {
+		return fmt.Errorf("gagal mencabut sesi user yang dinonaktifkan: %w", err)
+	}
+	return nil
+}
+
+// DirectorySyncWorker menjalankan sync LDAP secara berkala.
+type DirectorySyncWorker struct {
+	Service *LDAPService
+}
+
+func NewDirectorySyncWorker() *DirectorySyncWorker {
+	return &DirectorySyncWorker{Service: LDAP()}
+}
+
+func (w *DirectorySyncWorker) Run(ctx context.Context) {
+	if !w.Service.Enabled() || w.Service.cfg.SyncInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(w.Service.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		report, err := w.Service.Sync()
+		switch {
+		case err != nil:
+			log.Println("Directory sync error:", err)
+		case report.Created+report.Disabled > 0 || len(report.Errors) > 0:
+			log.Printf("Directory sync: %d created, %d updated, %d disabled, %d errors", report.Created, report.Updated, report.Disabled, len(report.Errors))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
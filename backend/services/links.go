@@ -0,0 +1,144 @@
+package services
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Nama link yang dibuat aplikasi. Path setiap link bisa diganti lewat
+// LINK_PATH_<NAMA>, mis. LINK_PATH_END_MEETING=/room-api/end/{token}.
+const (
+	LinkCheckIn       = "check_in"
+	LinkEndMeeting    = "end_meeting"
+	LinkExtendMeeting = "extend_meeting"
+	LinkCalendarFeed  = "calendar_feed"
+	LinkRoom          = "room"
+	LinkAdmin         = "admin"
+	LinkSSOCallback   = "sso_callback"
+)
+
+const (
+	defaultPublicAPIBaseURL = "http://localhost:8080"
+	defaultFrontendURL      = "http://localhost:5173"
+)
+
+// linkTarget adalah path template beserta origin-nya (API atau frontend).
+type linkTarget struct {
+	Frontend bool
+	Path     string
+}
+
+var defaultLinkTargets = map[string]linkTarget{
+	// QR check-in dibuka kamera ponsel (GET), jadi diarahkan ke halaman
+	// frontend yang mengirim POST /api/bookings/check-in/{token}
+	LinkCheckIn:       {Frontend: true, Path: "/check-in/{token}"},
+	LinkEndMeeting:    {Path: "/api/bookings/end/{token}"},
+	LinkExtendMeeting: {Path: "/api/bookings/extend/{token}"},
+	LinkCalendarFeed:  {Path: "/api/calendar/feed/{token}.ics"},
+	LinkRoom:          {Frontend: true, Path: "/room/{id}"},
+	LinkAdmin:         {Frontend: true, Path: "/admin"},
+	LinkSSOCallback:   {Frontend: true, Path: "/auth/callback"},
+}
+
+// LinkBuilder membuat URL publik untuk email, QR code dan feed kalender.
+// Base URL boleh memuat path prefix bila aplikasi berada di belakang
+// reverse proxy, mis. https://example.com/booking.
+type LinkBuilder struct {
+	APIBaseURL      string
+	FrontendBaseURL string
+	targets         map[string]linkTarget
+}
+
+// NewLinkBuilderFromEnv membaca PUBLIC_API_BASE_URL, PUBLIC_FRONTEND_URL dan
+// LINK_PATH_<NAMA>.
+func NewLinkBuilderFromEnv() *LinkBuilder {
+	l := &LinkBuilder{
+		APIBaseURL:      baseURLFromEnv("PUBLIC_API_BASE_URL", defaultPublicAPIBaseURL),
+		FrontendBaseURL: baseURLFromEnv("PUBLIC_FRONTEND_URL", defaultFrontendURL),
+		targets:         make(map[string]linkTarget, len(defaultLinkTargets)),
+	}
+	for name, target := range defaultLinkTargets {
+		if path := os.Getenv("LINK_PATH_" + strings.ToUpper(name)); path != "" {
+			target.Path = path
+		}
+		l.targets[name] = target
+	}
+	return l
+}
+
+func baseURLFromEnv(key, fallback string) string {
+	value := strings.TrimRight(strings.TrimSpace(os.Getenv(key)), "/")
+	if value == "" {
+		return fallback
+	}
+	if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+		log.Printf("Invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+var (
+	linksOnce sync.Once
+	links     *LinkBuilder
+)
+
+// Links mengembalikan LinkBuilder dari environment; dibaca sekali saat pertama dipakai.
+func Links() *LinkBuilder {
+	linksOnce.Do(func() { links = NewLinkBuilderFromEnv() })
+	return links
+}
+
+// URL mengisi path template link name dengan params ({token}, {id}, ...).
+// Nilai parameter di-escape sehingga aman dipakai di path maupun query.
+func (l *LinkBuilder) URL(name string, params map[string]string) string {
+	target, ok := l.targets[name]
+	if !ok {
+		return ""
+	}
+	path := target.Path
+	for key, value := range params {
+		path = strings.ReplaceAll(path, "{"+key+"}", url.QueryEscape(value))
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	base := l.APIBaseURL
+	if target.Frontend {
+		base = l.FrontendBaseURL
+	}
+	return base + path
+}
+
+func (l *LinkBuilder) CheckInURL(token string) string {
+	return l.URL(LinkCheckIn, map[string]string{"token": token})
+}
+
+func (l *LinkBuilder) EndMeetingURL(token string) string {
+	return l.URL(LinkEndMeeting, map[string]string{"token": token})
+}
+
+func (l *LinkBuilder) ExtendMeetingURL(token string) string {
+	return l.URL(LinkExtendMeeting, map[string]string{"token": token})
+}
+
+func (l *LinkBuilder) CalendarFeedURL(token string) string {
+	return l.URL(LinkCalendarFeed, map[string]string{"token": token})
+}
+
+func (l *LinkBuilder) RoomURL(roomID string) string {
+	return l.URL(LinkRoom, map[string]string{"id": roomID})
+}
+
+func (l *LinkBuilder) AdminURL() string {
+	return l.URL(LinkAdmin, nil)
+}
+
+// SSOCallbackURL adalah halaman frontend yang menerima hasil login SSO lewat
+// fragment URL (#token=... atau #error=...).
+func (l *LinkBuilder) SSOCallbackURL() string {
+	return l.URL(LinkSSOCallback, nil)
+}
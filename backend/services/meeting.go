@@ -0,0 +1,151 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultMaxExtensionMinutes = 120
+
+var (
+	ErrMeetingNotStarted = errors.New("meeting belum dimulai")
+	ErrMeetingNotActive  = errors.New("meeting tidak sedang berjalan atau sudah diakhiri")
+)
+
+// MaxExtensionMinutes adalah batas satu kali perpanjangan, diatur lewat
+// MAX_EXTENSION_MINUTES (default 120).
+func MaxExtensionMinutes() int {
+	return int(envMinutes("MAX_EXTENSION_MINUTES", defaultMaxExtensionMinutes) / time.Minute)
+}
+
+// isMeetingActive menandakan booking masih bisa diakhiri atau diperpanjang.
+func isMeetingActive(booking *models.Booking) bool {
+	return booking.EndedAt == nil &&
+		(booking.Status == models.BookingApproved || booking.Status == models.BookingCheckedIn)
+}
+
+// withLockedBooking membaca ulang booking di bawah lock ruangannya lalu
+// menjalankan fn di transaksi yang sama. ErrBookingMoved dikembalikan bila
+// booking dipindah ruangan sebelum lock didapat.
+func withLockedBooking(bookingID uuid.UUID, fn func(tx *gorm.DB, booking *models.Booking) error) (*models.Booking, error) {
+	booking, err := findBooking(config.DB, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	lockedRoomID := booking.RoomID
+	err = withRoomLock([]uuid.UUID{lockedRoomID}, func(tx *gorm.DB) error {
+		if err := tx.First(booking, bookingID).Error; err != nil {
+			return ErrBookingNotFound
+		}
+		if booking.RoomID != lockedRoomID {
+			return ErrBookingMoved
+		}
+		return fn(tx, booking)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// EndMeetingService mengakhiri meeting yang sedang berjalan. Bila diakhiri
+// sebelum EndTime, sisa slot dilepas; bila sudah lewat, lama overtime dicatat.
+func EndMeetingService(bookingID uuid.UUID, actor Actor, now time.Time, notifier *EmailService) (*models.Booking, error) {
+	return withLockedBooking(bookingID, func(tx *gorm.DB, booking *models.Booking) error {
+		if !isMeetingActive(booking) {
+			return ErrMeetingNotActive
+		}
+		if now.Before(booking.StartTime) {
+			return ErrMeetingNotStarted
+		}
+
+		released := now.Before(booking.EndTime)
+		updates := map[string]interface{}{"ended_at": now}
+		if released {
+			booking.EndTime = now
+			booking.Sequence++
+			updates["end_time"] = booking.EndTime
+			updates["sequence"] = booking.Sequence
+		} else {
+			booking.OvertimeMinutes = int(now.Sub(booking.EndTime) / time.Minute)
+			updates["overtime_minutes"] = booking.OvertimeMinutes
+		}
+		if err := tx.Model(booking).Updates(updates).Error; err != nil {
+			return fmt.Errorf("gagal mengakhiri meeting")
+		}
+		booking.EndedAt = &now
+
+		reason := "meeting diakhiri lebih awal"
+		if !released {
+			reason = fmt.Sprintf("meeting diakhiri dengan overtime %d menit", booking.OvertimeMinutes)
+		}
+		if err := TransitionBooking(tx, booking, models.BookingCompleted, actor, reason); err != nil {
+			return err
+		}
+		if released && notifier != nil {
+			var room models.Room
+			if err := tx.First(&room, booking.RoomID).Error; err != nil {
+				return fmt.Errorf("ruangan tidak ditemukan")
+			}
+			return notifier.QueueCalendarUpdate(tx, booking, &room, ICalMethodRequest)
+		}
+		return nil
+	})
+}
+
+// ExtendMeetingService memperpanjang EndTime sebanyak minutes setelah
+// memastikan tidak bentrok dengan booking berikutnya di ruangan yang sama.
+func ExtendMeetingService(bookingID uuid.UUID, minutes int, notifier *EmailService) (*models.Booking, error) {
+	if limit := MaxExtensionMinutes(); minutes <= 0 || minutes > limit {
+		return nil, fmt.Errorf("perpanjangan harus antara 1 dan %d menit", limit)
+	}
+	return withLockedBooking(bookingID, func(tx *gorm.DB, booking *models.Booking) error {
+		if !isMeetingActive(booking) {
+			return ErrMeetingNotActive
+		}
+
+		newEnd := booking.EndTime.Add(time.Duration(minutes) * time.Minute)
+		room, err := validateBookingSlot(tx, booking.RoomID, booking.StartTime, newEnd, booking.Attendees, &booking.ID)
+		if err != nil {
+			return err
+		}
+
+		booking.EndTime = newEnd
+		booking.ExtendedMinutes += minutes
+		booking.Sequence++
+		err = tx.Model(booking).Updates(map[string]interface{}{
+			"end_time":         booking.EndTime,
+			"extended_minutes": booking.ExtendedMinutes,
+			"sequence":         booking.Sequence,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("gagal memperpanjang meeting")
+		}
+		return notifier.QueueCalendarUpdate(tx, booking, room, ICalMethodRequest)
+	})
+}
+
+// EndMeetingByTokenService mengakhiri meeting lewat token QR akhiri meeting.
+func EndMeetingByTokenService(token string, now time.Time, notifier *EmailService) (*models.Booking, error) {
+	booking, _, err := VerifyBookingToken(config.DB, token, TokenActionEnd)
+	if err != nil {
+		return nil, err
+	}
+	return EndMeetingService(booking.ID, Actor{Role: "qr"}, now, notifier)
+}
+
+// ExtendMeetingByTokenService memperpanjang meeting lewat token QR perpanjang
+// meeting. Token akhiri meeting tidak berlaku untuk memperpanjang.
+func ExtendMeetingByTokenService(token string, minutes int, notifier *EmailService) (*models.Booking, error) {
+	booking, _, err := VerifyBookingToken(config.DB, token, TokenActionExtend)
+	if err != nil {
+		return nil, err
+	}
+	return ExtendMeetingService(booking.ID, minutes, notifier)
+}
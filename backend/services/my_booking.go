@@ -0,0 +1,110 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrBookingLocked dikembalikan bila requester mencoba mengubah booking yang
+// sudah dimulai atau berstatus akhir.
+var ErrBookingLocked = errors.New("booking yang sudah dimulai atau selesai tidak bisa diubah")
+
+type MyBookingQuery struct {
+	Status   models.BookingStatus
+	Upcoming bool
+	Limit    int
+	Offset   int
+}
+
+// ListUserBookings mengembalikan booking milik satu akun requester.
+func ListUserBookings(userID uuid.UUID, q MyBookingQuery) ([]models.Booking, int64, error) {
+	query := config.DB.Model(&models.Booking{}).Where("user_id = ?", userID)
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Upcoming {
+		query = query.Where("end_time > ?", time.Now())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	var bookings []models.Booking
+	order := "start_time DESC"
+	if q.Upcoming {
+		order = "start_time"
+	}
+	err := query.Preload("Room", IncludeDeleted).Order(order).Limit(q.Limit).Offset(q.Offset).Find(&bookings).Error
+	return bookings, total, err
+}
+
+// checkSelfServiceEditable memastikan booking masih boleh diubah sendiri oleh
+// pemesannya: pending/approved dan belum dimulai.
+func checkSelfServiceEditable(booking *models.Booking, now time.Time) error {
+	if booking.Status != models.BookingPending && booking.Status != models.BookingApproved {
+		return ErrBookingLocked
+	}
+	if !booking.StartTime.After(now) {
+		return ErrBookingLocked
+	}
+	return nil
+}
+
+// CancelOwnBookingService membatalkan booking oleh pemesannya sendiri.
+// Kepemilikan dicek middleware; di sini hanya aturan statusnya.
+func CancelOwnBookingService(bookingID uuid.UUID, actor Actor, reason string, notifier *EmailService) (*models.Booking, error) {
+	if reason == "" {
+		reason = "dibatalkan oleh pemesan"
+	}
+	return withLockedBooking(bookingID, func(tx *gorm.DB, booking *models.Booking) error {
+		if err := checkSelfServiceEditable(booking, time.Now()); err != nil {
+			return err
+		}
+		oldStatus := booking.Status
+		if err := TransitionBooking(tx, booking, models.BookingCancelled, actor, reason); err != nil {
+			return err
+		}
+		var room models.Room
+		if err := tx.Unscoped().First(&room, booking.RoomID).Error; err != nil {
+			return fmt.Errorf("ruangan tidak ditemukan")
+		}
+		booking.Room = room
+		return notifier.QueueBookingStatusUpdate(tx, booking, &room, oldStatus)
+	})
+}
+
+// RescheduleOwnBookingService memindah jadwal (dan opsional ruangan) booking
+// oleh pemesannya. Pengecekan bentrok sama dengan perubahan oleh admin.
+// Persetujuan hanya berlaku untuk slot yang disetujui, jadi booking approved
+// kembali ke pending dan harus disetujui ulang.
+func RescheduleOwnBookingService(bookingID uuid.UUID, input models.RescheduleBookingInput, actor Actor, notifier *EmailService) (*models.Booking, error) {
+	now := time.Now()
+	if !input.StartTime.After(now) {
+		return nil, fmt.Errorf("jadwal baru harus di masa depan")
+	}
+	update := models.UpdateBookingInput{
+		RoomID:    input.RoomID,
+		StartTime: input.StartTime,
+		EndTime:   input.EndTime,
+	}
+	return updateBooking(bookingID, update, actor, notifier, func(booking *models.Booking, update *models.UpdateBookingInput) error {
+		if err := checkSelfServiceEditable(booking, now); err != nil {
+			return err
+		}
+		if booking.Status == models.BookingApproved {
+			update.Status = models.BookingPending
+			update.Reason = "jadwal diubah pemesan, perlu disetujui ulang"
+		}
+		return nil
+	})
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	NotificationBookingCreated      = "booking_created"
+	NotificationBookingCreatedAdmin = "booking_created_admin"
+	NotificationBookingStatus       = "booking_status"
+	NotificationCalendarUpdate      = "calendar_update"
+	NotificationPasswordOTP         = "password_otp"
+	NotificationBookingReminder     = "booking_reminder"
+
+	defaultNotificationMaxAttempts = 8
+	notificationBaseBackoff        = 30 * time.Second
+	notificationMaxBackoff         = time.Hour
+	// Lama sebuah batch dianggap sedang dikirim; bila worker mati di tengah
+	// pengiriman, notifikasi akan diambil lagi setelah lease habis
+	notificationLease = 5 * time.Minute
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notifikasi tidak ditemukan")
+	ErrNotificationSecret   = errors.New("notifikasi berisi OTP tidak bisa dikirim ulang, minta OTP baru")
+)
+
+// secretNotificationKinds adalah jenis notifikasi yang payload-nya memuat
+// rahasia. Payload-nya dihapus begitu notifikasi tidak akan dikirim lagi
+// (terkirim, dead atau dilewati) dan notifikasi tersebut tidak bisa dikirim
+// ulang.
+var secretNotificationKinds = []string{NotificationPasswordOTP}
+
+func isSecretNotification(kind string) bool {
+	for _, k := range secretNotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue menyimpan pesan ke outbox lewat tx sehingga ikut di-commit atau
+// di-rollback bersama perubahan yang memicunya.
+func (es *EmailService) enqueue(tx *gorm.DB, kind string, bookingID *uuid.UUID, message *EmailMessage) (*models.Notification, error) {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan notifikasi: %w", err)
+	}
+	recipients := make([]string, 0, len(message.To))
+	for _, to := range message.To {
+		recipients = append(recipients, to.Address)
+	}
+	notification := models.Notification{
+		Kind:      kind,
+		BookingID: bookingID,
+		Recipient: strings.Join(recipients, ", "),
+		Subject:   message.Subject,
+		Payload:   string(payload),
+	}
+	if err := tx.Create(&notification).Error; err != nil {
+		return nil, fmt.Errorf("gagal menyimpan notifikasi")
+	}
+	return &notification, nil
+}
+
+// QueueBookingCreated mengantrekan konfirmasi ke pemesan dan pemberitahuan ke
+// setiap alamat di ADMIN_EMAIL (dipisah koma).
+func (es *EmailService) QueueBookingCreated(tx *gorm.DB, booking *models.Booking, room *models.Room) error {
+	if es == nil {
+		return nil
+	}
+	if err := es.QueueBookingNotification(tx, booking, room); err != nil {
+		return err
+	}
+	for _, adminEmail := range strings.Split(os.Getenv("ADMIN_EMAIL"), ",") {
+		adminEmail = strings.TrimSpace(adminEmail)
+		if adminEmail == "" {
+			continue
+		}
+		if err := es.QueueBookingNotificationToAdmin(tx, booking, room, adminEmail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bookingCheckInQR membuat URL dan QR check-in yang dipindai di ruangan;
+// hanya untuk booking approved.
+func bookingCheckInQR(booking *models.Booking) (string, []byte) {
+	if booking.Status != models.BookingApproved {
+		return "", nil
+	}
+	checkInURL, err := BookingActionURL(booking, TokenActionCheckIn)
+	if err != nil {
+		return "", nil
+	}
+	qr, err := qrcode.Encode(checkInURL, qrcode.Medium, 256)
+	if err != nil {
+		return "", nil
+	}
+	return checkInURL, qr
+}
+
+// NotificationWorker mengirim notifikasi dari outbox. Pengiriman gagal dicoba
+// lagi dengan backoff eksponensial; setelah MaxAttempts notifikasi masuk
+// status dead dan hanya dikirim ulang lewat admin.
+type NotificationWorker struct {
+	Email       *EmailService
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+}
+
+// NewNotificationWorker membaca NOTIFICATION_MAX_ATTEMPTS dari environment.
+func NewNotificationWorker(email *EmailService) *NotificationWorker {
+	maxAttempts := defaultNotificationMaxAttempts
+	if v, err := strconv.Atoi(os.Getenv("NOTIFICATION_MAX_ATTEMPTS")); err == nil && v > 0 {
+		maxAttempts = v
+	}
+	return &NotificationWorker{Email: email, Interval: 10 * time.Second, BatchSize: 50, MaxAttempts: maxAttempts}
+}
+
+func (w *NotificationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.ProcessBatch(); err != nil {
+			log.Println("Notification worker error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch mengambil notifikasi yang sudah jatuh tempo dan mengirimnya.
+// Baris diklaim dengan SKIP LOCKED agar beberapa instance bisa berjalan bersamaan.
+func (w *NotificationWorker) ProcessBatch() (int, error) {
+	var batch []models.Notification
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.NotificationPending, now).
+			Order("next_attempt_at").Limit(w.BatchSize).
+			Find(&batch).Error
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, 0, len(batch))
+		for _, n := range batch {
+			ids = append(ids, n.ID)
+		}
+		return tx.Model(&models.Notification{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(notificationLease)).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range batch {
+		if w.deliver(&batch[i]) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// staleNotificationReason memeriksa ulang notifikasi sebelum dikirim dan
+// mengembalikan alasan bila isinya sudah tidak berlaku.
+func staleNotificationReason(n *models.Notification) string {
+	switch n.Kind {
+	case NotificationBookingReminder:
+		return staleReminderReason(n)
+	case NotificationPasswordOTP:
+		if time.Now().After(n.CreatedAt.Add(ResetOTPValidity)) {
+			return "OTP sudah kedaluwarsa"
+		}
+	}
+	return ""
+}
+
+func (w *NotificationWorker) deliver(n *models.Notification) bool {
+	if reason := staleNotificationReason(n); reason != "" {
+		updates := map[string]interface{}{"status": models.NotificationSkipped, "last_error": reason}
+		if isSecretNotification(n.Kind) {
+			updates["payload"] = ""
+		}
+		config.DB.Model(&models.Notification{}).Where("id = ?", n.ID).Updates(updates)
+		log.Printf("Notification %s (%s) skipped: %s", n.ID, n.Kind, reason)
+		return false
+	}
+
+	var message EmailMessage
+	sendErr := json.Unmarshal([]byte(n.Payload), &message)
+	if sendErr == nil {
+		sendErr = w.Email.transport.Send(&message)
+	}
+
+	attempts := n.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	if sendErr == nil {
+		now := time.Now()
+		updates["status"] = models.NotificationSent
+		updates["sent_at"] = &now
+		updates["last_error"] = ""
+		log.Printf("Notification %s (%s) sent to %s", n.ID, n.Kind, n.Recipient)
+	} else {
+		updates["last_error"] = sendErr.Error()
+		if attempts >= w.MaxAttempts {
+			updates["status"] = models.NotificationDead
+			log.Printf("Notification %s (%s) to %s dead after %d attempts: %v", n.ID, n.Kind, n.Recipient, attempts, sendErr)
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(notificationBackoff(attempts))
+			log.Printf("Notification %s (%s) to %s failed (attempt %d): %v", n.ID, n.Kind, n.Recipient, attempts, sendErr)
+		}
+	}
+	if updates["status"] != nil && isSecretNotification(n.Kind) {
+		updates["payload"] = ""
+	}
+	if err := config.DB.Model(&models.Notification{}).Where("id = ?", n.ID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update notification %s: %v", n.ID, err)
+	}
+	return sendErr == nil
+}
+
+// notificationBackoff: 30 detik, 1 menit, 2 menit, ... maksimal 1 jam.
+func notificationBackoff(attempts int) time.Duration {
+	d := notificationBaseBackoff
+	for i := 1; i < attempts && d < notificationMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > notificationMaxBackoff {
+		d = notificationMaxBackoff
+	}
+	return d
+}
+
+type NotificationQuery struct {
+	Status    models.NotificationStatus
+	Kind      string
+	BookingID *uuid.UUID
+	Limit     int
+	Offset    int
+}
+
+func ListNotifications(q NotificationQuery) ([]models.Notification, int64, error) {
+	query := config.DB.Model(&models.Notification{})
+	if q.Status != "" {
+		query = query.Where("status = ?", q.Status)
+	}
+	if q.Kind != "" {
+		query = query.Where("kind = ?", q.Kind)
+	}
+	if q.BookingID != nil {
+		query = query.Where("booking_id = ?", q.BookingID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if q.Limit <= 0 || q.Limit > 200 {
+		q.Limit = 50
+	}
+	var notifications []models.Notification
+	err := query.Order("created_at DESC").Limit(q.Limit).Offset(q.Offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// RetryNotification mengembalikan notifikasi yang dead (atau masih pending)
+// ke antrean dengan jumlah percobaan direset. Notifikasi berisi rahasia
+// seperti OTP tidak bisa dikirim ulang.
+func RetryNotification(id uuid.UUID) (*models.Notification, error) {
+	var notification models.Notification
+	if err := config.DB.First(&notification, id).Error; err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	if notification.Status == models.NotificationSent {
+		return nil, fmt.Errorf("notifikasi sudah terkirim")
+	}
+	if isSecretNotification(notification.Kind) {
+		return nil, ErrNotificationSecret
+	}
+	err := config.DB.Model(&notification).Updates(map[string]interface{}{
+		"status":          models.NotificationPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengantrekan ulang notifikasi")
+	}
+	return &notification, nil
+}
+
+// RetryDeadNotifications mengantrekan ulang semua notifikasi dead kecuali
+// yang berisi rahasia.
+func RetryDeadNotifications() (int64, error) {
+	result := config.DB.Model(&models.Notification{}).
+		Where("status = ? AND kind NOT IN ?", models.NotificationDead, secretNotificationKinds).
+		Updates(map[string]interface{}{
+			"status":          models.NotificationPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// RedactSecretNotifications menghapus payload notifikasi berisi rahasia yang
+// tidak akan dikirim lagi, termasuk yang tersimpan sebelum penghapusan
+// otomatis di worker ada.
+func RedactSecretNotifications() error {
+	return config.DB.Model(&models.Notification{}).
+		Where("kind IN ? AND status <> ? AND payload <> ?", secretNotificationKinds, models.NotificationPending, "").
+		Update("payload", "").Error
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrOIDCDisabled = errors.New("login SSO belum dikonfigurasi")
+	ErrOIDCState    = errors.New("sesi login SSO tidak valid atau sudah kedaluwarsa")
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCStateTTL adalah masa berlaku state login, dipakai juga untuk cookie-nya.
+func OIDCStateTTL() time.Duration {
+	return oidcStateTTL
+}
+
+// OIDCConfig adalah konfigurasi identity provider untuk login SSO.
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// RoleClaim adalah claim berisi grup/role user, boleh berupa path bertitik
+	// seperti realm_access.roles (Keycloak).
+	RoleClaim string
+	Roles     RoleMapping
+}
+
+// LoadOIDCConfigFromEnv membaca OIDC_ISSUER_URL, OIDC_CLIENT_ID,
+// OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL, OIDC_SCOPES, OIDC_ROLE_CLAIM,
+// OIDC_ROLE_MAP, OIDC_DEFAULT_ROLE dan OIDC_ROLE_SYNC. SSO nonaktif bila
+// OIDC_ISSUER_URL kosong.
+func LoadOIDCConfigFromEnv() (OIDCConfig, error) {
+	cfg := OIDCConfig{
+		IssuerURL:    strings.TrimSpace(os.Getenv("OIDC_ISSUER_URL")),
+		ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret: config.Secret("OIDC_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		RoleClaim:    os.Getenv("OIDC_ROLE_CLAIM"),
+	}
+	if cfg.IssuerURL == "" {
+		return cfg, nil
+	}
+	if cfg.ClientID == "" {
+		return cfg, fmt.Errorf("OIDC_CLIENT_ID wajib diisi")
+	}
+	if cfg.RedirectURL == "" {
+		cfg.RedirectURL = Links().APIBaseURL + "/api/auth/oidc/callback"
+	}
+	if v := os.Getenv("OIDC_SCOPES"); v != "" {
+		cfg.Scopes = []string{oidc.ScopeOpenID}
+		for _, scope := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if scope != oidc.ScopeOpenID {
+				cfg.Scopes = append(cfg.Scopes, scope)
+			}
+		}
+	}
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "groups"
+	}
+	roleSync := true
+	if v, err := strconv.ParseBool(os.Getenv("OIDC_ROLE_SYNC")); err == nil {
+		roleSync = v
+	}
+	roles, err := ParseRoleMapping(os.Getenv("OIDC_ROLE_MAP"), os.Getenv("OIDC_DEFAULT_ROLE"), roleSync)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Roles = roles
+	return cfg, nil
+}
+
+// OIDCService menjalankan authorization code flow dengan PKCE. Discovery ke
+// identity provider dilakukan saat login pertama sehingga aplikasi tetap bisa
+// start walaupun provider sedang tidak bisa dihubungi.
+type OIDCService struct {
+	cfg OIDCConfig
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	provider *oidc.Provider
+}
+
+func NewOIDCService(cfg OIDCConfig) *OIDCService {
+	return &OIDCService{cfg: cfg}
+}
+
+var (
+	oidcOnce    sync.Once
+	oidcService *OIDCService
+)
+
+// OIDC mengembalikan OIDCService dari environment, atau nil bila SSO tidak
+// dikonfigurasi. Method OIDCService aman dipanggil pada nil.
+func OIDC() *OIDCService {
+	oidcOnce.Do(func() {
+		cfg, err := LoadOIDCConfigFromEnv()
+		if err != nil {
+			log.Printf("SSO dinonaktifkan: %v", err)
+			return
+		}
+		if cfg.IssuerURL != "" {
+			oidcService = NewOIDCService(cfg)
+		}
+	})
+	return oidcService
+}
+
+func (s *OIDCService) setup(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, *oidc.Provider, error) {
+	if s == nil {
+		return nil, nil, nil, ErrOIDCDisabled
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider == nil {
+		provider, err := oidc.NewProvider(ctx, s.cfg.IssuerURL)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("gagal menghubungi identity provider: %w", err)
+		}
+		s.provider = provider
+		s.verifier = provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID})
+		s.oauth = &oauth2.Config{
+			ClientID:     s.cfg.ClientID,
+			ClientSecret: s.cfg.ClientSecret,
+			RedirectURL:  s.cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       s.cfg.Scopes,
+		}
+	}
+	return s.oauth, s.verifier, s.provider, nil
+}
+
+// BeginLogin menyimpan state login dan mengembalikan URL authorize identity
+// provider beserta state-nya. redirectPath adalah halaman frontend tujuan
+// setelah login. Pemanggil harus mengikat state ke browser (cookie) agar
+// callback bisa memastikan login dimulai dari browser yang sama.
+func (s *OIDCService) BeginLogin(ctx context.Context, redirectPath string) (string, string, error) {
+	oauth, _, _, err := s.setup(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := newSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := newSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	loginState := models.OIDCLoginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		RedirectPath: SafeRedirectPath(redirectPath),
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}
+	config.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+	if err := config.DB.Create(&loginState).Error; err != nil {
+		return "", "", fmt.Errorf("gagal menyimpan sesi login SSO")
+	}
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(loginState.CodeVerifier)), state, nil
+}
+
+// CompleteLogin menukar authorization code, memverifikasi ID token lalu
+// mengembalikan user yang sudah diprovision beserta halaman tujuan.
+// browserState adalah state yang tersimpan di cookie browser; harus sama
+// dengan state dari identity provider untuk mencegah login CSRF.
+func (s *OIDCService) CompleteLogin(ctx context.Context, state, browserState, code string) (*models.User, string, error) {
+	oauth, verifier, provider, err := s.setup(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var loginState models.OIDCLoginState
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 || config.DB.First(&loginState, "state = ?", state).Error != nil {
+		return nil, "", ErrOIDCState
+	}
+	// State hanya boleh dipakai sekali walaupun callback dipanggil bersamaan
+	if res := config.DB.Delete(&models.OIDCLoginState{}, "state = ?", state); res.Error != nil || res.RowsAffected == 0 {
+		return nil, "", ErrOIDCState
+	}
+	if time.Now().After(loginState.ExpiresAt) {
+		return nil, "", ErrOIDCState
+	}
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(loginState.CodeVerifier))
+	if err != nil {
+		return nil, "", fmt.Errorf("gagal menukar authorization code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, "", fmt.Errorf("identity provider tidak mengirim id_token")
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, "", fmt.Errorf("id_token tidak valid: %w", err)
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return nil, "", fmt.Errorf("nonce id_token tidak cocok")
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, "", fmt.Errorf("gagal membaca claim id_token: %w", err)
+	}
+	// Sebagian provider hanya mengirim email/grup lewat endpoint userinfo
+	if claimString(claims, "email") == "" || claimValue(claims, s.cfg.RoleClaim) == nil {
+		if info, err := provider.UserInfo(ctx, oauth2.StaticTokenSource(token)); err == nil {
+			extra := map[string]interface{}{}
+			if info.Claims(&extra) == nil {
+				for k, v := range extra {
+					if _, exists := claims[k]; !exists {
+						claims[k] = v
+					}
+				}
+			}
+		}
+	}
+
+	identity := ExternalIdentity{
+		Provider:      models.AuthProviderOIDC,
+		Subject:       idToken.Subject,
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, "email_verified"),
+		Username:      claimString(claims, "preferred_username"),
+		Name:          claimString(claims, "name"),
+		Groups:        claimStrings(claims, s.cfg.RoleClaim),
+	}
+	user, _, err := ProvisionExternalUser(identity, s.cfg.Roles)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, loginState.RedirectPath, nil
+}
+
+// SafeRedirectPath hanya menerima path relatif agar parameter redirect tidak
+// bisa dipakai untuk mengarahkan user ke situs lain.
+func SafeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+// claimValue membaca claim, termasuk claim bertingkat seperti realm_access.roles.
+func claimValue(claims map[string]interface{}, path string) interface{} {
+	var current interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+func claimString(claims map[string]interface{}, path string) string {
+	s, _ := claimValue(claims, path).(string)
+	return s
+}
+
+func claimBool(claims map[string]interface{}, path string) bool {
+	switch v := claimValue(claims, path).(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// claimStrings menerima claim berupa array atau string dipisah spasi/koma.
+func claimStrings(claims map[string]interface{}, path string) []string {
+	switch v := claimValue(claims, path).(type) {
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
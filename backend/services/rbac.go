@@ -0,0 +1,222 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound   = errors.New("user tidak ditemukan")
+	ErrInvalidRole    = errors.New("role tidak dikenal")
+	ErrLastSuperAdmin = errors.New("minimal harus ada satu super admin")
+	ErrUserDisabled   = errors.New("akun dinonaktifkan")
+)
+
+// Access adalah role dan cakupan ruangan user yang sedang login. Dibaca dari
+// database setiap request sehingga perubahan role langsung berlaku tanpa
+// menunggu token kedaluwarsa.
+type Access struct {
+	UserID uuid.UUID
+	Role   string
+	// RoomIDs hanya dipakai untuk role yang dibatasi per ruangan
+	RoomIDs map[uuid.UUID]bool
+}
+
+// LoadAccess membaca role dan ruangan yang di-assign ke user.
+func LoadAccess(userID uuid.UUID) (*Access, error) {
+	var user models.User
+	if err := config.DB.Select("id", "role", "disabled_at").First(&user, userID).Error; err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsDisabled() {
+		return nil, ErrUserDisabled
+	}
+	access := &Access{UserID: user.ID, Role: models.NormalizeRole(user.Role)}
+	if models.IsRoomScopedRole(access.Role) {
+		var roomIDs []uuid.UUID
+		if err := config.DB.Model(&models.RoomAssignment{}).Where("user_id = ?", userID).Pluck("room_id", &roomIDs).Error; err != nil {
+			return nil, fmt.Errorf("gagal membaca ruangan user: %w", err)
+		}
+		access.RoomIDs = make(map[uuid.UUID]bool, len(roomIDs))
+		for _, id := range roomIDs {
+			access.RoomIDs[id] = true
+		}
+	}
+	return access, nil
+}
+
+// Can menandakan role punya permission, untuk minimal satu ruangan bila role
+// dibatasi per ruangan.
+func (a *Access) Can(perm models.Permission) bool {
+	return a != nil && models.RoleHasPermission(a.Role, perm)
+}
+
+// CanForRoom menandakan permission berlaku untuk semua ruangan yang diberikan.
+func (a *Access) CanForRoom(perm models.Permission, roomIDs ...uuid.UUID) bool {
+	if !a.Can(perm) {
+		return false
+	}
+	if !models.IsRoomScopedRole(a.Role) {
+		return true
+	}
+	for _, id := range roomIDs {
+		if !a.RoomIDs[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// ScopedRoomIDs mengembalikan ruangan yang boleh diakses, atau nil bila
+// aksesnya tidak dibatasi per ruangan.
+func (a *Access) ScopedRoomIDs() []uuid.UUID {
+	if !models.IsRoomScopedRole(a.Role) {
+		return nil
+	}
+	ids := make([]uuid.UUID, 0, len(a.RoomIDs))
+	for id := range a.RoomIDs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// MigrateLegacyAdminRoles mengganti role lama "admin" menjadi super_admin.
+func MigrateLegacyAdminRoles() error {
+	return config.DB.Model(&models.User{}).Where("role = ?", models.RoleAdmin).
+		Update("role", models.RoleSuperAdmin).Error
+}
+
+type UserWithRooms struct {
+	models.User
+	Permissions []models.Permission `json:"permissions"`
+	RoomIDs     []uuid.UUID         `json:"room_ids"`
+}
+
+// ListUsers mengembalikan user beserta ruangan yang di-assign. role kosong
+// berarti semua role.
+func ListUsers(role string) ([]UserWithRooms, error) {
+	query := config.DB.Order("username")
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	var assignments []models.RoomAssignment
+	if err := config.DB.Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	rooms := make(map[uuid.UUID][]uuid.UUID)
+	for _, a := range assignments {
+		rooms[a.UserID] = append(rooms[a.UserID], a.RoomID)
+	}
+	result := make([]UserWithRooms, 0, len(users))
+	for _, u := range users {
+		roomIDs := rooms[u.ID]
+		if roomIDs == nil {
+			roomIDs = []uuid.UUID{}
+		}
+		result = append(result, UserWithRooms{User: u, Permissions: models.RolePermissions(u.Role), RoomIDs: roomIDs})
+	}
+	return result, nil
+}
+
+// AssignRole mengganti role user dan, untuk room manager/approver, daftar
+// ruangannya. Role lain tidak menyimpan ruangan.
+func AssignRole(userID uuid.UUID, role string, roomIDs []uuid.UUID) (*UserWithRooms, error) {
+	var user models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return ErrUserNotFound
+		}
+		var err error
+		roomIDs, err = assignRole(tx, &user, role, roomIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserWithRooms{User: user, Permissions: models.RolePermissions(user.Role), RoomIDs: roomIDs}, nil
+}
+
+// CreateStaffUser membuat user staf beserta ruangan yang di-assign dalam satu
+// transaksi.
+func CreateStaffUser(user *models.User, roomIDs []uuid.UUID) (*UserWithRooms, error) {
+	role := models.NormalizeRole(user.Role)
+	if !models.IsStaffRole(role) {
+		return nil, ErrInvalidRole
+	}
+	user.Role = role
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		var err error
+		roomIDs, err = assignRole(tx, user, role, roomIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &UserWithRooms{User: *user, Permissions: models.RolePermissions(user.Role), RoomIDs: roomIDs}, nil
+}
+
+func assignRole(tx *gorm.DB, user *models.User, role string, roomIDs []uuid.UUID) ([]uuid.UUID, error) {
+	role = models.NormalizeRole(role)
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	roomIDs = uniqueUUIDs(roomIDs)
+	if !models.IsRoomScopedRole(role) {
+		roomIDs = []uuid.UUID{}
+	}
+
+	if models.NormalizeRole(user.Role) == models.RoleSuperAdmin && role != models.RoleSuperAdmin {
+		var others int64
+		tx.Model(&models.User{}).Where("role IN ? AND id <> ?", []string{models.RoleSuperAdmin, models.RoleAdmin}, user.ID).Count(&others)
+		if others == 0 {
+			return nil, ErrLastSuperAdmin
+		}
+	}
+	if len(roomIDs) > 0 {
+		var found int64
+		if err := tx.Model(&models.Room{}).Where("id IN ?", roomIDs).Count(&found).Error; err != nil {
+			return nil, fmt.Errorf("gagal memeriksa ruangan")
+		}
+		if int(found) != len(roomIDs) {
+			return nil, ErrRoomNotFound
+		}
+	}
+
+	if err := tx.Model(user).Update("role", role).Error; err != nil {
+		return nil, fmt.Errorf("gagal menyimpan role")
+	}
+	user.Role = role
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.RoomAssignment{}).Error; err != nil {
+		return nil, fmt.Errorf("gagal menyimpan ruangan user")
+	}
+	for _, roomID := range roomIDs {
+		if err := tx.Create(&models.RoomAssignment{UserID: user.ID, RoomID: roomID}).Error; err != nil {
+			return nil, fmt.Errorf("gagal menyimpan ruangan user")
+		}
+	}
+	return roomIDs, nil
+}
+
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	result := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
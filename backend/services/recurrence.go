@@ -0,0 +1,249 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Batas jumlah kemunculan dalam satu seri agar request tidak membuat ribuan booking.
+const maxSeriesOccurrences = 366
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceRule adalah subset RRULE RFC 5545 yang didukung:
+// FREQ (DAILY/WEEKLY/MONTHLY), INTERVAL, BYDAY (hanya untuk WEEKLY), COUNT dan UNTIL.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    *time.Time
+	// untilFloating: UNTIL ditulis tanpa akhiran Z sehingga jam dinding Until
+	// berlaku di zona waktu DTSTART, bukan UTC.
+	untilFloating bool
+}
+
+// ParseRecurrenceRule mem-parsing string seperti "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=8".
+// Prefix "RRULE:" boleh disertakan.
+func ParseRecurrenceRule(value string) (*RecurrenceRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("aturan pengulangan kosong")
+	}
+
+	rule := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bagian RRULE tidak valid: %s", part)
+		}
+		key, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		switch key {
+		case "FREQ":
+			if val != FreqDaily && val != FreqWeekly && val != FreqMonthly {
+				return nil, fmt.Errorf("FREQ %s tidak didukung", val)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL tidak valid")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT tidak valid")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, floating, err := parseRRuleTime(val)
+			if err != nil {
+				return nil, fmt.Errorf("UNTIL tidak valid")
+			}
+			rule.Until, rule.untilFloating = &until, floating
+		case "BYDAY":
+			for _, d := range strings.Split(val, ",") {
+				wd, ok := rruleWeekdays[d]
+				if !ok {
+					return nil, fmt.Errorf("BYDAY %s tidak valid", d)
+				}
+				rule.ByDay = append(rule.ByDay, wd)
+			}
+		case "WKST":
+			// Minggu selalu dihitung mulai Senin
+		default:
+			return nil, fmt.Errorf("properti RRULE %s tidak didukung", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("FREQ wajib diisi")
+	}
+	if rule.Count == 0 && rule.Until == nil {
+		return nil, fmt.Errorf("RRULE harus memiliki COUNT atau UNTIL")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("COUNT dan UNTIL tidak boleh dipakai bersamaan")
+	}
+	if rule.Count > maxSeriesOccurrences {
+		return nil, fmt.Errorf("COUNT maksimal %d", maxSeriesOccurrences)
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly {
+		return nil, fmt.Errorf("BYDAY hanya didukung untuk FREQ=WEEKLY")
+	}
+	return rule, nil
+}
+
+// parseRRuleTime mem-parsing nilai UNTIL. floating bernilai true bila nilai
+// tidak diakhiri Z; jam dindingnya dikembalikan dalam UTC dan baru ditetapkan
+// ke zona waktu DTSTART oleh anchorUntil.
+func parseRRuleTime(val string) (t time.Time, floating bool, err error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, val); err == nil {
+			if layout == "20060102" {
+				// UNTIL berupa tanggal mencakup seluruh hari tersebut
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, layout != "20060102T150405Z", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("format waktu tidak dikenal: %s", val)
+}
+
+// anchorUntil menetapkan UNTIL tanpa zona waktu ke loc, biasanya zona waktu
+// DTSTART. UNTIL yang sudah absolut (berakhiran Z) tidak berubah.
+func (r *RecurrenceRule) anchorUntil(loc *time.Location) {
+	if r.Until == nil || !r.untilFloating {
+		return
+	}
+	u := *r.Until
+	until := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, loc)
+	r.Until, r.untilFloating = &until, false
+}
+
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, wd := range r.ByDay {
+			for code, d := range rruleWeekdays {
+				if d == wd {
+					days = append(days, code)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil && r.untilFloating {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	} else if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences mengembalikan waktu mulai setiap kemunculan, dimulai dari start.
+// Jam lokal start dipertahankan pada setiap kemunculan dan UNTIL tanpa zona
+// waktu dibaca di zona waktu start. UNTIL yang menghasilkan lebih dari
+// maxSeriesOccurrences kemunculan ditolak, bukan dipotong diam-diam.
+func (r *RecurrenceRule) Occurrences(start time.Time) ([]time.Time, error) {
+	anchored := *r
+	anchored.anchorUntil(start.Location())
+	r = &anchored
+
+	var result []time.Time
+	accept := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		result = append(result, t)
+		return !r.done(len(result))
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		for i := 0; ; i++ {
+			if !accept(start.AddDate(0, 0, i*r.Interval)) {
+				break
+			}
+		}
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		offsets := make([]int, 0, len(days))
+		for _, d := range days {
+			offsets = append(offsets, (int(d)+6)%7) // Senin = 0
+		}
+		sort.Ints(offsets)
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	weeks:
+		for w := 0; ; w++ {
+			for _, off := range offsets {
+				t := weekStart.AddDate(0, 0, w*7*r.Interval+off)
+				if t.Before(start) {
+					continue
+				}
+				if !accept(t) {
+					break weeks
+				}
+			}
+		}
+	case FreqMonthly:
+		for i := 0; ; i++ {
+			y, m, _ := start.Date()
+			t := time.Date(y, m+time.Month(i*r.Interval), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+			// Bulan tanpa tanggal tersebut (mis. 31) dilewati sesuai RFC 5545
+			if t.Day() != start.Day() {
+				if r.Until != nil && t.After(*r.Until) {
+					break
+				}
+				continue
+			}
+			if !accept(t) {
+				break
+			}
+		}
+	}
+	if len(result) > maxSeriesOccurrences {
+		return nil, fmt.Errorf("UNTIL menghasilkan lebih dari %d kemunculan", maxSeriesOccurrences)
+	}
+	return result, nil
+}
+
+// done menghentikan perulangan. Untuk UNTIL perulangan berhenti satu
+// kemunculan setelah batas agar kelebihannya terdeteksi.
+func (r *RecurrenceRule) done(n int) bool {
+	if r.Count > 0 {
+		return n >= r.Count
+	}
+	return n > maxSeriesOccurrences
+}
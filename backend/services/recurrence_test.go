@@ -0,0 +1,168 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string // hasil String(); kosong bila wantErr
+		wantErr bool
+	}{
+		{value: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=8", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=8"},
+		{value: "freq=daily;count=2", want: "FREQ=DAILY;COUNT=2"},
+		{value: "FREQ=MONTHLY;WKST=MO;COUNT=3", want: "FREQ=MONTHLY;COUNT=3"},
+		{value: "FREQ=DAILY;UNTIL=20260304T050000Z", want: "FREQ=DAILY;UNTIL=20260304T050000Z"},
+		{value: "FREQ=DAILY;UNTIL=20260304T050000", want: "FREQ=DAILY;UNTIL=20260304T050000"},
+		{value: "FREQ=DAILY;COUNT=366", want: "FREQ=DAILY;COUNT=366"},
+		{value: "", wantErr: true},
+		{value: "FREQ=YEARLY;COUNT=2", wantErr: true},
+		{value: "FREQ=DAILY", wantErr: true},
+		{value: "COUNT=2", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=2;UNTIL=20260301", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=367", wantErr: true},
+		{value: "FREQ=DAILY;COUNT=0", wantErr: true},
+		{value: "FREQ=DAILY;INTERVAL=0;COUNT=2", wantErr: true},
+		{value: "FREQ=DAILY;BYDAY=MO;COUNT=2", wantErr: true},
+		{value: "FREQ=WEEKLY;BYDAY=XX;COUNT=2", wantErr: true},
+		{value: "FREQ=DAILY;UNTIL=besok", wantErr: true},
+		{value: "FREQ=DAILY;BYMONTH=1;COUNT=2", wantErr: true},
+		{value: "FREQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRecurrenceRule(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && rule.String() != tt.want {
+			t.Errorf("ParseRecurrenceRule(%q).String() = %q, want %q", tt.value, rule.String(), tt.want)
+		}
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*3600)
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 9, 0, 0, 0, wib)
+	}
+	// 2 Maret 2026 adalah hari Senin
+	monday := at(time.March, 2)
+
+	tests := []struct {
+		name    string
+		rule    string
+		start   time.Time
+		want    []time.Time
+		wantLen int // dipakai bila want terlalu panjang untuk ditulis
+		wantErr bool
+	}{
+		{
+			name:  "daily interval",
+			rule:  "FREQ=DAILY;INTERVAL=3;COUNT=3",
+			start: monday,
+			want:  []time.Time{at(time.March, 2), at(time.March, 5), at(time.March, 8)},
+		},
+		{
+			name:  "weekly defaults to start weekday",
+			rule:  "FREQ=WEEKLY;COUNT=2",
+			start: monday,
+			want:  []time.Time{at(time.March, 2), at(time.March, 9)},
+		},
+		{
+			name:  "weekly byday skips days before start",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			start: at(time.March, 4),
+			want:  []time.Time{at(time.March, 4), at(time.March, 9), at(time.March, 11), at(time.March, 16)},
+		},
+		{
+			name:  "weekly byday with interval",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=3",
+			start: monday,
+			want:  []time.Time{at(time.March, 3), at(time.March, 17), at(time.March, 31)},
+		},
+		{
+			name:  "monthly skips months without day 31",
+			rule:  "FREQ=MONTHLY;COUNT=4",
+			start: at(time.January, 31),
+			want:  []time.Time{at(time.January, 31), at(time.March, 31), at(time.May, 31), at(time.July, 31)},
+		},
+		{
+			name:  "monthly until stops on skipped month",
+			rule:  "FREQ=MONTHLY;UNTIL=20260415",
+			start: at(time.January, 31),
+			want:  []time.Time{at(time.January, 31), at(time.March, 31)},
+		},
+		{
+			name:  "utc until",
+			rule:  "FREQ=DAILY;UNTIL=20260304T050000Z",
+			start: monday,
+			want:  []time.Time{at(time.March, 2), at(time.March, 3), at(time.March, 4)},
+		},
+		{
+			// 05:00 jam dinding di zona waktu start, sebelum meeting 09:00 WIB
+			name:  "floating until uses start zone",
+			rule:  "FREQ=DAILY;UNTIL=20260304T050000",
+			start: monday,
+			want:  []time.Time{at(time.March, 2), at(time.March, 3)},
+		},
+		{
+			name:  "date until includes whole day",
+			rule:  "FREQ=DAILY;UNTIL=20260304",
+			start: monday,
+			want:  []time.Time{at(time.March, 2), at(time.March, 3), at(time.March, 4)},
+		},
+		{
+			name:    "until at occurrence limit",
+			rule:    "FREQ=DAILY;UNTIL=20270302",
+			start:   monday,
+			wantLen: maxSeriesOccurrences,
+		},
+		{
+			name:    "until past occurrence limit",
+			rule:    "FREQ=DAILY;UNTIL=20270303",
+			start:   monday,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q): %v", tt.rule, err)
+			}
+			got, err := rule.Occurrences(tt.start)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Occurrences() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.want == nil {
+				if len(got) != tt.wantLen {
+					t.Fatalf("jumlah kemunculan = %d, want %d", len(got), tt.wantLen)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Occurrences() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("kemunculan %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Occurrences tidak boleh mengubah rule milik pemanggil
+	rule, _ := ParseRecurrenceRule("FREQ=DAILY;UNTIL=20260304T050000")
+	if _, err := rule.Occurrences(monday); err != nil {
+		t.Fatal(err)
+	}
+	if got := rule.String(); got != "FREQ=DAILY;UNTIL=20260304T050000" {
+		t.Fatalf("rule berubah menjadi %q", got)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultReminderOffsets = "24h,15m"
+
+// ReminderScheduler mengantrekan pengingat sebelum meeting yang sudah
+// disetujui dimulai, satu untuk setiap offset.
+type ReminderScheduler struct {
+	Email    *EmailService
+	Offsets  []time.Duration
+	Interval time.Duration
+}
+
+// NewReminderScheduler membaca REMINDER_OFFSETS (mis. "24h,15m"). Offset yang
+// tidak valid membuat scheduler memakai nilai default.
+func NewReminderScheduler(email *EmailService) *ReminderScheduler {
+	value := os.Getenv("REMINDER_OFFSETS")
+	if value == "" {
+		value = defaultReminderOffsets
+	}
+	offsets, err := ParseReminderOffsets(value)
+	if err != nil {
+		log.Printf("Invalid REMINDER_OFFSETS %q, using %s: %v", value, defaultReminderOffsets, err)
+		offsets, _ = ParseReminderOffsets(defaultReminderOffsets)
+	}
+	return &ReminderScheduler{Email: email, Offsets: offsets, Interval: time.Minute}
+}
+
+// ParseReminderOffsets mengurai daftar durasi dipisah koma, diurutkan dari
+// yang terkecil.
+func ParseReminderOffsets(value string) ([]time.Duration, error) {
+	var offsets []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("offset pengingat %q tidak valid", part)
+		}
+		offsets = append(offsets, d)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets, nil
+}
+
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	if len(s.Offsets) == 0 {
+		return
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.QueueDueReminders(time.Now()); err != nil {
+			log.Println("Reminder scheduler error:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueDueReminders mengantrekan pengingat untuk booking approved yang sudah
+// masuk jendela offset-nya. Bila beberapa offset sudah lewat (mis. setelah
+// server mati), hanya offset terkecil yang dikirim.
+func (s *ReminderScheduler) QueueDueReminders(now time.Time) (int, error) {
+	if len(s.Offsets) == 0 {
+		return 0, nil
+	}
+	largest := s.Offsets[len(s.Offsets)-1]
+
+	var bookings []models.Booking
+	err := config.DB.Preload("Room").
+		Where("status = ? AND start_time > ? AND start_time <= ?", models.BookingApproved, now, now.Add(largest)).
+		Find(&bookings).Error
+	if err != nil {
+		return 0, fmt.Errorf("gagal mengambil booking untuk pengingat: %w", err)
+	}
+
+	queued := 0
+	for i := range bookings {
+		booking := &bookings[i]
+		offset := s.dueOffset(booking.StartTime.Sub(now))
+		ok, err := s.queueReminder(booking, offset)
+		if err != nil {
+			log.Printf("Failed to queue reminder for booking %s: %v", booking.ID, err)
+			continue
+		}
+		if ok {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// dueOffset adalah offset terkecil yang sudah jatuh tempo untuk sisa waktu until.
+func (s *ReminderScheduler) dueOffset(until time.Duration) time.Duration {
+	for _, offset := range s.Offsets {
+		if until <= offset {
+			return offset
+		}
+	}
+	return s.Offsets[len(s.Offsets)-1]
+}
+
+// queueReminder mencatat pengingat dan mengantrekan email-nya dalam satu
+// transaksi. Baris yang sudah ada (unik per booking, offset dan jadwal)
+// berarti pengingat sudah pernah diantrekan.
+func (s *ReminderScheduler) queueReminder(booking *models.Booking, offset time.Duration) (bool, error) {
+	queued := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		reminder := models.BookingReminder{
+			BookingID:     booking.ID,
+			OffsetMinutes: int(offset / time.Minute),
+			StartTime:     booking.StartTime,
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reminder)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		notification, err := s.Email.QueueBookingReminder(tx, booking, &booking.Room, time.Until(booking.StartTime))
+		if err != nil {
+			return err
+		}
+		if notification != nil {
+			if err := tx.Model(&reminder).Update("notification_id", notification.ID).Error; err != nil {
+				return err
+			}
+		}
+		queued = true
+		return nil
+	})
+	return queued, err
+}
+
+// staleReminderReason memeriksa ulang pengingat sebelum dikirim. Pengingat
+// untuk booking yang sudah dibatalkan, dipindah jadwalnya atau sudah dimulai
+// tidak dikirim.
+func staleReminderReason(n *models.Notification) string {
+	var reminder models.BookingReminder
+	if err := config.DB.Where("notification_id = ?", n.ID).First(&reminder).Error; err != nil {
+		return ""
+	}
+	var booking models.Booking
+	if err := config.DB.First(&booking, reminder.BookingID).Error; err != nil {
+		return "booking sudah dihapus"
+	}
+	switch {
+	case booking.Status != models.BookingApproved:
+		return fmt.Sprintf("status booking sudah %s", booking.Status)
+	case !booking.StartTime.Equal(reminder.StartTime):
+		return "jadwal booking sudah berubah"
+	case !time.Now().Before(booking.StartTime):
+		return "meeting sudah dimulai"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReminderOffsets(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []time.Duration
+		wantErr bool
+	}{
+		{value: "24h,15m", want: []time.Duration{15 * time.Minute, 24 * time.Hour}},
+		{value: " 1h , 30m ,, 2h ", want: []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour}},
+		{value: "1m", want: []time.Duration{time.Minute}},
+		{value: "", want: nil},
+		{value: "30s", wantErr: true},
+		{value: "15m,besok", wantErr: true},
+		{value: "-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReminderOffsets(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReminderOffsets(%q) err = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseReminderOffsets(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReminderDueOffset(t *testing.T) {
+	s := &ReminderScheduler{Offsets: []time.Duration{15 * time.Minute, time.Hour, 24 * time.Hour}}
+
+	tests := []struct {
+		name  string
+		until time.Duration
+		want  time.Duration
+	}{
+		{name: "just entered largest window", until: 24 * time.Hour, want: 24 * time.Hour},
+		{name: "between day and hour", until: 5 * time.Hour, want: 24 * time.Hour},
+		{name: "exactly one hour", until: time.Hour, want: time.Hour},
+		{name: "between hour and 15m", until: 40 * time.Minute, want: time.Hour},
+		{name: "exactly 15m", until: 15 * time.Minute, want: 15 * time.Minute},
+		// Beberapa offset terlewat (server mati): hanya offset terkecil dikirim
+		{name: "all windows passed", until: 2 * time.Minute, want: 15 * time.Minute},
+		{name: "outside every window", until: 48 * time.Hour, want: 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.dueOffset(tt.until); got != tt.want {
+				t.Fatalf("dueOffset(%s) = %s, want %s", tt.until, got, tt.want)
+			}
+		})
+	}
+}
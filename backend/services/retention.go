@@ -0,0 +1,490 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultRetentionCompleteMinutes = 120
+	defaultRetentionArchiveDays     = 365
+	// Jumlah booking maksimal per tahap dalam satu kali jalan
+	retentionBatchSize = 500
+)
+
+var errRetentionRollback = errors.New("retensi dry-run")
+
+// archivableStatuses adalah status akhir yang boleh dipindah ke arsip.
+var archivableStatuses = []models.BookingStatus{
+	models.BookingCompleted, models.BookingRejected, models.BookingCancelled, models.BookingNoShow, models.BookingExpired,
+}
+
+// RetentionPolicy mengatur siklus hidup booking yang sudah lewat:
+//  1. approved/checked_in yang selesai lebih dari CompleteAfter menjadi completed,
+//     pending yang sudah lewat menjadi expired;
+//  2. booking berstatus akhir atau yang sudah dihapus, yang selesai lebih dari
+//     ArchiveAfter, disalin ke booking_archives tanpa data pribadi (dan ke
+//     ExportDir bila diisi), lalu dihapus permanen beserta notifikasi,
+//     pengingat dan riwayat statusnya. Data pemesan di audit log booking dan
+//     notifikasinya dikosongkan. Seri dan rekap requester_stats dihapus bila
+//     sudah tidak punya booking lain.
+type RetentionPolicy struct {
+	CompleteAfter time.Duration
+	ArchiveAfter  time.Duration
+	ExportDir     string
+	DryRun        bool
+}
+
+// RetentionPolicyFromEnv membaca RETENTION_COMPLETE_MINUTES (default 120),
+// RETENTION_ARCHIVE_DAYS (default 365), RETENTION_EXPORT_DIR dan RETENTION_DRY_RUN.
+func RetentionPolicyFromEnv() RetentionPolicy {
+	days, err := strconv.Atoi(os.Getenv("RETENTION_ARCHIVE_DAYS"))
+	if err != nil || days < 1 {
+		days = defaultRetentionArchiveDays
+	}
+	dryRun, _ := strconv.ParseBool(os.Getenv("RETENTION_DRY_RUN"))
+	return RetentionPolicy{
+		CompleteAfter: envMinutes("RETENTION_COMPLETE_MINUTES", defaultRetentionCompleteMinutes),
+		ArchiveAfter:  time.Duration(days) * 24 * time.Hour,
+		ExportDir:     os.Getenv("RETENTION_EXPORT_DIR"),
+		DryRun:        dryRun,
+	}
+}
+
+type RetentionReport struct {
+	DryRun               bool      `json:"dry_run"`
+	StartedAt            time.Time `json:"started_at"`
+	DurationMs           int64     `json:"duration_ms"`
+	Completed            int       `json:"completed"`
+	Expired              int       `json:"expired"`
+	Archived             int       `json:"archived"`
+	NotificationsPurged  int64     `json:"notifications_purged"`
+	TransitionsPurged    int64     `json:"transitions_purged"`
+	RequesterStatsPurged int64     `json:"requester_stats_purged"`
+	SeriesPurged         int       `json:"series_purged"`
+	AuditLogsAnonymized  int       `json:"audit_logs_anonymized"`
+	ExportFile           string    `json:"export_file,omitempty"`
+	Error                string    `json:"error,omitempty"`
+}
+
+// RetentionMetrics adalah rekap sejak proses berjalan. Total hanya menghitung
+// run yang bukan dry-run.
+type RetentionMetrics struct {
+	Runs           int              `json:"runs"`
+	Failures       int              `json:"failures"`
+	TotalCompleted int              `json:"total_completed"`
+	TotalExpired   int              `json:"total_expired"`
+	TotalArchived  int              `json:"total_archived"`
+	LastRun        *RetentionReport `json:"last_run,omitempty"`
+}
+
+var (
+	// retentionMu mencegah worker dan admin menjalankan retensi bersamaan
+	retentionMu      sync.Mutex
+	retentionMetrics RetentionMetrics
+)
+
+// GetRetentionMetrics mengembalikan salinan metrik retensi.
+func GetRetentionMetrics() RetentionMetrics {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+	return retentionMetrics
+}
+
+// RunRetention menjalankan satu putaran kebijakan retensi. Pada dry-run semua
+// perubahan di-rollback dan file export tidak ditulis; report tetap berisi
+// jumlah yang akan diproses.
+func RunRetention(policy RetentionPolicy, now time.Time) (*RetentionReport, error) {
+	retentionMu.Lock()
+	defer retentionMu.Unlock()
+
+	started := time.Now()
+	report := &RetentionReport{DryRun: policy.DryRun, StartedAt: started}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := completeFinishedBookings(tx, policy, now, report); err != nil {
+			return err
+		}
+		if err := archiveOldBookings(tx, policy, now, report); err != nil {
+			return err
+		}
+		if err := purgeOrphanedTransitions(tx, report); err != nil {
+			return err
+		}
+		if policy.DryRun {
+			return errRetentionRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errRetentionRollback) {
+		err = nil
+	}
+	report.DurationMs = time.Since(started).Milliseconds()
+
+	retentionMetrics.Runs++
+	if err != nil {
+		report.Error = err.Error()
+		retentionMetrics.Failures++
+	} else if !policy.DryRun {
+		retentionMetrics.TotalCompleted += report.Completed
+		retentionMetrics.TotalExpired += report.Expired
+		retentionMetrics.TotalArchived += report.Archived
+	}
+	retentionMetrics.LastRun = report
+	return report, err
+}
+
+func completeFinishedBookings(tx *gorm.DB, policy RetentionPolicy, now time.Time, report *RetentionReport) error {
+	var finished []models.Booking
+	err := tx.Where("status IN ? AND end_time < ?", []models.BookingStatus{models.BookingApproved, models.BookingCheckedIn}, now.Add(-policy.CompleteAfter)).
+		Order("end_time").Limit(retentionBatchSize).Find(&finished).Error
+	if err != nil {
+		return fmt.Errorf("gagal mengambil booking yang sudah selesai: %w", err)
+	}
+	for i := range finished {
+		err := TransitionBooking(tx, &finished[i], models.BookingCompleted, SystemActor, "meeting sudah selesai")
+		// Booking yang statusnya berubah sejak query di atas dilewati
+		if errors.Is(err, ErrBookingStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.Completed++
+	}
+
+	var stale []models.Booking
+	err = tx.Where("status = ? AND end_time < ?", models.BookingPending, now).
+		Order("end_time").Limit(retentionBatchSize).Find(&stale).Error
+	if err != nil {
+		return fmt.Errorf("gagal mengambil booking pending yang kedaluwarsa: %w", err)
+	}
+	for i := range stale {
+		err := TransitionBooking(tx, &stale[i], models.BookingExpired, SystemActor, "tidak diproses sampai jadwal lewat")
+		if errors.Is(err, ErrBookingStatusChanged) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.Expired++
+	}
+	return nil
+}
+
+func archiveOldBookings(tx *gorm.DB, policy RetentionPolicy, now time.Time, report *RetentionReport) error {
+	// Booking yang sudah di-soft delete ikut diarsipkan lalu dihapus permanen
+	var bookings []models.Booking
+	err := tx.Unscoped().Preload("Room", IncludeDeleted).
+		Where("(status IN ? OR deleted_at IS NOT NULL) AND end_time < ?", archivableStatuses, now.Add(-policy.ArchiveAfter)).
+		Order("end_time").Limit(retentionBatchSize).Find(&bookings).Error
+	if err != nil {
+		return fmt.Errorf("gagal mengambil booking untuk diarsipkan: %w", err)
+	}
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	archivedAt := time.Now()
+	archives := make([]models.BookingArchive, 0, len(bookings))
+	ids := make([]uuid.UUID, 0, len(bookings))
+	for i := range bookings {
+		archives = append(archives, anonymizedArchive(&bookings[i], archivedAt))
+		ids = append(ids, bookings[i].ID)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&archives).Error; err != nil {
+		return fmt.Errorf("gagal menyimpan arsip booking: %w", err)
+	}
+	if policy.ExportDir != "" && !policy.DryRun {
+		file, err := exportBookingArchives(policy.ExportDir, archives, archivedAt)
+		if err != nil {
+			return err
+		}
+		report.ExportFile = file
+	}
+
+	// Payload notifikasi memuat nama dan email pemesan, jadi ikut dihapus
+	var notificationIDs []string
+	if err := tx.Model(&models.Notification{}).Where("booking_id IN ?", ids).Pluck("id", &notificationIDs).Error; err != nil {
+		return fmt.Errorf("gagal mengambil notifikasi booking: %w", err)
+	}
+	result := tx.Where("booking_id IN ?", ids).Delete(&models.Notification{})
+	if result.Error != nil {
+		return fmt.Errorf("gagal menghapus notifikasi booking: %w", result.Error)
+	}
+	report.NotificationsPurged = result.RowsAffected
+	if err := tx.Where("booking_id IN ?", ids).Delete(&models.BookingReminder{}).Error; err != nil {
+		return fmt.Errorf("gagal menghapus pengingat booking: %w", err)
+	}
+	result = tx.Where("booking_id IN ?", ids).Delete(&models.BookingStatusTransition{})
+	if result.Error != nil {
+		return fmt.Errorf("gagal menghapus riwayat status booking: %w", result.Error)
+	}
+	report.TransitionsPurged += result.RowsAffected
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Booking{}).Error; err != nil {
+		return fmt.Errorf("gagal menghapus booking yang sudah diarsipkan: %w", err)
+	}
+
+	bookingIDs := make([]string, len(ids))
+	for i, id := range ids {
+		bookingIDs[i] = id.String()
+	}
+	n, err := anonymizeAuditLogs(tx, AuditEntityBooking, bookingIDs)
+	if err != nil {
+		return err
+	}
+	report.AuditLogsAnonymized += n
+	n, err = anonymizeAuditLogs(tx, AuditEntityNotification, notificationIDs)
+	if err != nil {
+		return err
+	}
+	report.AuditLogsAnonymized += n
+	if err := purgeArchivedSeries(tx, bookings, report); err != nil {
+		return err
+	}
+	if err := purgeRequesterStats(tx, bookings, report); err != nil {
+		return err
+	}
+	report.Archived = len(archives)
+	return nil
+}
+
+// auditPersonalFields adalah kolom berisi data pemesan yang dikosongkan dari
+// audit log saat booking diarsipkan.
+var auditPersonalFields = []string{"user_email", "user_name", "purpose", "recipient", "subject"}
+
+// anonymizeAuditLogs mengosongkan data pemesan di before/after audit log milik
+// entity yang diarsipkan. Ini satu-satunya perubahan yang boleh dilakukan pada
+// audit log, jadi hook imutabilitas sengaja dilewati lewat UpdateColumns.
+func anonymizeAuditLogs(tx *gorm.DB, entityType string, entityIDs []string) (int, error) {
+	if len(entityIDs) == 0 {
+		return 0, nil
+	}
+	var logs []models.AuditLog
+	if err := tx.Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs).Find(&logs).Error; err != nil {
+		return 0, fmt.Errorf("gagal mengambil audit log untuk dianonimkan: %w", err)
+	}
+	anonymized := 0
+	for i := range logs {
+		before, beforeChanged, err := scrubAuditData(logs[i].Before)
+		if err != nil {
+			return 0, err
+		}
+		after, afterChanged, err := scrubAuditData(logs[i].After)
+		if err != nil {
+			return 0, err
+		}
+		if !beforeChanged && !afterChanged {
+			continue
+		}
+		err = tx.Model(&logs[i]).UpdateColumns(map[string]interface{}{"before_data": before, "after_data": after}).Error
+		if err != nil {
+			return 0, fmt.Errorf("gagal menganonimkan audit log: %w", err)
+		}
+		anonymized++
+	}
+	return anonymized, nil
+}
+
+// scrubAuditData menghapus auditPersonalFields dari JSON before/after.
+func scrubAuditData(data string) (string, bool, error) {
+	if data == "" {
+		return data, false, nil
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return "", false, fmt.Errorf("gagal membaca data audit: %w", err)
+	}
+	changed := false
+	for _, key := range auditPersonalFields {
+		if _, ok := state[key]; ok {
+			delete(state, key)
+			changed = true
+		}
+	}
+	if !changed {
+		return data, false, nil
+	}
+	scrubbed, err := encodeAuditState(state)
+	return scrubbed, true, err
+}
+
+// purgeArchivedSeries menghapus seri yang semua kemunculannya sudah diarsipkan,
+// karena seri menyimpan nama, email dan keperluan pemesan. Arsip tetap
+// menyimpan series_id untuk mengelompokkan kemunculannya.
+func purgeArchivedSeries(tx *gorm.DB, archived []models.Booking, report *RetentionReport) error {
+	seen := make(map[uuid.UUID]bool)
+	var purged []string
+	for i := range archived {
+		seriesID := archived[i].SeriesID
+		if seriesID == nil || seen[*seriesID] {
+			continue
+		}
+		seen[*seriesID] = true
+		var remaining int64
+		if err := tx.Unscoped().Model(&models.Booking{}).Where("series_id = ?", *seriesID).Count(&remaining).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa booking seri: %w", err)
+		}
+		if remaining > 0 {
+			continue
+		}
+		result := tx.Delete(&models.BookingSeries{}, *seriesID)
+		if result.Error != nil {
+			return fmt.Errorf("gagal menghapus seri booking: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			purged = append(purged, seriesID.String())
+		}
+	}
+	report.SeriesPurged += len(purged)
+	n, err := anonymizeAuditLogs(tx, AuditEntitySeries, purged)
+	if err != nil {
+		return err
+	}
+	report.AuditLogsAnonymized += n
+	return nil
+}
+
+// purgeRequesterStats menghapus rekap kehadiran pemesan yang sudah tidak punya
+// booking lain (termasuk yang di-soft delete) setelah booking-nya diarsipkan.
+func purgeRequesterStats(tx *gorm.DB, archived []models.Booking, report *RetentionReport) error {
+	seen := make(map[string]bool)
+	for i := range archived {
+		email := archived[i].UserEmail
+		if email == "" || seen[email] {
+			continue
+		}
+		seen[email] = true
+		var remaining int64
+		if err := tx.Unscoped().Model(&models.Booking{}).Where("user_email = ?", email).Count(&remaining).Error; err != nil {
+			return fmt.Errorf("gagal memeriksa booking pemesan: %w", err)
+		}
+		if remaining > 0 {
+			continue
+		}
+		result := tx.Where("user_email = ?", email).Delete(&models.RequesterStat{})
+		if result.Error != nil {
+			return fmt.Errorf("gagal menghapus rekap pemesan: %w", result.Error)
+		}
+		report.RequesterStatsPurged += result.RowsAffected
+	}
+	return nil
+}
+
+// purgeOrphanedTransitions menghapus riwayat status yang booking-nya sudah
+// tidak ada, misalnya sisa arsip sebelum riwayat ikut dihapus.
+func purgeOrphanedTransitions(tx *gorm.DB, report *RetentionReport) error {
+	existing := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&models.Booking{}).Select("id")
+	result := tx.Where("booking_id NOT IN (?)", existing).Delete(&models.BookingStatusTransition{})
+	if result.Error != nil {
+		return fmt.Errorf("gagal menghapus riwayat status yatim: %w", result.Error)
+	}
+	report.TransitionsPurged += result.RowsAffected
+	return nil
+}
+
+// anonymizedArchive menyalin booking tanpa nama, email dan keperluan.
+func anonymizedArchive(b *models.Booking, archivedAt time.Time) models.BookingArchive {
+	return models.BookingArchive{
+		ID:              b.ID,
+		RoomID:          b.RoomID,
+		RoomName:        b.Room.Name,
+		RequesterHash:   requesterHash(b.UserEmail),
+		Attendees:       b.Attendees,
+		StartTime:       b.StartTime,
+		EndTime:         b.EndTime,
+		Status:          b.Status,
+		SeriesID:        b.SeriesID,
+		CheckedInAt:     b.CheckedInAt,
+		EndedAt:         b.EndedAt,
+		OvertimeMinutes: b.OvertimeMinutes,
+		ExtendedMinutes: b.ExtendedMinutes,
+		CreatedAt:       b.CreatedAt,
+		ArchivedAt:      archivedAt,
+	}
+}
+
+// requesterHash adalah SHA-256 dari email (dengan RETENTION_HASH_SALT bila diisi).
+func requesterHash(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(os.Getenv("RETENTION_HASH_SALT") + email))
+	return hex.EncodeToString(sum[:])
+}
+
+// exportBookingArchives menulis arsip sebagai JSON Lines. File ditulis ke nama
+// sementara lalu di-rename agar tidak pernah terbaca setengah jadi.
+func exportBookingArchives(dir string, archives []models.BookingArchive, archivedAt time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori export arsip: %w", err)
+	}
+	name := filepath.Join(dir, fmt.Sprintf("bookings-%s-%s.jsonl", archivedAt.UTC().Format("20060102T150405Z"), randomHex(4)))
+	tmp, err := os.CreateTemp(dir, ".bookings-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("gagal menulis export arsip: %w", err)
+	}
+	enc := json.NewEncoder(tmp)
+	for i := range archives {
+		if err = enc.Encode(&archives[i]); err != nil {
+			break
+		}
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("gagal menulis export arsip: %w", err)
+	}
+	return name, nil
+}
+
+// RetentionWorker menjalankan RunRetention secara berkala dengan kebijakan
+// dari environment.
+type RetentionWorker struct {
+	Interval time.Duration
+}
+
+func NewRetentionWorker() *RetentionWorker {
+	return &RetentionWorker{Interval: 5 * time.Minute}
+}
+
+func (w *RetentionWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		report, err := RunRetention(RetentionPolicyFromEnv(), time.Now())
+		switch {
+		case err != nil:
+			log.Println("Retention error:", err)
+		case report.Completed+report.Expired+report.Archived > 0:
+			log.Printf("Retention (dry run: %t): %d completed, %d expired, %d archived", report.DryRun, report.Completed, report.Expired, report.Archived)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
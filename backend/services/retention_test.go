@@ -0,0 +1,132 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestScrubAuditData(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		want        map[string]interface{}
+		wantChanged bool
+		wantErr     bool
+	}{
+		{name: "empty", data: ""},
+		{
+			name:        "booking snapshot",
+			data:        `{"user_email":"a@example.com","user_name":"Ani","purpose":"rapat","status":"approved","attendees":4}`,
+			want:        map[string]interface{}{"status": "approved", "attendees": float64(4)},
+			wantChanged: true,
+		},
+		{
+			name:        "notification snapshot",
+			data:        `{"recipient":"a@example.com","subject":"Booking Ani","status":"dead"}`,
+			want:        map[string]interface{}{"status": "dead"},
+			wantChanged: true,
+		},
+		{
+			name: "no personal fields",
+			data: `{"status":"cancelled"}`,
+			want: map[string]interface{}{"status": "cancelled"},
+		},
+		{name: "invalid json", data: `{"user_email":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := scrubAuditData(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %t, want %t", changed, tt.wantChanged)
+			}
+			if tt.want == nil {
+				if got != tt.data {
+					t.Fatalf("data = %q, want %q", got, tt.data)
+				}
+				return
+			}
+			var state map[string]interface{}
+			if err := json.Unmarshal([]byte(got), &state); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state, tt.want) {
+				t.Fatalf("data = %v, want %v", state, tt.want)
+			}
+		})
+	}
+}
+
+// TestRunRetentionPurgesArchivedSeries memastikan seri yang semua
+// kemunculannya sudah diarsipkan ikut dihapus, sedangkan seri yang masih punya
+// booking aktif dibiarkan.
+func TestRunRetentionPurgesArchivedSeries(t *testing.T) {
+	setupTestDB(t)
+	err := config.DB.AutoMigrate(&models.BookingArchive{}, &models.Notification{}, &models.BookingReminder{}, &models.AuditLog{}, &models.RequesterStat{})
+	if err != nil {
+		t.Fatalf("failed to migrate retention tables: %v", err)
+	}
+	room := createTestRoom(t, 10)
+
+	// Jauh di masa lalu agar masuk batch arsip pertama
+	old := time.Date(2001, 1, 1, 9, 0, 0, 0, time.UTC)
+	upcoming := time.Now().Add(72 * time.Hour).Truncate(time.Minute)
+	seed := func(start time.Time, status models.BookingStatus) (models.BookingSeries, models.Booking) {
+		t.Helper()
+		series := models.BookingSeries{
+			ID: uuid.New(), RoomID: room.ID, UserName: "Ani", UserEmail: "ani-" + uuid.NewString() + "@example.com",
+			Purpose: "Rapat mingguan", Attendees: 2, StartTime: start, EndTime: start.Add(time.Hour), RRule: "FREQ=WEEKLY;COUNT=1",
+		}
+		if err := config.DB.Create(&series).Error; err != nil {
+			t.Fatalf("failed to seed series: %v", err)
+		}
+		booking := newBooking(room.ID, series.UserName, series.UserEmail, series.Purpose, 2, start, start.Add(time.Hour))
+		booking.Status = status
+		booking.SeriesID = &series.ID
+		if err := config.DB.Create(&booking).Error; err != nil {
+			t.Fatalf("failed to seed booking: %v", err)
+		}
+		t.Cleanup(func() {
+			config.DB.Delete(&models.BookingArchive{}, booking.ID)
+			config.DB.Delete(&models.BookingSeries{}, series.ID)
+		})
+		return series, booking
+	}
+	archivedSeries, archivedBooking := seed(old, models.BookingCancelled)
+	liveSeries, _ := seed(upcoming, models.BookingApproved)
+
+	report, err := RunRetention(RetentionPolicy{CompleteAfter: time.Hour, ArchiveAfter: 24 * time.Hour}, time.Now())
+	if err != nil {
+		t.Fatalf("RunRetention: %v", err)
+	}
+	if report.SeriesPurged < 1 {
+		t.Fatalf("series_purged = %d, want >= 1", report.SeriesPurged)
+	}
+
+	var archive models.BookingArchive
+	if err := config.DB.First(&archive, archivedBooking.ID).Error; err != nil {
+		t.Fatalf("booking tidak diarsipkan: %v", err)
+	}
+	if archive.SeriesID == nil || *archive.SeriesID != archivedSeries.ID {
+		t.Fatalf("arsip kehilangan series_id: %v", archive.SeriesID)
+	}
+	if err := config.DB.First(&models.BookingSeries{}, archivedSeries.ID).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("seri yang sudah diarsipkan masih ada: %v", err)
+	}
+	if err := config.DB.First(&models.BookingSeries{}, liveSeries.ID).Error; err != nil {
+		t.Fatalf("seri yang masih punya booking ikut terhapus: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RoomDeletePolicy menentukan nasib booking mendatang saat ruangan dihapus.
+type RoomDeletePolicy string
+
+const (
+	// RoomDeleteBlock menolak penghapusan selama masih ada booking mendatang
+	RoomDeleteBlock RoomDeletePolicy = "block"
+	// RoomDeleteCancel membatalkan booking mendatang dan memberi tahu pemesan
+	RoomDeleteCancel RoomDeletePolicy = "cancel"
+	// RoomDeleteMigrate memindahkan booking mendatang ke ruangan lain
+	RoomDeleteMigrate RoomDeletePolicy = "migrate"
+)
+
+var (
+	ErrRoomNotFound      = errors.New("ruangan tidak ditemukan")
+	ErrRoomNotDeleted    = errors.New("ruangan tidak dalam keadaan terhapus")
+	ErrRoomDeleted       = errors.New("ruangan booking ini sudah dihapus")
+	ErrBookingNotDeleted = errors.New("booking tidak dalam keadaan terhapus")
+)
+
+// RoomInUseError dikembalikan pada kebijakan block bila ruangan masih punya
+// booking mendatang.
+type RoomInUseError struct {
+	Bookings []models.Booking
+}
+
+func (e *RoomInUseError) Error() string {
+	return fmt.Sprintf("ruangan masih memiliki %d booking mendatang", len(e.Bookings))
+}
+
+// IncludeDeleted dipakai untuk Preload relasi yang mungkin sudah dihapus,
+// mis. ruangan dari booking lama.
+func IncludeDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// DefaultRoomDeletePolicy membaca ROOM_DELETE_POLICY (default block).
+func DefaultRoomDeletePolicy() RoomDeletePolicy {
+	if policy := RoomDeletePolicy(os.Getenv("ROOM_DELETE_POLICY")); policy.IsValid() {
+		return policy
+	}
+	return RoomDeleteBlock
+}
+
+func (p RoomDeletePolicy) IsValid() bool {
+	return p == RoomDeleteBlock || p == RoomDeleteCancel || p == RoomDeleteMigrate
+}
+
+type RoomDeleteResult struct {
+	Policy   RoomDeletePolicy `json:"policy"`
+	Bookings []models.Booking `json:"bookings"`
+}
+
+// DeleteRoomService menghapus (soft delete) ruangan dan menerapkan kebijakan
+// pada booking yang belum dimulai. Pada migrate semua booking harus muat di
+// ruangan tujuan; bila ada yang bentrok tidak ada yang dipindah.
+func DeleteRoomService(roomID uuid.UUID, policy RoomDeletePolicy, targetRoomID *uuid.UUID, actor Actor, notifier *EmailService) (*RoomDeleteResult, error) {
+	if !policy.IsValid() {
+		return nil, fmt.Errorf("kebijakan %q tidak dikenal, gunakan block, cancel atau migrate", policy)
+	}
+	lockIDs := []uuid.UUID{roomID}
+	if policy == RoomDeleteMigrate {
+		if targetRoomID == nil || *targetRoomID == roomID {
+			return nil, fmt.Errorf("ruangan tujuan wajib diisi dan harus berbeda")
+		}
+		lockIDs = append(lockIDs, *targetRoomID)
+	}
+
+	result := &RoomDeleteResult{Policy: policy, Bookings: []models.Booking{}}
+	err := withRoomLock(lockIDs, func(tx *gorm.DB) error {
+		var room models.Room
+		if err := tx.First(&room, roomID).Error; err != nil {
+			return ErrRoomNotFound
+		}
+		var upcoming []models.Booking
+		err := tx.Where("room_id = ? AND start_time > ? AND status IN ?", roomID, time.Now(),
+			[]models.BookingStatus{models.BookingPending, models.BookingApproved}).
+			Order("start_time").Find(&upcoming).Error
+		if err != nil {
+			return fmt.Errorf("gagal memeriksa booking ruangan")
+		}
+
+		switch {
+		case len(upcoming) == 0:
+		case policy == RoomDeleteBlock:
+			return &RoomInUseError{Bookings: upcoming}
+		case policy == RoomDeleteCancel:
+			for i := range upcoming {
+				b := &upcoming[i]
+				oldStatus := b.Status
+				if err := TransitionBooking(tx, b, models.BookingCancelled, actor, "ruangan "+room.Name+" dihapus"); err != nil {
+					return err
+				}
+				if err := notifier.QueueBookingStatusUpdate(tx, b, &room, oldStatus); err != nil {
+					return err
+				}
+			}
+		case policy == RoomDeleteMigrate:
+			if err := migrateBookings(tx, upcoming, *targetRoomID, notifier); err != nil {
+				return err
+			}
+		}
+		result.Bookings = upcoming
+
+		if err := tx.Delete(&room).Error; err != nil {
+			return fmt.Errorf("gagal menghapus ruangan")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// migrateBookings memindahkan booking ke ruangan lain setelah semuanya lolos
+// pengecekan bentrok dan kapasitas.
+func migrateBookings(tx *gorm.DB, bookings []models.Booking, targetRoomID uuid.UUID, notifier *EmailService) error {
+	var target *models.Room
+	var conflicts []models.Booking
+	for i := range bookings {
+		b := &bookings[i]
+		room, err := validateBookingSlot(tx, targetRoomID, b.StartTime, b.EndTime, b.Attendees, &b.ID)
+		var conflictErr *ConflictError
+		switch {
+		case errors.As(err, &conflictErr):
+			conflicts = append(conflicts, conflictErr.Conflicts...)
+		case err != nil:
+			return err
+		default:
+			target = room
+		}
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	for i := range bookings {
+		b := &bookings[i]
+		b.RoomID = targetRoomID
+		b.Room = *target
+		b.Sequence++
+		// QR lama menunjuk ke jadwal di ruangan lama
+		b.TokenVersion++
+		err := tx.Model(b).Updates(map[string]interface{}{
+			"room_id":       b.RoomID,
+			"sequence":      b.Sequence,
+			"token_version": b.TokenVersion,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("gagal memindahkan booking")
+		}
+		if b.Status == models.BookingApproved {
+			if err := notifier.QueueCalendarUpdate(tx, b, target, ICalMethodRequest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreRoomService memulihkan ruangan yang sudah dihapus. Booking yang
+// dibatalkan atau dipindah saat penghapusan tidak dikembalikan.
+func RestoreRoomService(roomID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	if err := config.DB.Unscoped().First(&room, roomID).Error; err != nil {
+		return nil, ErrRoomNotFound
+	}
+	if !room.DeletedAt.Valid {
+		return nil, ErrRoomNotDeleted
+	}
+	if err := config.DB.Unscoped().Model(&room).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("gagal memulihkan ruangan")
+	}
+	room.DeletedAt = gorm.DeletedAt{}
+	return &room, nil
+}
+
+// DeleteBookingService menghapus (soft delete) booking di bawah lock
+// ruangannya. Booking approved yang belum selesai dihapus dari kalender
+// pemesan; SEQUENCE-nya dinaikkan agar undangan saat booking dipulihkan lebih
+// baru dari CANCEL ini. Ruangan yang sudah dihapus tetap dipakai untuk undangan.
+func DeleteBookingService(bookingID uuid.UUID, notifier *EmailService) (*models.Booking, error) {
+	return withLockedBooking(bookingID, func(tx *gorm.DB, booking *models.Booking) error {
+		if err := tx.Delete(booking).Error; err != nil {
+			return fmt.Errorf("gagal menghapus booking")
+		}
+		if booking.Status != models.BookingApproved || !booking.EndTime.After(time.Now()) {
+			return nil
+		}
+		var room models.Room
+		if err := tx.Unscoped().First(&room, booking.RoomID).Error; err != nil {
+			return ErrRoomNotFound
+		}
+		booking.Sequence++
+		if err := tx.Unscoped().Model(booking).Update("sequence", booking.Sequence).Error; err != nil {
+			return fmt.Errorf("gagal menghapus booking")
+		}
+		return notifier.QueueCalendarUpdate(tx, booking, &room, ICalMethodCancel)
+	})
+}
+
+// RestoreBookingService memulihkan booking yang sudah dihapus. Booking yang
+// masih menempati slot dicek ulang terhadap jadwal ruangan saat ini, dan
+// undangan kalender dikirim ulang bila booking sudah approved.
+func RestoreBookingService(bookingID uuid.UUID, notifier *EmailService) (*models.Booking, error) {
+	var booking models.Booking
+	if err := config.DB.Unscoped().First(&booking, bookingID).Error; err != nil {
+		return nil, ErrBookingNotFound
+	}
+	err := withRoomLock([]uuid.UUID{booking.RoomID}, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().First(&booking, bookingID).Error; err != nil {
+			return ErrBookingNotFound
+		}
+		if !booking.DeletedAt.Valid {
+			return ErrBookingNotDeleted
+		}
+		room, err := findRoom(tx, booking.RoomID)
+		if err != nil {
+			return err
+		}
+		upcoming := booking.Status.OccupiesSlot() && booking.EndTime.After(time.Now())
+		if upcoming {
+			if _, err := validateBookingSlot(tx, booking.RoomID, booking.StartTime, booking.EndTime, booking.Attendees, &booking.ID); err != nil {
+				return err
+			}
+		}
+		resend := upcoming && booking.Status == models.BookingApproved
+		updates := map[string]interface{}{"deleted_at": nil}
+		if resend {
+			booking.Sequence++
+			updates["sequence"] = booking.Sequence
+		}
+		if err := tx.Unscoped().Model(&booking).Updates(updates).Error; err != nil {
+			return fmt.Errorf("gagal memulihkan booking")
+		}
+		booking.DeletedAt = gorm.DeletedAt{}
+		booking.Room = *room
+		if resend {
+			return notifier.QueueCalendarUpdate(tx, &booking, room, ICalMethodRequest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// findRoom mencari ruangan yang belum dihapus.
+func findRoom(db *gorm.DB, roomID uuid.UUID) (*models.Room, error) {
+	var room models.Room
+	err := db.First(&room, roomID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var deleted int64
+		db.Unscoped().Model(&models.Room{}).Where("id = ?", roomID).Count(&deleted)
+		if deleted > 0 {
+			return nil, ErrRoomDeleted
+		}
+		return nil, ErrRoomNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil ruangan")
+	}
+	return &room, nil
+}
+
+// ListDeletedRooms mengembalikan ruangan yang sudah dihapus, terbaru lebih dulu.
+func ListDeletedRooms() ([]models.Room, error) {
+	var rooms []models.Room
+	err := config.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&rooms).Error
+	return rooms, err
+}
+
+// ListDeletedBookings mengembalikan booking terhapus beserta ruangannya. roomIDs
+// nil berarti semua ruangan.
+func ListDeletedBookings(limit, offset int, roomIDs []uuid.UUID) ([]models.Booking, int64, error) {
+	query := config.DB.Unscoped().Model(&models.Booking{}).Where("deleted_at IS NOT NULL")
+	if roomIDs != nil {
+		query = query.Where("room_id IN ?", roomIDs)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var bookings []models.Booking
+	err := query.Preload("Room", IncludeDeleted).Order("deleted_at DESC").Limit(limit).Offset(offset).Find(&bookings).Error
+	return bookings, total, err
+}
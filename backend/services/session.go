@@ -0,0 +1,323 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+	// refreshReuseGrace: refresh token lama yang dipakai lagi dalam jendela ini
+	// dianggap balapan antar-tab (dua refresh bersamaan), bukan token bocor.
+	refreshReuseGrace = 10 * time.Second
+)
+
+const (
+	SessionRevokedLogout    = "logout"
+	SessionRevokedLogoutAll = "logout_all"
+	SessionRevokedAdmin     = "admin"
+	SessionRevokedReuse     = "refresh_reuse"
+	SessionRevokedPassword  = "password_reset"
+	SessionRevokedDisabled  = "user_disabled"
+)
+
+var (
+	ErrSessionInvalid = errors.New("refresh token tidak valid atau sudah kedaluwarsa")
+	ErrSessionReused  = errors.New("refresh token sudah pernah dipakai, sesi dicabut")
+	ErrSessionRevoked = errors.New("sesi sudah berakhir")
+)
+
+// SessionTTL adalah masa berlaku access token (JWT) dan refresh token.
+type SessionTTL struct {
+	Access  time.Duration
+	Refresh time.Duration
+}
+
+// SessionTTLFromEnv membaca ACCESS_TOKEN_TTL (default 15m) dan
+// REFRESH_TOKEN_TTL (default 168h) dalam format durasi Go.
+func SessionTTLFromEnv() SessionTTL {
+	return SessionTTL{
+		Access:  envDuration("ACCESS_TOKEN_TTL", defaultAccessTokenTTL),
+		Refresh: envDuration("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL),
+	}
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// SessionClient adalah informasi perangkat yang ditampilkan di daftar sesi.
+type SessionClient struct {
+	IP        string
+	UserAgent string
+}
+
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession membuat sesi baru untuk user dan mengembalikan refresh token
+// mentah. Token hanya dikembalikan sekali; database menyimpan hash-nya.
+func CreateSession(userID uuid.UUID, client SessionClient) (*models.Session, string, error) {
+	raw, err := newSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	session := &models.Session{
+		UserID:           userID,
+		RefreshTokenHash: hashRefreshToken(raw),
+		IP:               truncate(client.IP, 64),
+		UserAgent:        truncate(client.UserAgent, 255),
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(SessionTTLFromEnv().Refresh),
+	}
+	if err := config.DB.Create(session).Error; err != nil {
+		return nil, "", err
+	}
+	return session, raw, nil
+}
+
+// RefreshSession menukar refresh token dengan refresh token baru (rotasi) dan
+// mengembalikan sesi serta user pemiliknya. Refresh token sebelumnya yang
+// dipakai ulang di luar jendela toleransi dianggap bocor: sesi langsung
+// dicabut sehingga pencuri maupun pemilik asli harus login ulang; sesi yang
+// dicabut tetap dikembalikan bersama ErrSessionReused untuk audit.
+func RefreshSession(raw string, client SessionClient) (*models.Session, *models.User, string, error) {
+	if raw == "" {
+		return nil, nil, "", ErrSessionInvalid
+	}
+	hash := hashRefreshToken(raw)
+	now := time.Now()
+
+	var session models.Session
+	err := config.DB.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		reused, err := detectRefreshReuse(hash, now)
+		return reused, nil, "", err
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !session.IsActive(now) {
+		return nil, nil, "", ErrSessionInvalid
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, session.UserID).Error; err != nil {
+		return nil, nil, "", ErrSessionInvalid
+	}
+	if user.IsDisabled() {
+		RevokeSession(session.ID, SessionRevokedDisabled)
+		return nil, nil, "", ErrUserDisabled
+	}
+
+	next, err := newSecureToken()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	updates := map[string]interface{}{
+		"refresh_token_hash":  hashRefreshToken(next),
+		"previous_token_hash": hash,
+		"last_used_at":        now,
+		"expires_at":          now.Add(SessionTTLFromEnv().Refresh),
+	}
+	if client.IP != "" {
+		updates["ip"] = truncate(client.IP, 64)
+	}
+	if client.UserAgent != "" {
+		updates["user_agent"] = truncate(client.UserAgent, 255)
+	}
+	// Kondisi refresh_token_hash memastikan hanya satu dari dua refresh
+	// bersamaan dengan token yang sama yang berhasil
+	result := config.DB.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, hash).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, nil, "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, "", ErrSessionInvalid
+	}
+	if err := config.DB.First(&session, session.ID).Error; err != nil {
+		return nil, nil, "", err
+	}
+	return &session, &user, next, nil
+}
+
+func detectRefreshReuse(hash string, now time.Time) (*models.Session, error) {
+	var session models.Session
+	err := config.DB.Where("previous_token_hash = ? AND revoked_at IS NULL", hash).First(&session).Error
+	if err != nil {
+		return nil, ErrSessionInvalid
+	}
+	if now.Sub(session.LastUsedAt) < refreshReuseGrace {
+		return nil, ErrSessionInvalid
+	}
+	if err := RevokeSession(session.ID, SessionRevokedReuse); err != nil {
+		return nil, err
+	}
+	log.Printf("Session: refresh token lama dipakai ulang, sesi %s milik user %s dicabut", session.ID, session.UserID)
+	return &session, ErrSessionReused
+}
+
+// GetSession mengembalikan sesi berdasarkan ID, termasuk yang sudah dicabut.
+func GetSession(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	if err := config.DB.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeSession mencabut satu sesi. Access token yang diterbitkan untuk sesi
+// ini ikut ditolak karena middleware mengecek sid.
+func RevokeSession(id uuid.UUID, reason string) error {
+	return config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// RevokeSessionByRefreshToken mencabut sesi pemilik refresh token raw. Sesi
+// yang tidak ditemukan atau sudah dicabut tidak dianggap error.
+func RevokeSessionByRefreshToken(raw, reason string) (*models.Session, error) {
+	var session models.Session
+	err := config.DB.Where("refresh_token_hash = ?", hashRefreshToken(raw)).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, RevokeSession(session.ID, reason)
+}
+
+// RevokeUserSessions mencabut semua sesi aktif milik user dan mengembalikan
+// jumlah sesi yang dicabut.
+func RevokeUserSessions(userID uuid.UUID, reason string) (int64, error) {
+	return revokeSessionsWhere(config.DB.Where("user_id = ?", userID), reason)
+}
+
+func revokeSessionsWhere(scope *gorm.DB, reason string) (int64, error) {
+	result := scope.Model(&models.Session{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAccessToken memasukkan jti ke daftar revokasi sampai token
+// kedaluwarsa.
+func RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	return config.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// ValidateAccessToken memastikan jti belum dicabut dan sesi sid masih aktif.
+func ValidateAccessToken(jti string, sessionID uuid.UUID) error {
+	var count int64
+	if err := config.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrSessionRevoked
+	}
+	err := config.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// SessionFilter menyaring daftar sesi aktif.
+type SessionFilter struct {
+	UserID *uuid.UUID
+	Limit  int
+	Offset int
+}
+
+// ListActiveSessions mengembalikan sesi yang belum dicabut dan belum
+// kedaluwarsa, terbaru dipakai lebih dulu.
+func ListActiveSessions(filter SessionFilter) ([]models.Session, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	query := config.DB.Model(&models.Session{}).Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var sessions []models.Session
+	err := query.Preload("User").Order("last_used_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&sessions).Error
+	return sessions, total, err
+}
+
+// PurgeExpiredSessions menghapus sesi dan jti yang sudah kedaluwarsa. Sesi
+// yang dicabut tetap disimpan sampai masa berlakunya habis.
+func PurgeExpiredSessions(now time.Time) (sessions int64, tokens int64, err error) {
+	result := config.DB.Where("expires_at <= ?", now).Delete(&models.Session{})
+	if result.Error != nil {
+		return 0, 0, result.Error
+	}
+	sessions = result.RowsAffected
+	result = config.DB.Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	return sessions, result.RowsAffected, result.Error
+}
+
+// SessionCleanupWorker membersihkan sesi dan daftar revokasi secara berkala.
+type SessionCleanupWorker struct {
+	Interval time.Duration
+}
+
+func NewSessionCleanupWorker() *SessionCleanupWorker {
+	return &SessionCleanupWorker{Interval: time.Hour}
+}
+
+func (w *SessionCleanupWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		sessions, tokens, err := PurgeExpiredSessions(time.Now())
+		switch {
+		case err != nil:
+			log.Println("Session cleanup error:", err)
+		case sessions+tokens > 0:
+			log.Printf("Session cleanup: %d sesi, %d token dicabut dihapus", sessions, tokens)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"backendgo/config"
+	"backendgo/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSessionTTLFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		access  string
+		refresh string
+		want    SessionTTL
+	}{
+		{name: "defaults", want: SessionTTL{Access: defaultAccessTokenTTL, Refresh: defaultRefreshTokenTTL}},
+		{name: "custom", access: "5m", refresh: "720h", want: SessionTTL{Access: 5 * time.Minute, Refresh: 720 * time.Hour}},
+		{name: "invalid and negative fall back", access: "15", refresh: "-1h", want: SessionTTL{Access: defaultAccessTokenTTL, Refresh: defaultRefreshTokenTTL}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACCESS_TOKEN_TTL", tt.access)
+			t.Setenv("REFRESH_TOKEN_TTL", tt.refresh)
+			if got := SessionTTLFromEnv(); got != tt.want {
+				t.Fatalf("SessionTTLFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func createTestUser(t *testing.T) models.User {
+	t.Helper()
+	suffix := uuid.NewString()
+	user := models.User{Email: "test-" + suffix + "@example.com", Username: "test-" + suffix, Role: models.RoleAdmin}
+	if err := config.DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		config.DB.Where("user_id = ?", user.ID).Delete(&models.Session{})
+		config.DB.Delete(&user)
+	})
+	return user
+}
+
+// backdateSession memundurkan last_used_at agar pemakaian ulang token lama
+// berada di luar jendela toleransi balapan antar-tab.
+func backdateSession(t *testing.T, id uuid.UUID, by time.Duration) {
+	t.Helper()
+	if err := config.DB.Model(&models.Session{}).Where("id = ?", id).Update("last_used_at", time.Now().Add(-by)).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshSessionRotation(t *testing.T) {
+	setupTestDB(t)
+	if err := config.DB.AutoMigrate(&models.User{}, &models.Session{}); err != nil {
+		t.Fatalf("failed to migrate sessions: %v", err)
+	}
+	client := SessionClient{IP: "127.0.0.1", UserAgent: "go test"}
+
+	tests := []struct {
+		name string
+		// run menjalankan skenario dan mengembalikan error refresh terakhir
+		run      func(t *testing.T, session *models.Session, raw string) error
+		wantErr  error
+		revoked  string
+		rotation bool
+	}{
+		{
+			name: "refresh rotates the token",
+			run: func(t *testing.T, _ *models.Session, raw string) error {
+				_, _, next, err := RefreshSession(raw, client)
+				if err == nil && (next == "" || next == raw) {
+					t.Fatalf("refresh token tidak dirotasi")
+				}
+				return err
+			},
+			rotation: true,
+		},
+		{
+			name: "new token keeps working",
+			run: func(t *testing.T, _ *models.Session, raw string) error {
+				_, _, next, err := RefreshSession(raw, client)
+				if err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+				_, _, _, err = RefreshSession(next, client)
+				return err
+			},
+		},
+		{
+			name: "old token reused within grace is a tab race",
+			run: func(t *testing.T, _ *models.Session, raw string) error {
+				if _, _, _, err := RefreshSession(raw, client); err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+				_, _, _, err := RefreshSession(raw, client)
+				return err
+			},
+			wantErr: ErrSessionInvalid,
+		},
+		{
+			name: "old token reused after grace revokes the session",
+			run: func(t *testing.T, session *models.Session, raw string) error {
+				if _, _, _, err := RefreshSession(raw, client); err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+				backdateSession(t, session.ID, refreshReuseGrace+time.Second)
+				reused, _, _, err := RefreshSession(raw, client)
+				if reused == nil || reused.ID != session.ID {
+					t.Fatalf("sesi yang dicabut harus dikembalikan untuk audit, got %+v", reused)
+				}
+				return err
+			},
+			wantErr: ErrSessionReused,
+			revoked: SessionRevokedReuse,
+		},
+		{
+			name: "rotated token stops working after reuse",
+			run: func(t *testing.T, session *models.Session, raw string) error {
+				_, _, next, err := RefreshSession(raw, client)
+				if err != nil {
+					t.Fatalf("first refresh: %v", err)
+				}
+				backdateSession(t, session.ID, refreshReuseGrace+time.Second)
+				if _, _, _, err := RefreshSession(raw, client); !errors.Is(err, ErrSessionReused) {
+					t.Fatalf("reuse: err = %v, want ErrSessionReused", err)
+				}
+				_, _, _, err = RefreshSession(next, client)
+				return err
+			},
+			wantErr: ErrSessionInvalid,
+			revoked: SessionRevokedReuse,
+		},
+		{
+			name: "revoked session",
+			run: func(t *testing.T, session *models.Session, raw string) error {
+				if err := RevokeSession(session.ID, SessionRevokedLogout); err != nil {
+					t.Fatal(err)
+				}
+				_, _, _, err := RefreshSession(raw, client)
+				return err
+			},
+			wantErr: ErrSessionInvalid,
+			revoked: SessionRevokedLogout,
+		},
+		{
+			name: "expired session",
+			run: func(t *testing.T, session *models.Session, raw string) error {
+				config.DB.Model(session).Update("expires_at", time.Now().Add(-time.Minute))
+				_, _, _, err := RefreshSession(raw, client)
+				return err
+			},
+			wantErr: ErrSessionInvalid,
+		},
+		{
+			name: "disabled user",
+			run: func(t *testing.T, session *models.Session, raw string) error {
+				config.DB.Model(&models.User{}).Where("id = ?", session.UserID).Update("disabled_at", time.Now())
+				_, _, _, err := RefreshSession(raw, client)
+				return err
+			},
+			wantErr: ErrUserDisabled,
+			revoked: SessionRevokedDisabled,
+		},
+		{
+			name: "unknown token",
+			run: func(t *testing.T, _ *models.Session, _ string) error {
+				_, _, _, err := RefreshSession("bukan-token", client)
+				return err
+			},
+			wantErr: ErrSessionInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := createTestUser(t)
+			session, raw, err := CreateSession(user.ID, client)
+			if err != nil {
+				t.Fatalf("CreateSession: %v", err)
+			}
+
+			err = tt.run(t, session, raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored models.Session
+			if err := config.DB.First(&stored, session.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.RevokedReason != tt.revoked {
+				t.Fatalf("revoked_reason = %q, want %q", stored.RevokedReason, tt.revoked)
+			}
+			if rotated := stored.PreviousTokenHash == hashRefreshToken(raw); tt.rotation && !rotated {
+				t.Fatalf("previous_token_hash tidak diisi token lama")
+			}
+		})
+	}
+}
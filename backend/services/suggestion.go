@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Rentang pencarian slot alternatif di ruangan yang sama, sebelum dan sesudah waktu yang diminta
+	suggestionSearchWindow = 8 * time.Hour
+	defaultSuggestionLimit = 5
+
+	SuggestionSameRoom  = "same_room"
+	SuggestionOtherRoom = "other_room"
+)
+
+type SuggestionQuery struct {
+	// RoomID boleh kosong; bila diisi, slot lain di ruangan tersebut ikut dicari
+	RoomID    uuid.UUID
+	Start     time.Time
+	End       time.Time
+	Attendees int
+	Limit     int
+}
+
+type BookingSuggestion struct {
+	RoomID    uuid.UUID `json:"room_id"`
+	RoomName  string    `json:"room_name"`
+	Capacity  int       `json:"capacity"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Kind      string    `json:"kind"`
+	Score     float64   `json:"score"`
+}
+
+// SuggestAlternatives mencari slot pengganti untuk request yang bentrok:
+// ruangan yang sama pada waktu kosong terdekat, dan ruangan lain dengan
+// kapasitas cukup pada waktu yang diminta. Hasil diurutkan berdasarkan skor
+// gabungan kedekatan waktu dan kecocokan kapasitas.
+func SuggestAlternatives(q SuggestionQuery) ([]BookingSuggestion, error) {
+	duration := q.End.Sub(q.Start)
+	if duration <= 0 {
+		return nil, fmt.Errorf("waktu selesai harus setelah waktu mulai")
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultSuggestionLimit
+	}
+
+	suggestions := []BookingSuggestion{}
+
+	if q.RoomID != uuid.Nil {
+		sameRoom, err := GetRoomAvailability(AvailabilityQuery{
+			Start:          q.Start.Add(-suggestionSearchWindow),
+			End:            q.End.Add(suggestionSearchWindow),
+			Duration:       duration,
+			Attendees:      q.Attendees,
+			RoomIDs:        []uuid.UUID{q.RoomID},
+			IncludePending: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, room := range sameRoom {
+			for _, w := range room.Free {
+				for _, start := range nearestStarts(w, q.Start, duration) {
+					if start.Before(now) || start.Equal(q.Start) {
+						continue
+					}
+					suggestions = append(suggestions, BookingSuggestion{
+						RoomID:    room.RoomID,
+						RoomName:  room.RoomName,
+						Capacity:  room.Capacity,
+						StartTime: start,
+						EndTime:   start.Add(duration),
+						Kind:      SuggestionSameRoom,
+						Score:     suggestionScore(start.Sub(q.Start), q.Attendees, room.Capacity),
+					})
+				}
+			}
+		}
+	}
+
+	otherRooms, err := GetRoomAvailability(AvailabilityQuery{
+		Start:          q.Start,
+		End:            q.End,
+		Duration:       duration,
+		Attendees:      q.Attendees,
+		IncludePending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, room := range otherRooms {
+		if room.RoomID == q.RoomID || len(room.Free) == 0 {
+			continue
+		}
+		suggestions = append(suggestions, BookingSuggestion{
+			RoomID:    room.RoomID,
+			RoomName:  room.RoomName,
+			Capacity:  room.Capacity,
+			StartTime: q.Start,
+			EndTime:   q.End,
+			Kind:      SuggestionOtherRoom,
+			Score:     suggestionScore(0, q.Attendees, room.Capacity),
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > q.Limit {
+		suggestions = suggestions[:q.Limit]
+	}
+	return suggestions, nil
+}
+
+// nearestStarts mengembalikan waktu mulai dalam jendela kosong w yang paling
+// dekat dengan waktu yang diminta, baik sebelum maupun sesudahnya.
+func nearestStarts(w TimeWindow, requested time.Time, duration time.Duration) []time.Time {
+	latest := w.EndTime.Add(-duration)
+	if latest.Before(w.StartTime) {
+		return nil
+	}
+	switch {
+	case requested.Before(w.StartTime):
+		return []time.Time{w.StartTime}
+	case requested.After(latest):
+		return []time.Time{latest}
+	default:
+		// Waktu yang diminta sendiri muat di jendela ini
+		return []time.Time{requested}
+	}
+}
+
+// suggestionScore bernilai 0..1: 60% kedekatan waktu, 40% kecocokan kapasitas
+// (ruangan yang terlalu besar untuk jumlah peserta mendapat skor lebih rendah).
+func suggestionScore(shift time.Duration, attendees, capacity int) float64 {
+	proximity := 1 - math.Abs(shift.Minutes())/suggestionSearchWindow.Minutes()
+	if proximity < 0 {
+		proximity = 0
+	}
+	fit := 1.0
+	if attendees > 0 && capacity > 0 {
+		fit = float64(attendees) / float64(capacity)
+	}
+	return math.Round((0.6*proximity+0.4*fit)*1000) / 1000
+}
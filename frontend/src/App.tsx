@@ -3,6 +3,8 @@ import { AuthProvider } from './contexts/AuthContext';
 import { HomePage } from './pages/HomePage';
 import { RoomPage } from './pages/RoomPage';
 import { AdminPage } from './pages/AdminPage';
+import { AuthCallbackPage } from './pages/AuthCallbackPage';
+import { CheckInPage } from './pages/CheckInPage';
 import AdminLogin from './components/Admin/AdminLogin';
 import AdminRegister from './components/Admin/AdminRegister';
 import ForgotPassword from './components/Admin/ForgotPassword';
@@ -21,6 +23,8 @@ function App() {
           <Route path="/admin/register" element={<AdminRegister />} />
           <Route path="/admin/forgot-password" element={<ForgotPassword />} />
           <Route path="/admin/reset-password" element={<ResetPassword />} />
+          <Route path="/auth/callback" element={<AuthCallbackPage />} />
+          <Route path="/check-in/:token" element={<CheckInPage />} />
           <Route
             path="/admin"
             element={
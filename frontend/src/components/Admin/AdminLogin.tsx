@@ -119,6 +119,12 @@ const AdminLogin = () => {
                 )}
               </Button>
             </form>
+
+            <div className="mt-4">
+              <Button variant="outline" className="w-full" asChild>
+                <a href="http://localhost:8080/api/auth/oidc/login?redirect=/admin">Sign in with SSO</a>
+              </Button>
+            </div>
           </CardContent>
         </Card>
 
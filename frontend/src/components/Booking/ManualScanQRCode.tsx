@@ -1,8 +1,35 @@
 import React, { useRef, useState } from 'react';
 import jsQR from 'jsqr';
 import { Layout } from '../Layout/Layout';
+import { api } from '../../utils/api';
 
-const validateQRCode = (data: string) => data.includes('/api/bookings/delete/');
+// Aksi yang bisa dijalankan dari QR. Path link bisa diganti lewat LINK_PATH_*
+// di backend, jadi aksi dibaca dari klaim "act" token (segmen terakhir URL),
+// bukan dari path-nya.
+const QR_ACTIONS: Record<string, { endpoint: string; success: string; failure: string }> = {
+  check_in: { endpoint: '/bookings/check-in/', success: 'Check-in berhasil!', failure: 'Gagal check-in' },
+  end: { endpoint: '/bookings/end/', success: 'Meeting Selesai!', failure: 'Gagal mengakhiri meeting' },
+};
+
+const parseQRCode = (data: string) => {
+  let url: URL;
+  try {
+    url = new URL(data);
+  } catch {
+    return null;
+  }
+  const token = decodeURIComponent(url.pathname.split('/').filter(Boolean).pop() || '');
+  const payload = token.split('.')[1];
+  if (!payload) return null;
+  try {
+    const base64 = payload.replace(/-/g, '+').replace(/_/g, '/');
+    const claims = JSON.parse(atob(base64.padEnd(Math.ceil(base64.length / 4) * 4, '=')));
+    const action = QR_ACTIONS[claims.act];
+    return action ? { ...action, token } : null;
+  } catch {
+    return null;
+  }
+};
 
 const ManualScanQRCode: React.FC = () => {
   const [files, setFiles] = useState<any[]>([]);
@@ -46,14 +73,14 @@ const ManualScanQRCode: React.FC = () => {
               result.status = 'error'; result.message = 'QR code tidak terdeteksi';
             } else {
               result.data = code.data;
-              result.isValid = validateQRCode(code.data);
-              if (result.isValid) {
+              const action = parseQRCode(code.data);
+              result.isValid = action !== null;
+              if (action) {
                 try {
-                  const res = await fetch(code.data, { method: 'DELETE' });
-                  if (!res.ok) throw new Error('Gagal hapus booking');
-                  result.status = 'success'; result.message = 'Meeting Selesai!';
-                } catch (err) {
-                  result.status = 'error'; result.message = 'Gagal hapus booking';
+                  await api(`${action.endpoint}${encodeURIComponent(action.token)}`, { method: 'POST' });
+                  result.status = 'success'; result.message = action.success;
+                } catch (err: any) {
+                  result.status = 'error'; result.message = err.message || action.failure;
                 }
               } else {
                 result.status = 'error'; result.message = 'QR code tidak valid';
@@ -76,7 +103,7 @@ const ManualScanQRCode: React.FC = () => {
     <Layout>
       <div className="max-w-2xl mx-auto mt-8 p-6 border rounded-lg shadow-lg bg-white">
         <h2 className="text-xl font-semibold mb-6 text-center text-gray-800">
-          Upload the QR Code Image when you have finished the meeting!
+          Upload the QR Code Image to check in or when you have finished the meeting!
         </h2>
         <div className="space-y-4">
           {status === 'processing' && total > 0 && (
@@ -1,11 +1,13 @@
 import React, { createContext, useContext, useEffect, useState } from 'react';
+import { api, AUTH_EXPIRED_EVENT, clearTokens, saveTokens } from '../utils/api';
 
 interface AuthContextType {
   user: any;
   isAdmin: boolean;
   loading: boolean;
   signIn: (username: string, password: string) => Promise<{ error: any } | undefined>;
-  signOut: () => void;
+  signInWithToken: (token: string, refreshToken?: string) => boolean;
+  signOut: () => Promise<void>;
 }
 
 const AuthContext = createContext<AuthContextType | undefined>(undefined);
@@ -29,10 +31,10 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       try {
         const payload = JSON.parse(atob(token.split('.')[1]));
         setUser({ id: payload.user_id, role: payload.role });
-        setIsAdmin(payload.role === 'admin');
+        setIsAdmin(!!payload.role && payload.role !== 'requester');
       } catch (error) {
         console.error('Invalid token:', error);
-        localStorage.removeItem('token');
+        clearTokens();
         setUser(null);
         setIsAdmin(false);
       }
@@ -41,6 +43,14 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       setIsAdmin(false);
     }
     setLoading(false);
+
+    // Refresh token ditolak (logout dari perangkat lain, dicabut admin, dst.)
+    const onExpired = () => {
+      setUser(null);
+      setIsAdmin(false);
+    };
+    window.addEventListener(AUTH_EXPIRED_EVENT, onExpired);
+    return () => window.removeEventListener(AUTH_EXPIRED_EVENT, onExpired);
   }, []);
 
   const signIn = async (username: string, password: string) => {
@@ -52,11 +62,11 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
       });
       const data = await res.json();
       if (res.ok && data.data?.token) {
-        localStorage.setItem('token', data.data.token);
+        saveTokens(data.data);
         try {
           const payload = JSON.parse(atob(data.data.token.split('.')[1]));
           setUser({ id: payload.user_id, role: payload.role });
-          setIsAdmin(payload.role === 'admin');
+          setIsAdmin(!!payload.role && payload.role !== 'requester');
         } catch (error) {
           console.error('Invalid token received:', error);
           return { error: 'Invalid token received' };
@@ -70,8 +80,32 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
   };
 
-  const signOut = () => {
-    localStorage.removeItem('token');
+  // Dipakai callback SSO: token sudah diterbitkan backend
+  const signInWithToken = (token: string, refreshToken?: string) => {
+    try {
+      const payload = JSON.parse(atob(token.split('.')[1]));
+      saveTokens({ token, refresh_token: refreshToken });
+      setUser({ id: payload.user_id, role: payload.role });
+      setIsAdmin(!!payload.role && payload.role !== 'requester');
+      return true;
+    } catch (error) {
+      console.error('Invalid token received:', error);
+      return false;
+    }
+  };
+
+  const signOut = async () => {
+    const refreshToken = localStorage.getItem('refresh_token');
+    try {
+      // Sesi dicabut di server; token lokal tetap dihapus meski request gagal
+      await api('/auth/logout', {
+        method: 'POST',
+        body: JSON.stringify({ refresh_token: refreshToken || '' }),
+      });
+    } catch (error) {
+      console.error('Logout failed:', error);
+    }
+    clearTokens();
     setUser(null);
     setIsAdmin(false);
   };
@@ -81,6 +115,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     isAdmin,
     loading,
     signIn,
+    signInWithToken,
     signOut,
   };
 
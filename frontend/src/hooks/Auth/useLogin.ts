@@ -1,5 +1,5 @@
 import { useMutation } from '@tanstack/react-query';
-import { api } from '../../utils/api';
+import { api, saveTokens } from '../../utils/api';
 
 export const useLogin = () =>
   useMutation({
@@ -10,7 +10,7 @@ export const useLogin = () =>
       });
       // Simpan token ke localStorage
       if (res.data?.token) {
-        localStorage.setItem('token', res.data.token);
+        saveTokens(res.data);
       }
       return res;
     },
@@ -0,0 +1,51 @@
+import { useEffect, useState } from 'react';
+import { useNavigate, Link } from 'react-router-dom';
+import { useAuth } from '../contexts/AuthContext';
+import { Layout } from '../components/Layout/Layout';
+import { Card, CardContent, CardHeader, CardTitle } from '../components/ui/Card';
+import { Button } from '../components/ui/Button';
+
+// Halaman tujuan redirect backend setelah login SSO. Token atau pesan error
+// dikirim lewat fragment URL agar tidak tercatat di log server.
+export function AuthCallbackPage() {
+  const navigate = useNavigate();
+  const { signInWithToken } = useAuth();
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    window.history.replaceState(null, '', window.location.pathname);
+
+    const token = params.get('token');
+    if (!token) {
+      setError(params.get('error') || 'Login SSO gagal');
+      return;
+    }
+    if (!signInWithToken(token, params.get('refresh_token') || undefined)) {
+      setError('Token dari server tidak valid');
+      return;
+    }
+    const redirect = params.get('redirect') || '/';
+    navigate(redirect.startsWith('/') && !redirect.startsWith('//') ? redirect : '/', { replace: true });
+  }, []);
+
+  return (
+    <Layout>
+      <div className="min-h-screen flex items-center justify-center bg-background p-4">
+        <Card className="w-full max-w-md">
+          <CardHeader>
+            <CardTitle>{error ? 'Login gagal' : 'Memproses login...'}</CardTitle>
+          </CardHeader>
+          {error && (
+            <CardContent className="space-y-4">
+              <p className="text-sm text-destructive">{error}</p>
+              <Button variant="link" asChild>
+                <Link to="/admin/login">← Kembali ke halaman login</Link>
+              </Button>
+            </CardContent>
+          )}
+        </Card>
+      </div>
+    </Layout>
+  );
+}
@@ -0,0 +1,63 @@
+import { useState } from 'react';
+import { useParams } from 'react-router-dom';
+import { format } from 'date-fns';
+import { Layout } from '../components/Layout/Layout';
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '../components/ui/Card';
+import { Button } from '../components/ui/Button';
+import { api } from '../utils/api';
+import type { Booking } from '../types/booking';
+
+// Halaman tujuan QR check-in di email. Kamera ponsel membuka link dengan GET,
+// jadi check-in baru dikirim (POST) setelah pemesan menekan tombol; pratinjau
+// link oleh aplikasi email juga tidak ikut melakukan check-in.
+export function CheckInPage() {
+  const { token } = useParams<{ token: string }>();
+  const [status, setStatus] = useState<'idle' | 'processing' | 'done' | 'error'>('idle');
+  const [booking, setBooking] = useState<Booking | null>(null);
+  const [error, setError] = useState<string | null>(null);
+
+  const handleCheckIn = async () => {
+    if (!token) return;
+    setStatus('processing');
+    setError(null);
+    try {
+      const res = await api(`/bookings/check-in/${encodeURIComponent(token)}`, { method: 'POST' });
+      setBooking(res.data);
+      setStatus('done');
+    } catch (err: any) {
+      setError(err.message || 'Check-in gagal');
+      setStatus('error');
+    }
+  };
+
+  return (
+    <Layout>
+      <div className="min-h-screen flex items-center justify-center bg-background p-4">
+        <Card className="w-full max-w-md">
+          <CardHeader>
+            <CardTitle>{status === 'done' ? 'Check-in berhasil' : 'Check-in meeting'}</CardTitle>
+            <CardDescription>
+              {status === 'done' ? 'Selamat meeting!' : 'Tekan tombol di bawah saat Anda sudah berada di ruangan.'}
+            </CardDescription>
+          </CardHeader>
+          <CardContent className="space-y-4">
+            {booking && (
+              <div className="text-sm space-y-1">
+                <p className="font-medium">{booking.purpose}</p>
+                <p className="text-muted-foreground">
+                  {format(new Date(booking.start_time), 'dd MMM yyyy HH:mm')} - {format(new Date(booking.end_time), 'HH:mm')}
+                </p>
+              </div>
+            )}
+            {error && <p className="text-sm text-destructive">{error}</p>}
+            {status !== 'done' && (
+              <Button className="w-full" onClick={handleCheckIn} disabled={!token || status === 'processing'}>
+                {status === 'processing' ? 'Memproses...' : 'Check-in sekarang'}
+              </Button>
+            )}
+          </CardContent>
+        </Card>
+      </div>
+    </Layout>
+  );
+}
@@ -1,3 +1,13 @@
+export type BookingStatus =
+  | 'pending'
+  | 'approved'
+  | 'rejected'
+  | 'cancelled'
+  | 'checked_in'
+  | 'completed'
+  | 'no_show'
+  | 'expired';
+
 export interface Booking {
   id: string;
   room_id: string;
@@ -6,14 +16,17 @@ export interface Booking {
   room_name: string;
   purpose: string;
   attendees: number;
-  status: 'pending' | 'approved' | 'rejected';
+  status: BookingStatus;
   start_time: string;
   end_time: string;
   approved_at?: string;
   qr_code_base64?: string;
+  extend_qr_code_base64?: string;
   is_overtime?: boolean;
   overtime_minutes?: number;
-  extended_until?: string;
+  extended_minutes?: number;
+  checked_in_at?: string;
+  ended_at?: string;
 }
 
 export type FilterType = 'all' | 'pending' | 'approved' | 'rejected';
@@ -1,6 +1,59 @@
 const API_URL = 'http://localhost:8080/api';
 
-export const api = async (endpoint: string, options: RequestInit = {}) => {
+// Dikirim saat refresh token ditolak sehingga AuthContext bisa mengosongkan user
+export const AUTH_EXPIRED_EVENT = 'auth:expired';
+
+export const saveTokens = (data: { token: string; refresh_token?: string }) => {
+  localStorage.setItem('token', data.token);
+  if (data.refresh_token) {
+    localStorage.setItem('refresh_token', data.refresh_token);
+  }
+};
+
+export const clearTokens = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refresh_token');
+};
+
+// 401 dari endpoint ini berarti kredensial salah, bukan access token kedaluwarsa
+const NO_REFRESH_ENDPOINTS = ['/admin/login', '/auth/login', '/auth/refresh', '/auth/logout'];
+
+let refreshing: Promise<boolean> | null = null;
+
+// Menukar refresh token dengan access token baru. Hanya satu refresh yang
+// berjalan sekaligus karena refresh token lama langsung tidak berlaku.
+export const refreshAccessToken = (): Promise<boolean> => {
+  if (refreshing) return refreshing;
+  const refreshToken = localStorage.getItem('refresh_token');
+  if (!refreshToken) return Promise.resolve(false);
+
+  refreshing = fetch(`${API_URL}/auth/refresh`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ refresh_token: refreshToken }),
+  })
+    .then(async (res) => {
+      const data = await res.json();
+      if (res.ok && data.data?.token) {
+        saveTokens(data.data);
+        return true;
+      }
+      // Tab lain sudah lebih dulu me-refresh: pakai token miliknya
+      if (localStorage.getItem('refresh_token') !== refreshToken) {
+        return true;
+      }
+      clearTokens();
+      window.dispatchEvent(new Event(AUTH_EXPIRED_EVENT));
+      return false;
+    })
+    .catch(() => false)
+    .finally(() => {
+      refreshing = null;
+    });
+  return refreshing;
+};
+
+const request = (endpoint: string, options: RequestInit) => {
   const token = localStorage.getItem('token');
   return fetch(`${API_URL}${endpoint}`, {
     ...options,
@@ -9,14 +62,23 @@ export const api = async (endpoint: string, options: RequestInit = {}) => {
       ...(token ? { Authorization: `Bearer ${token}` } : {}),
       ...options.headers,
     },
-  }).then(async (res) => {
-    const data = await res.json();
-    if (!res.ok) {
-      const error: any = new Error(data.message || 'API Error');
-      error.status = res.status;
-      error.data = data;
-      throw error;
-    }
-    return data;
   });
-}; 
\ No newline at end of file
+};
+
+export const api = async (endpoint: string, options: RequestInit = {}) => {
+  let res = await request(endpoint, options);
+  // Access token berumur pendek: refresh sekali lalu ulangi request
+  if (res.status === 401 && localStorage.getItem('refresh_token') && !NO_REFRESH_ENDPOINTS.includes(endpoint)) {
+    if (await refreshAccessToken()) {
+      res = await request(endpoint, options);
+    }
+  }
+  const data = await res.json();
+  if (!res.ok) {
+    const error: any = new Error(data.message || 'API Error');
+    error.status = res.status;
+    error.data = data;
+    throw error;
+  }
+  return data;
+};